@@ -0,0 +1,42 @@
+// Package admin implements the admin interface of a running banserver, a unix socket that
+// speaks json lines: every request is a single json object per line, which is answered with a single json
+// object per line.
+//
+//	{"command":"test-ip","params":{"ips":["1.2.3.4"]}}
+//	{"result":[{"ip":"1.2.3.4","matches":[...]}]}
+//
+// Streaming commands answer with one event per line until the client disconnects,
+// which also closes the connection on the server side.
+//
+//	{"command":"events"}
+//	{"event":{...}}
+//	{"event":{...}}
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Request is a single command that is sent to the admin interface.
+type Request struct {
+	Command string          `json:"command"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// Response is the answer to a request, either a result, an event of a stream or an error.
+type Response struct {
+	Result json.RawMessage `json:"result,omitempty"`
+	Event  json.RawMessage `json:"event,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// Error is returned by the client in case that the banserver failed to execute a command.
+type Error struct {
+	Command string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("admin command %s failed: %s", e.Command, e.Message)
+}
@@ -0,0 +1,197 @@
+package admin_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jxsl13/banserver/admin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type echoParams struct {
+	Text string `json:"text"`
+}
+
+type echoResult struct {
+	Text string `json:"text"`
+}
+
+// socketPath returns a short socket path, as unix socket paths are limited to about 100 bytes.
+func socketPath(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "admin")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return filepath.Join(dir, "admin.sock")
+}
+
+// streamDone is notified whenever a stream handler returns, e.g. due to a disconnected client.
+var streamDone = make(chan struct{}, 1)
+
+func startServer(t *testing.T, path string) *admin.Server {
+	t.Helper()
+
+	srv := admin.NewServer()
+	admin.Handle(srv, "echo", func(_ context.Context, p echoParams) (echoResult, error) {
+		if p.Text == "" {
+			return echoResult{}, errors.New("missing text")
+		}
+		return echoResult{Text: strings.ToUpper(p.Text)}, nil
+	})
+	admin.HandleStream(srv, "count", func(ctx context.Context, p echoParams, send func(int) error) error {
+		if p.Text == "fail" {
+			return errors.New("stream failed")
+		}
+		defer func() {
+			streamDone <- struct{}{}
+		}()
+
+		for i := 1; ; i++ {
+			if err := send(i); err != nil {
+				return err
+			}
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(time.Millisecond):
+			}
+		}
+	})
+
+	listener, err := admin.Listen(path)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Serve(ctx, listener)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-done)
+		// the socket file is removed
+		_, err := os.Stat(path)
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+	return srv
+}
+
+func TestAdmin(t *testing.T) {
+	path := socketPath(t)
+	srv := startServer(t, path)
+	assert.Equal(t, []string{"count", "echo"}, srv.Commands())
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client, err := admin.Dial(ctx, path)
+	require.NoError(t, err)
+	defer client.Close()
+
+	var result echoResult
+	require.NoError(t, client.Call(ctx, "echo", echoParams{Text: "hello"}, &result))
+	assert.Equal(t, "HELLO", result.Text)
+
+	var adminErr *admin.Error
+	err = client.Call(ctx, "echo", echoParams{}, &result)
+	require.ErrorAs(t, err, &adminErr)
+	assert.Equal(t, "missing text", adminErr.Message)
+
+	err = client.Call(ctx, "unknown", nil, nil)
+	require.ErrorAs(t, err, &adminErr)
+
+	err = client.Call(ctx, "echo", map[string]int{"text": 1}, &result)
+	require.ErrorAs(t, err, &adminErr)
+
+	// the connection is still usable after errors
+	require.NoError(t, client.Call(ctx, "echo", echoParams{Text: "again"}, &result))
+	assert.Equal(t, "AGAIN", result.Text)
+}
+
+func TestAdminSocketInUse(t *testing.T) {
+	path := socketPath(t)
+	startServer(t, path)
+
+	_, err := admin.Listen(path)
+	require.Error(t, err)
+
+	file := filepath.Join(filepath.Dir(path), "file")
+	require.NoError(t, os.WriteFile(file, nil, 0o600))
+	_, err = admin.Listen(file)
+	require.Error(t, err)
+}
+
+func TestAdminStaleSocket(t *testing.T) {
+	path := socketPath(t)
+	listener, err := admin.Listen(path)
+	require.NoError(t, err)
+
+	// a crashed banserver leaves its socket file behind
+	listener.(interface{ SetUnlinkOnClose(bool) }).SetUnlinkOnClose(false)
+	require.NoError(t, listener.Close())
+	_, err = os.Stat(path)
+	require.NoError(t, err)
+
+	listener, err = admin.Listen(path)
+	require.NoError(t, err)
+	require.NoError(t, listener.Close())
+}
+
+func TestAdminStream(t *testing.T) {
+	path := socketPath(t)
+	startServer(t, path)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client, err := admin.Dial(ctx, path)
+	require.NoError(t, err)
+
+	var events []string
+	errStop := errors.New("stop")
+	err = client.Stream(ctx, "count", nil, func(event json.RawMessage) error {
+		events = append(events, string(event))
+		if len(events) == 3 {
+			return errStop
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, errStop)
+	assert.Equal(t, []string{"1", "2", "3"}, events)
+
+	// the handler stops once the client disconnected
+	select {
+	case <-streamDone:
+	case <-ctx.Done():
+		t.Fatal("stream handler did not stop after the client disconnected")
+	}
+
+	client, err = admin.Dial(ctx, path)
+	require.NoError(t, err)
+	var adminErr *admin.Error
+	err = client.Stream(ctx, "count", echoParams{Text: "fail"}, func(json.RawMessage) error { return nil })
+	require.ErrorAs(t, err, &adminErr)
+	assert.Equal(t, "stream failed", adminErr.Message)
+
+	// canceling the context ends the stream
+	client, err = admin.Dial(ctx, path)
+	require.NoError(t, err)
+	streamCtx, streamCancel := context.WithCancel(ctx)
+	err = client.Stream(streamCtx, "count", nil, func(json.RawMessage) error {
+		streamCancel()
+		return nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	<-streamDone
+}
@@ -0,0 +1,147 @@
+package admin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// Client sends commands to the admin interface of a running banserver.
+type Client struct {
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// Dial connects to the admin socket at path.
+func Dial(ctx context.Context, path string) (*Client, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to admin socket, is the banserver running with an admin socket? %w", err)
+	}
+	return &Client{
+		conn:   conn,
+		reader: bufio.NewReader(conn),
+	}, nil
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Call executes the command with the given params and decodes its result into result, which may be nil.
+func (c *Client) Call(ctx context.Context, command string, params, result any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stop, err := c.send(ctx, command, params)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	resp, err := c.receive(ctx, command)
+	if err != nil {
+		return err
+	}
+
+	if result == nil || len(resp.Result) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(resp.Result, result); err != nil {
+		return fmt.Errorf("invalid result of admin command %s: %w", command, err)
+	}
+	return nil
+}
+
+// Stream executes a streaming command and calls fn with every event until the context is canceled,
+// fn returns an error or the banserver closes the connection. The banserver closes the connection
+// after a stream, which is why the client cannot be used anymore afterwards.
+func (c *Client) Stream(ctx context.Context, command string, params any, fn func(event json.RawMessage) error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer c.conn.Close()
+
+	stop, err := c.send(ctx, command, params)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	for {
+		resp, err := c.receive(ctx, command)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		if len(resp.Event) == 0 {
+			return nil
+		}
+
+		err = fn(resp.Event)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// send writes the request, the returned function must be called once the response was received.
+func (c *Client) send(ctx context.Context, command string, params any) (stop func() bool, err error) {
+	req := Request{Command: command}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode params of admin command %s: %w", command, err)
+		}
+		req.Params = data
+	}
+
+	// no deadline resets the deadline of the previous call
+	deadline, _ := ctx.Deadline()
+	if err := c.conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+	stop = context.AfterFunc(ctx, func() {
+		// unblock reads and writes
+		_ = c.conn.SetDeadline(time.Unix(1, 0))
+	})
+
+	err = json.NewEncoder(c.conn).Encode(req)
+	if err != nil {
+		stop()
+		return nil, ctxErr(ctx, fmt.Errorf("failed to send admin command %s: %w", command, err))
+	}
+	return stop, nil
+}
+
+// receive reads a single response, errors of the banserver are returned as *Error.
+func (c *Client) receive(ctx context.Context, command string) (Response, error) {
+	line, err := c.reader.ReadBytes('\n')
+	if err != nil {
+		return Response{}, ctxErr(ctx, fmt.Errorf("failed to read response of admin command %s: %w", command, err))
+	}
+
+	var resp Response
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return Response{}, fmt.Errorf("invalid response of admin command %s: %w", command, err)
+	}
+	if resp.Error != "" {
+		return Response{}, &Error{Command: command, Message: resp.Error}
+	}
+	return resp, nil
+}
+
+func ctxErr(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return errors.Join(ctxErr, err)
+	}
+	return err
+}
@@ -0,0 +1,244 @@
+package admin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net"
+	"os"
+	"sort"
+	"sync"
+)
+
+// maxRequestSize limits the size of a single request line.
+const maxRequestSize = 1024 * 1024
+
+type (
+	handlerFunc func(ctx context.Context, params json.RawMessage) (any, error)
+	streamFunc  func(ctx context.Context, params json.RawMessage, send func(event any) error) error
+)
+
+// handler is either a command with a single result or a streaming command.
+type handler struct {
+	call   handlerFunc
+	stream streamFunc
+}
+
+// Server executes the commands that are received via the admin socket.
+type Server struct {
+	mu       sync.RWMutex
+	handlers map[string]handler
+}
+
+func NewServer() *Server {
+	return &Server{
+		handlers: make(map[string]handler),
+	}
+}
+
+func decodeParams[P any](raw json.RawMessage) (params P, err error) {
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return params, fmt.Errorf("invalid params: %w", err)
+		}
+	}
+	return params, nil
+}
+
+// Handle registers the handler of a command. The params of the request are decoded into P
+// and the result R is encoded as result of the response.
+func Handle[P, R any](s *Server, command string, fn func(ctx context.Context, params P) (R, error)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[command] = handler{call: func(ctx context.Context, raw json.RawMessage) (any, error) {
+		params, err := decodeParams[P](raw)
+		if err != nil {
+			return nil, err
+		}
+		return fn(ctx, params)
+	}}
+}
+
+// HandleStream registers the handler of a streaming command, which sends events of type E until its context
+// is canceled. The context is canceled as soon as the client disconnects.
+func HandleStream[P, E any](s *Server, command string, fn func(ctx context.Context, params P, send func(E) error) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[command] = handler{stream: func(ctx context.Context, raw json.RawMessage, send func(any) error) error {
+		params, err := decodeParams[P](raw)
+		if err != nil {
+			return err
+		}
+		return fn(ctx, params, func(event E) error {
+			return send(event)
+		})
+	}}
+}
+
+// Commands returns the names of all registered commands.
+func (s *Server) Commands() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	commands := make([]string, 0, len(s.handlers))
+	for command := range s.handlers {
+		commands = append(commands, command)
+	}
+	sort.Strings(commands)
+	return commands
+}
+
+// Listen listens on the unix socket at path, a stale socket file of a previous instance is removed.
+// The socket is only accessible by the owner of the banserver process.
+func Listen(path string) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode().Type() != fs.ModeSocket {
+			return nil, fmt.Errorf("admin socket %s already exists and is not a socket", path)
+		}
+		// another banserver might still be listening
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("admin socket %s is already in use", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale admin socket: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on admin socket: %w", err)
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to restrict access to admin socket: %w", err)
+	}
+	return listener, nil
+}
+
+// Serve accepts connections until the context is canceled or the listener is closed, which removes the socket file.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.serveConn(ctx, conn)
+		}()
+	}
+}
+
+func (s *Server) serveConn(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+
+	// unblock the scanner when the server shuts down
+	stop := context.AfterFunc(ctx, func() {
+		conn.Close()
+	})
+	defer stop()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRequestSize)
+	enc := json.NewEncoder(conn)
+
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var resp Response
+		req, h, err := s.parse(scanner.Bytes())
+		switch {
+		case err != nil:
+			resp = Response{Error: err.Error()}
+		case h.stream != nil:
+			// a stream ends the connection
+			s.stream(ctx, conn, enc, req, h.stream)
+			return
+		default:
+			resp = call(ctx, req, h.call)
+		}
+
+		if err := enc.Encode(resp); err != nil {
+			log.Printf("failed to write admin response: %v", err)
+			return
+		}
+	}
+
+	if err := scanner.Err(); err != nil && ctx.Err() == nil && !errors.Is(err, net.ErrClosed) {
+		log.Printf("failed to read admin request: %v", err)
+	}
+}
+
+func (s *Server) parse(line []byte) (Request, handler, error) {
+	var req Request
+	if err := json.Unmarshal(line, &req); err != nil {
+		return req, handler{}, fmt.Errorf("invalid request: %w", err)
+	}
+
+	s.mu.RLock()
+	h, ok := s.handlers[req.Command]
+	s.mu.RUnlock()
+	if !ok {
+		return req, handler{}, fmt.Errorf("unknown command %q", req.Command)
+	}
+	return req, h, nil
+}
+
+func call(ctx context.Context, req Request, fn handlerFunc) Response {
+	result, err := fn(ctx, req.Params)
+	if err != nil {
+		return Response{Error: err.Error()}
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return Response{Error: fmt.Sprintf("failed to encode result: %v", err)}
+	}
+	return Response{Result: data}
+}
+
+// stream sends the events of a streaming command until the client disconnects or the server shuts down.
+func (s *Server) stream(ctx context.Context, conn net.Conn, enc *json.Encoder, req Request, fn streamFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// clients do not send anything during a stream, the read returns as soon as the client disconnects
+	go func() {
+		_, _ = conn.Read(make([]byte, 1))
+		cancel()
+	}()
+
+	err := fn(ctx, req.Params, func(event any) error {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to encode event: %w", err)
+		}
+		return enc.Encode(Response{Event: data})
+	})
+	if err != nil && ctx.Err() == nil {
+		_ = enc.Encode(Response{Error: err.Error()})
+	}
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/jxsl13/banserver/admin"
+	"github.com/jxsl13/banserver/config"
+	"github.com/jxsl13/banserver/econ"
+	"github.com/jxsl13/banserver/model"
+	"github.com/jxsl13/banserver/parser"
+)
+
+// admin commands
+const (
+	adminTestIP    = "test-ip"
+	adminTestChat  = "test-chat"
+	adminServers   = "servers"
+	adminPlayers   = "players"
+	adminBan       = "ban"
+	adminUnban     = "unban"
+	adminSay       = "say"
+	adminBroadcast = "broadcast"
+	adminEvents    = "events"
+	adminReload    = "reload"
+	adminRcon      = "rcon"
+)
+
+// default and maximum time to collect the output of rcon commands
+const (
+	defaultRconTimeout = 2 * time.Second
+	maxRconTimeout     = time.Minute
+)
+
+type testIPParams struct {
+	IPs []netip.Addr `json:"ips"`
+}
+
+type testIPResult struct {
+	IP      netip.Addr            `json:"ip"`
+	Matches []model.SourcedPrefix `json:"matches"`
+}
+
+type testChatParams struct {
+	Message string `json:"message"`
+	Scope   string `json:"scope"` // public, team or whisper
+}
+
+type testChatResult struct {
+	Matches []model.ChatMatch `json:"matches"`
+}
+
+// serverParams selects a single game server by its name or address, all servers if empty.
+type serverParams struct {
+	Server string `json:"server,omitempty"`
+}
+
+type serverInfo struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	Group   string `json:"group,omitempty"`
+	Flavor  string `json:"flavor"`
+	Players int    `json:"players"`
+}
+
+type serverPlayers struct {
+	Server  string        `json:"server"`
+	Players []econ.Client `json:"players"`
+}
+
+type banParams struct {
+	Server   string     `json:"server,omitempty"`
+	IP       netip.Addr `json:"ip"`
+	Duration string     `json:"duration,omitempty"` // e.g. 1h, 0 for permanent bans, defaults to the perma ban duration
+	Reason   string     `json:"reason,omitempty"`   // defaults to the perma ban reason
+}
+
+type messageParams struct {
+	Server  string `json:"server,omitempty"`
+	Message string `json:"message"`
+}
+
+// actionResult contains the names of the servers the command was sent to.
+type actionResult struct {
+	Servers []string `json:"servers"`
+}
+
+// rconParams selects servers by their names, addresses or groups, all servers if empty.
+type rconParams struct {
+	Servers []string `json:"servers,omitempty"`
+	Command string   `json:"command"`
+	Timeout string   `json:"timeout,omitempty"` // e.g. 5s, defaults to 2s
+}
+
+type adminHandlers struct {
+	broker *model.Broker
+	cfg    *config.Config
+}
+
+// newAdminServer returns the admin interface of the running banserver.
+func newAdminServer(broker *model.Broker, cfg *config.Config) *admin.Server {
+	h := &adminHandlers{broker: broker, cfg: cfg}
+
+	srv := admin.NewServer()
+	admin.Handle(srv, adminTestIP, func(_ context.Context, p testIPParams) ([]testIPResult, error) {
+		return testIP(broker, p)
+	})
+	admin.Handle(srv, adminTestChat, func(_ context.Context, p testChatParams) (testChatResult, error) {
+		return testChat(broker, p)
+	})
+	admin.Handle(srv, adminServers, h.servers)
+	admin.Handle(srv, adminPlayers, h.players)
+	admin.Handle(srv, adminBan, h.ban)
+	admin.Handle(srv, adminUnban, h.unban)
+	admin.Handle(srv, adminSay, h.message((*econ.Server).Say))
+	admin.Handle(srv, adminBroadcast, h.message((*econ.Server).Broadcast))
+	admin.HandleStream(srv, adminEvents, h.events)
+	admin.Handle(srv, adminReload, h.reload)
+	admin.Handle(srv, adminRcon, h.rcon)
+	return srv
+}
+
+func testIP(broker *model.Broker, p testIPParams) ([]testIPResult, error) {
+	results := make([]testIPResult, 0, len(p.IPs))
+	for _, ip := range p.IPs {
+		matches, err := broker.MatchIP(ip)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, testIPResult{IP: ip, Matches: matches})
+	}
+	return results, nil
+}
+
+func testChat(broker *model.Broker, p testChatParams) (testChatResult, error) {
+	chat := parser.ChatMessage{Message: p.Message}
+	switch p.Scope {
+	case "", model.ChatScopePublic:
+		chat.TargetID = parser.ChatTargetPublic
+	case model.ChatScopeTeam:
+		chat.TargetID = parser.ChatTargetTeam
+	case model.ChatScopeWhisper:
+		chat.TargetID = 0
+	default:
+		return testChatResult{}, fmt.Errorf("invalid chat scope %q, must be one of public, team or whisper", p.Scope)
+	}
+	return testChatResult{Matches: broker.MatchChat(chat)}, nil
+}
+
+// targets returns the selected server or all servers.
+func (h *adminHandlers) targets(server string) ([]*econ.Server, error) {
+	if server == "" {
+		return h.broker.Servers(), nil
+	}
+	s, ok := h.broker.Server(server)
+	if !ok {
+		return nil, fmt.Errorf("unknown server %q", server)
+	}
+	return []*econ.Server{s}, nil
+}
+
+func names(servers []*econ.Server) []string {
+	names := make([]string, 0, len(servers))
+	for _, s := range servers {
+		names = append(names, s.Name())
+	}
+	return names
+}
+
+func (h *adminHandlers) servers(context.Context, struct{}) ([]serverInfo, error) {
+	servers := h.broker.Servers()
+	infos := make([]serverInfo, 0, len(servers))
+	for _, s := range servers {
+		infos = append(infos, serverInfo{
+			Name:    s.Name(),
+			Address: s.AddressPort(),
+			Group:   s.Group(),
+			Flavor:  string(s.Flavor()),
+			Players: len(s.Clients()),
+		})
+	}
+	return infos, nil
+}
+
+func (h *adminHandlers) players(_ context.Context, p serverParams) ([]serverPlayers, error) {
+	servers, err := h.targets(p.Server)
+	if err != nil {
+		return nil, err
+	}
+
+	players := make([]serverPlayers, 0, len(servers))
+	for _, s := range servers {
+		players = append(players, serverPlayers{Server: s.Name(), Players: s.Clients()})
+	}
+	return players, nil
+}
+
+func (h *adminHandlers) ban(_ context.Context, p banParams) (actionResult, error) {
+	if !p.IP.IsValid() {
+		return actionResult{}, errors.New("missing ip")
+	}
+
+	duration, reason := h.cfg.PermaBanDuration, h.cfg.PermaBanReason
+	if p.Duration != "" {
+		d, err := time.ParseDuration(p.Duration)
+		if err != nil {
+			return actionResult{}, fmt.Errorf("invalid ban duration: %w", err)
+		}
+		if d != 0 && d < time.Minute {
+			return actionResult{}, errors.New("ban duration must be at least 1m or 0 for permanent bans")
+		}
+		duration = d
+	}
+	if p.Reason != "" {
+		reason = p.Reason
+	}
+
+	servers, err := h.targets(p.Server)
+	if err != nil {
+		return actionResult{}, err
+	}
+
+	if p.Server == "" {
+		err = h.broker.BanOnAll("", p.IP, duration, reason)
+	} else {
+		err = h.broker.BanOn(servers[0], p.IP, duration, reason)
+	}
+	if err != nil {
+		return actionResult{}, err
+	}
+	return actionResult{Servers: names(servers)}, nil
+}
+
+func (h *adminHandlers) unban(_ context.Context, p banParams) (actionResult, error) {
+	if !p.IP.IsValid() {
+		return actionResult{}, errors.New("missing ip")
+	}
+
+	servers, err := h.targets(p.Server)
+	if err != nil {
+		return actionResult{}, err
+	}
+
+	if p.Server == "" {
+		err = h.broker.UnbanOnAll("", p.IP)
+	} else {
+		err = servers[0].UnbanIP(servers[0].AddressPort(), p.IP)
+	}
+	if err != nil {
+		return actionResult{}, err
+	}
+	return actionResult{Servers: names(servers)}, nil
+}
+
+// message returns a handler that sends a message to the selected servers.
+func (h *adminHandlers) message(send func(s *econ.Server, message string) error) func(context.Context, messageParams) (actionResult, error) {
+	return func(_ context.Context, p messageParams) (actionResult, error) {
+		if p.Message == "" {
+			return actionResult{}, errors.New("missing message")
+		}
+
+		servers, err := h.targets(p.Server)
+		if err != nil {
+			return actionResult{}, err
+		}
+
+		for _, s := range servers {
+			err = send(s, p.Message)
+			if err != nil {
+				return actionResult{}, err
+			}
+		}
+		return actionResult{Servers: names(servers)}, nil
+	}
+}
+
+// events sends the events of the selected servers until the client disconnects.
+func (h *adminHandlers) events(ctx context.Context, p serverParams, send func(model.Event) error) error {
+	name := ""
+	if p.Server != "" {
+		s, ok := h.broker.Server(p.Server)
+		if !ok {
+			return fmt.Errorf("unknown server %q", p.Server)
+		}
+		name = s.Name()
+	}
+
+	events, unsubscribe := h.broker.SubscribeEvents(256)
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-events:
+			if name != "" && event.Server != name {
+				continue
+			}
+			err := send(event)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (h *adminHandlers) reload(ctx context.Context, _ struct{}) (struct{}, error) {
+	return struct{}{}, h.broker.ReloadBlacklists(ctx)
+}
+
+func (h *adminHandlers) rcon(ctx context.Context, p rconParams) ([]model.ExecResult, error) {
+	if strings.TrimSpace(p.Command) == "" {
+		return nil, errors.New("missing command")
+	}
+
+	timeout := defaultRconTimeout
+	if p.Timeout != "" {
+		d, err := time.ParseDuration(p.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout: %w", err)
+		}
+		if d <= 0 || d > maxRconTimeout {
+			return nil, fmt.Errorf("timeout must be between 0 and %s", maxRconTimeout)
+		}
+		timeout = d
+	}
+
+	servers, err := h.broker.SelectServers(p.Servers...)
+	if err != nil {
+		return nil, err
+	}
+	return h.broker.Exec(ctx, servers, p.Command, timeout), nil
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jxsl13/banserver/bans"
+	"github.com/jxsl13/banserver/model"
+	"github.com/spf13/cobra"
+)
+
+func NewBansCommand() *cobra.Command {
+	bansCmd := &cobra.Command{
+		Use:   "bans",
+		Short: "Convert between ip blacklists and ban lists of game servers",
+		Long: `Ban lists can be read and written in the following formats:
+
+	cfg:  DDNet bans_save format, lines of 'ban <ip> <minutes> <reason>' and 'ban_range <first> <last> <minutes> <reason>'
+	csv:  columns ip, minutes and reason
+	json: array of objects with the keys ip, minutes and reason
+
+The format is derived from the file extension unless --format is set.
+A duration of 0 minutes is a permanent ban.`,
+	}
+
+	bansCmd.AddCommand(newBansExportCommand())
+	bansCmd.AddCommand(newBansImportCommand())
+	return bansCmd
+}
+
+type bansExportOptions struct {
+	format  string
+	output  string
+	minutes int
+	reason  string
+}
+
+func newBansExportCommand() *cobra.Command {
+	opts := bansExportOptions{}
+	cmd := &cobra.Command{
+		Use:   "export [flags] <ip blacklist>...",
+		Short: "Export ip blacklists as ban list, e.g. in order to seed new servers with 'exec bans.cfg'",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.SetOutput(cmd.ErrOrStderr())
+			return opts.run(cmd.OutOrStdout(), args)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&opts.format, "format", "f", "", "ban list format: cfg, csv or json (default derived from --output, otherwise cfg)")
+	flags.StringVarP(&opts.output, "output", "o", "", "ban list file (default stdout)")
+	flags.IntVar(&opts.minutes, "minutes", 0, "ban duration in minutes, 0 is permanent")
+	flags.StringVar(&opts.reason, "reason", "banned", "ban reason of blacklist entries without comment")
+	return cmd
+}
+
+func (o *bansExportOptions) run(stdout io.Writer, blacklists []string) (err error) {
+	format, err := banFormat(o.format, o.output, bans.FormatDDNet)
+	if err != nil {
+		return err
+	}
+	if o.minutes < 0 {
+		return fmt.Errorf("invalid ban duration: %d minutes", o.minutes)
+	}
+
+	var list []bans.Ban
+	for _, path := range blacklists {
+		entries, err := readBlacklist(path)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			reason := entry.Comment
+			if reason == "" {
+				reason = o.reason
+			}
+			list = append(list, bans.FromPrefixes(entry.Prefixes, time.Duration(o.minutes)*time.Minute, reason)...)
+		}
+	}
+
+	w, closeFunc, err := createOutput(stdout, o.output, false)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, closeFunc())
+	}()
+
+	err = bans.Write(w, format, list)
+	if err != nil {
+		return fmt.Errorf("failed to write ban list: %w", err)
+	}
+	log.Printf("exported %d bans", len(list))
+	return nil
+}
+
+func readBlacklist(path string) ([]model.BlacklistEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries, err := model.ParseBlacklist(f)
+	for _, e := range unjoin(err) {
+		log.Printf("skipping invalid entry in %s: %v", path, e)
+	}
+	if len(entries) == 0 && err != nil {
+		return nil, fmt.Errorf("blacklist %s does not contain any valid entries", path)
+	}
+	return entries, nil
+}
+
+type bansImportOptions struct {
+	format           string
+	output           string
+	includeTemporary bool
+}
+
+func newBansImportCommand() *cobra.Command {
+	opts := bansImportOptions{}
+	cmd := &cobra.Command{
+		Use:   "import [flags] <ban list>...",
+		Short: "Import ban lists into an ip blacklist, e.g. the output of the DDNet bans_save command",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.SetOutput(cmd.ErrOrStderr())
+			return opts.run(cmd.OutOrStdout(), args)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&opts.format, "format", "f", "", "ban list format: cfg, csv or json (default derived from the file extension)")
+	flags.StringVarP(&opts.output, "output", "o", "", "ip blacklist file the bans are appended to (default stdout)")
+	flags.BoolVar(&opts.includeTemporary, "include-temporary", false, "import bans that are not permanent as well, which become permanent as blacklist entries never expire")
+	return cmd
+}
+
+func (o *bansImportOptions) run(stdout io.Writer, banLists []string) (err error) {
+	var list []bans.Ban
+	for _, path := range banLists {
+		format, err := banFormat(o.format, path, "")
+		if err != nil {
+			return err
+		}
+
+		banList, err := readBanList(path, format)
+		if err != nil {
+			return err
+		}
+		list = append(list, banList...)
+	}
+
+	w, closeFunc, err := createOutput(stdout, o.output, true)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, closeFunc())
+	}()
+
+	imported := 0
+	for _, ban := range list {
+		if ban.Duration > 0 {
+			if !o.includeTemporary {
+				continue
+			}
+			log.Printf("importing temporary ban of %s for %s as permanent blacklist entry", ban.IP(), ban.Duration)
+		}
+
+		line := ban.IP()
+		if reason := strings.Join(strings.Fields(ban.Reason), " "); reason != "" {
+			line += " # " + reason
+		}
+		_, err = fmt.Fprintln(w, line)
+		if err != nil {
+			return fmt.Errorf("failed to write ip blacklist: %w", err)
+		}
+		imported++
+	}
+	log.Printf("imported %d of %d bans", imported, len(list))
+	return nil
+}
+
+func readBanList(path string, format bans.Format) ([]bans.Ban, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	list, err := bans.Read(f, format)
+	for _, e := range unjoin(err) {
+		log.Printf("skipping invalid ban in %s: %v", path, e)
+	}
+	if len(list) == 0 && err != nil {
+		return nil, fmt.Errorf("ban list %s does not contain any valid bans", path)
+	}
+	return list, nil
+}
+
+// banFormat returns the explicitly set format, the format derived from the path or the fallback format.
+func banFormat(format, path string, fallback bans.Format) (bans.Format, error) {
+	if format != "" {
+		return bans.ParseFormat(format)
+	}
+	if path == "" && fallback != "" {
+		return fallback, nil
+	}
+	return bans.FormatFromPath(path)
+}
+
+// createOutput returns stdout in case that no path is given.
+func createOutput(stdout io.Writer, path string, appendOnly bool) (io.Writer, func() error, error) {
+	if path == "" {
+		return stdout, func() error { return nil }, nil
+	}
+
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if appendOnly {
+		flag = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	f, err := os.OpenFile(path, flag, 0o644)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, f.Close, nil
+}
+
+func unjoin(err error) []error {
+	if err == nil {
+		return nil
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap()
+	}
+	return []error{err}
+}
@@ -0,0 +1,131 @@
+package bans
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/jxsl13/banserver/parser"
+)
+
+// Ban bans a single ip or an ip range for a duration.
+type Ban struct {
+	First    netip.Addr
+	Last     netip.Addr    // equal to First in case of a single ip
+	Duration time.Duration // zero for permanent bans
+	Reason   string
+}
+
+// FromPrefix returns a ban of all ips of the prefix.
+func FromPrefix(prefix netip.Prefix, duration time.Duration, reason string) Ban {
+	prefix = prefix.Masked()
+	return Ban{
+		First:    prefix.Addr(),
+		Last:     parser.LastAddr(prefix),
+		Duration: duration,
+		Reason:   reason,
+	}
+}
+
+// FromPrefixes returns the bans of all ips of the prefixes, adjacent prefixes are merged into a single range.
+func FromPrefixes(prefixes []netip.Prefix, duration time.Duration, reason string) []Ban {
+	bans := make([]Ban, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		ban := FromPrefix(prefix, duration, reason)
+		if n := len(bans); n > 0 && bans[n-1].Last.Next() == ban.First {
+			bans[n-1].Last = ban.Last
+			continue
+		}
+		bans = append(bans, ban)
+	}
+	return bans
+}
+
+// IsRange returns true in case that more than a single ip is banned.
+func (b Ban) IsRange() bool {
+	return b.First != b.Last
+}
+
+// Minutes returns the ban duration in minutes as used by the ban commands, rounded up.
+func (b Ban) Minutes() int {
+	return int((b.Duration + time.Minute - 1) / time.Minute)
+}
+
+// IP returns the banned ip, CIDR or start-end range in the notation of ip blacklists.
+func (b Ban) IP() string {
+	if !b.IsRange() {
+		return b.First.String()
+	}
+
+	for bits := 0; bits <= b.First.BitLen(); bits++ {
+		prefix := netip.PrefixFrom(b.First, bits)
+		if prefix.Masked().Addr() == b.First && parser.LastAddr(prefix) == b.Last {
+			return prefix.String()
+		}
+	}
+	return b.First.String() + "-" + b.Last.String()
+}
+
+func (b Ban) String() string {
+	return fmt.Sprintf("%s %d %s", b.IP(), b.Minutes(), b.Reason)
+}
+
+var (
+	errInvalidRange   = errors.New("invalid ip range")
+	errInvalidMinutes = errors.New("invalid ban duration")
+)
+
+// parseIP parses a single ip, a CIDR or a start-end range.
+func parseIP(s string) (first, last netip.Addr, err error) {
+	s = strings.TrimSpace(s)
+
+	if start, end, found := strings.Cut(s, "-"); found {
+		first, err = parser.ParseIP(strings.TrimSpace(start))
+		if err != nil {
+			return first, last, err
+		}
+		last, err = parser.ParseIP(strings.TrimSpace(end))
+		if err != nil {
+			return first, last, err
+		}
+		if first.Is4() != last.Is4() || last.Less(first) {
+			return first, last, fmt.Errorf("%w: %s", errInvalidRange, s)
+		}
+		return first, last, nil
+	}
+
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return first, last, err
+		}
+		if prefix.Addr().Is4In6() && prefix.Bits() >= 96 {
+			prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+		}
+		prefix = prefix.Masked()
+		return prefix.Addr(), parser.LastAddr(prefix), nil
+	}
+
+	first, err = parser.ParseIP(s)
+	return first, first, err
+}
+
+func parseBan(ip string, minutes int, reason string) (Ban, error) {
+	first, last, err := parseIP(ip)
+	if err != nil {
+		return Ban{}, err
+	}
+	if minutes < 0 {
+		// DDNet saves permanent bans with negative durations
+		minutes = 0
+	}
+	return Ban{
+		First:    first,
+		Last:     last,
+		Duration: time.Duration(minutes) * time.Minute,
+		Reason:   strings.TrimSpace(reason),
+	}, nil
+}
+
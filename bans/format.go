@@ -0,0 +1,299 @@
+package bans
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/jxsl13/banserver/parser"
+)
+
+// Format is the file format of a ban list.
+type Format string
+
+const (
+	// FormatDDNet is the format of the DDNet bans_save command, one ban or ban_range command per line.
+	FormatDDNet Format = "cfg"
+	// FormatCSV has the columns ip, minutes and reason and a header line.
+	FormatCSV Format = "csv"
+	// FormatJSON is an array of objects with the keys ip, minutes and reason.
+	FormatJSON Format = "json"
+)
+
+// Formats returns all supported formats.
+func Formats() []Format {
+	return []Format{FormatDDNet, FormatCSV, FormatJSON}
+}
+
+// ParseFormat parses the name of a format.
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats() {
+		if strings.EqualFold(s, string(f)) {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("invalid ban format %q, must be one of cfg, csv or json", s)
+}
+
+// FormatFromPath derives the format from the file extension.
+func FormatFromPath(path string) (Format, error) {
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	if ext == "" {
+		return "", fmt.Errorf("cannot derive ban format of %q without file extension", path)
+	}
+	return ParseFormat(ext)
+}
+
+// Read parses a ban list. All valid bans are returned, invalid lines or records are returned as joined errors.
+func Read(r io.Reader, format Format) ([]Ban, error) {
+	switch format {
+	case FormatDDNet:
+		return readDDNet(r)
+	case FormatCSV:
+		return readCSV(r)
+	case FormatJSON:
+		return readJSON(r)
+	default:
+		return nil, fmt.Errorf("invalid ban format %q", format)
+	}
+}
+
+// Write writes a ban list that can be read with Read.
+func Write(w io.Writer, format Format, bans []Ban) error {
+	switch format {
+	case FormatDDNet:
+		return writeDDNet(w, bans)
+	case FormatCSV:
+		return writeCSV(w, bans)
+	case FormatJSON:
+		return writeJSON(w, bans)
+	default:
+		return fmt.Errorf("invalid ban format %q", format)
+	}
+}
+
+// readDDNet parses lines of 'ban <ip> <minutes> <reason>' and 'ban_range <first> <last> <minutes> <reason>'.
+// Minutes and reason are optional like for the console commands, bans without minutes last 30 minutes.
+func readDDNet(r io.Reader) (bans []Ban, err error) {
+	var (
+		errs    []error
+		lineNum int
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		ban, err := parseDDNetLine(line)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w: %q", lineNum, err, line))
+			continue
+		}
+		bans = append(bans, ban)
+	}
+
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, err)
+	}
+	return bans, errors.Join(errs...)
+}
+
+func parseDDNetLine(line string) (Ban, error) {
+	command, args, _ := strings.Cut(line, " ")
+
+	var ip string
+	switch command {
+	case "ban":
+		ip, args, _ = strings.Cut(strings.TrimSpace(args), " ")
+	case "ban_range":
+		var first, last string
+		first, args, _ = strings.Cut(strings.TrimSpace(args), " ")
+		last, args, _ = strings.Cut(strings.TrimSpace(args), " ")
+		ip = first + "-" + last
+	default:
+		return Ban{}, fmt.Errorf("unsupported command %q", command)
+	}
+
+	minutes := 30
+	value, reason, _ := strings.Cut(strings.TrimSpace(args), " ")
+	if value != "" {
+		var err error
+		minutes, err = strconv.Atoi(value)
+		if err != nil {
+			return Ban{}, fmt.Errorf("%w: %q", errInvalidMinutes, value)
+		}
+	}
+
+	return parseBan(ip, minutes, ddnetUnquote(strings.TrimSpace(reason)))
+}
+
+func writeDDNet(w io.Writer, bans []Ban) error {
+	bw := bufio.NewWriter(w)
+	for _, ban := range bans {
+		var line string
+		if ban.IsRange() {
+			line = fmt.Sprintf("ban_range %s %s %d",
+				parser.FormatIP(ban.First),
+				parser.FormatIP(ban.Last),
+				ban.Minutes(),
+			)
+		} else {
+			line = fmt.Sprintf("ban %s %d", parser.FormatIP(ban.First), ban.Minutes())
+		}
+
+		if reason := ddnetReason(ban.Reason); reason != "" {
+			line += " " + reason
+		}
+		_, err := bw.WriteString(line + "\n")
+		if err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// ddnetReason quotes reasons that would otherwise be interpreted by the console,
+// e.g. as command separator or comment.
+func ddnetReason(reason string) string {
+	reason = strings.Join(strings.Fields(reason), " ")
+	if strings.ContainsAny(reason, `;#"\`) {
+		// the console only unescapes quotes and backslashes, unlike strconv.Quote
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(reason) + `"`
+	}
+	return reason
+}
+
+// ddnetUnquote removes the quotes of a reason that was written by ddnetReason.
+func ddnetUnquote(reason string) string {
+	if len(reason) < 2 || reason[0] != '"' || reason[len(reason)-1] != '"' {
+		return reason
+	}
+	return strings.NewReplacer(`\\`, `\`, `\"`, `"`).Replace(reason[1 : len(reason)-1])
+}
+
+var csvHeader = []string{"ip", "minutes", "reason"}
+
+func readCSV(r io.Reader) (bans []Ban, err error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	cr.Comment = '#'
+
+	var (
+		errs  []error
+		first = true
+	)
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return bans, errors.Join(append(errs, err)...)
+			}
+			errs = append(errs, err)
+			continue
+		}
+
+		isHeader := first && strings.EqualFold(record[0], csvHeader[0])
+		first = false
+		if isHeader {
+			continue
+		}
+
+		ban, err := parseCSVRecord(record)
+		if err != nil {
+			line, _ := cr.FieldPos(0)
+			errs = append(errs, fmt.Errorf("line %d: %w: %q", line, err, strings.Join(record, ",")))
+			continue
+		}
+		bans = append(bans, ban)
+	}
+	return bans, errors.Join(errs...)
+}
+
+func parseCSVRecord(record []string) (Ban, error) {
+	var minutes int
+	if len(record) > 1 && record[1] != "" {
+		var err error
+		minutes, err = strconv.Atoi(strings.TrimSpace(record[1]))
+		if err != nil {
+			return Ban{}, fmt.Errorf("%w: %q", errInvalidMinutes, record[1])
+		}
+	}
+
+	var reason string
+	if len(record) > 2 {
+		reason = record[2]
+	}
+	return parseBan(record[0], minutes, reason)
+}
+
+func writeCSV(w io.Writer, bans []Ban) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write(csvHeader)
+	if err != nil {
+		return err
+	}
+
+	for _, ban := range bans {
+		err = cw.Write([]string{ban.IP(), strconv.Itoa(ban.Minutes()), ban.Reason})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+type jsonBan struct {
+	IP      string `json:"ip"`
+	Minutes int    `json:"minutes"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+func readJSON(r io.Reader) (bans []Ban, err error) {
+	var records []jsonBan
+	err = json.NewDecoder(r).Decode(&records)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode bans: %w", err)
+	}
+
+	var errs []error
+	for idx, record := range records {
+		ban, err := parseBan(record.IP, record.Minutes, record.Reason)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("ban %d: %w: %q", idx, err, record.IP))
+			continue
+		}
+		bans = append(bans, ban)
+	}
+	return bans, errors.Join(errs...)
+}
+
+func writeJSON(w io.Writer, bans []Ban) error {
+	records := make([]jsonBan, 0, len(bans))
+	for _, ban := range bans {
+		records = append(records, jsonBan{
+			IP:      ban.IP(),
+			Minutes: ban.Minutes(),
+			Reason:  ban.Reason,
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
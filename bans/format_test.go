@@ -0,0 +1,170 @@
+package bans_test
+
+import (
+	"bytes"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jxsl13/banserver/bans"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ban(first, last string, minutes int, reason string) bans.Ban {
+	if last == "" {
+		last = first
+	}
+	return bans.Ban{
+		First:    netip.MustParseAddr(first),
+		Last:     netip.MustParseAddr(last),
+		Duration: time.Duration(minutes) * time.Minute,
+		Reason:   reason,
+	}
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  bans.Format
+		input   string
+		want    []bans.Ban
+		wantErr int
+	}{
+		{
+			name:   "ddnet bans_save",
+			format: bans.FormatDDNet,
+			input: `ban 1.2.3.4 60 spamming
+ban [2001:db8::1] -1 permanent ban
+ban_range 1.2.3.0 1.2.3.255 0 "bots; again"
+ban 5.6.7.8
+
+# comment
+ban 1.2.3.4 abc reason
+ban_range 1.2.3.255 1.2.3.0 0
+kick 1 reason`,
+			want: []bans.Ban{
+				ban("1.2.3.4", "", 60, "spamming"),
+				ban("2001:db8::1", "", 0, "permanent ban"),
+				ban("1.2.3.0", "1.2.3.255", 0, "bots; again"),
+				ban("5.6.7.8", "", 30, ""),
+			},
+			wantErr: 3,
+		},
+		{
+			name:   "csv",
+			format: bans.FormatCSV,
+			input: `ip,minutes,reason
+1.2.3.4,60,"spamming, again"
+10.0.0.0/8,0,private
+1.2.3.4-1.2.3.9,5,range
+::ffff:1.2.3.5
+invalid,0,reason
+1.2.3.4,1h,reason`,
+			want: []bans.Ban{
+				ban("1.2.3.4", "", 60, "spamming, again"),
+				ban("10.0.0.0", "10.255.255.255", 0, "private"),
+				ban("1.2.3.4", "1.2.3.9", 5, "range"),
+				ban("1.2.3.5", "", 0, ""),
+			},
+			wantErr: 2,
+		},
+		{
+			name:   "json",
+			format: bans.FormatJSON,
+			input:  `[{"ip":"1.2.3.4","minutes":60,"reason":"spamming"},{"ip":"2001:db8::/32"},{"ip":"1.2.3"}]`,
+			want: []bans.Ban{
+				ban("1.2.3.4", "", 60, "spamming"),
+				ban("2001:db8::", "2001:db8:ffff:ffff:ffff:ffff:ffff:ffff", 0, ""),
+			},
+			wantErr: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := bans.Read(strings.NewReader(tt.input), tt.format)
+			assert.Equal(t, tt.want, got)
+
+			var errs []error
+			if err != nil {
+				errs = err.(interface{ Unwrap() []error }).Unwrap()
+			}
+			assert.Len(t, errs, tt.wantErr, err)
+		})
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	list := []bans.Ban{
+		ban("1.2.3.4", "", 60, "spamming"),
+		ban("2001:db8::1", "", 0, "permanent"),
+		ban("1.2.3.0", "1.2.3.255", 0, "bots # and ; more"),
+		ban("1.2.3.4", "1.2.3.9", 5, ""),
+		ban("5.6.7.8", "", 30, `très "méchant" \ #1`),
+	}
+
+	for _, format := range bans.Formats() {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, bans.Write(&buf, format, list))
+
+			got, err := bans.Read(&buf, format)
+			require.NoError(t, err)
+			assert.Equal(t, list, got)
+		})
+	}
+}
+
+func TestWriteDDNet(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, bans.Write(&buf, bans.FormatDDNet, []bans.Ban{
+		ban("1.2.3.4", "", 60, "spamming"),
+		ban("2001:db8::1", "", 0, "multi\nline"),
+		ban("1.2.3.0", "1.2.3.255", 0, "a;b"),
+		ban("5.6.7.8", "", 30, `très "méchant" \ #1`),
+		{First: netip.MustParseAddr("1.2.3.4"), Last: netip.MustParseAddr("1.2.3.4"), Duration: 90 * time.Second},
+	}))
+
+	assert.Equal(t, `ban 1.2.3.4 60 spamming
+ban [2001:db8::1] 0 multi line
+ban_range 1.2.3.0 1.2.3.255 0 "a;b"
+ban 5.6.7.8 30 "très \"méchant\" \\ #1"
+ban 1.2.3.4 2
+`, buf.String())
+}
+
+func TestBanIP(t *testing.T) {
+	tests := map[string]bans.Ban{
+		"1.2.3.4":         ban("1.2.3.4", "", 0, ""),
+		"1.2.3.0/24":      ban("1.2.3.0", "1.2.3.255", 0, ""),
+		"1.2.3.1-1.2.3.2": ban("1.2.3.1", "1.2.3.2", 0, ""),
+		"0.0.0.0/0":       ban("0.0.0.0", "255.255.255.255", 0, ""),
+		"2001:db8::/127":  ban("2001:db8::", "2001:db8::1", 0, ""),
+	}
+
+	for want, b := range tests {
+		assert.Equal(t, want, b.IP())
+	}
+
+	assert.Equal(t, ban("1.2.3.0", "1.2.3.255", 5, "r"), bans.FromPrefix(netip.MustParsePrefix("1.2.3.4/24"), 5*time.Minute, "r"))
+	assert.Equal(t, []bans.Ban{ban("1.2.3.4", "1.2.3.11", 0, "r"), ban("1.2.3.13", "", 0, "r")}, bans.FromPrefixes([]netip.Prefix{
+		netip.MustParsePrefix("1.2.3.4/30"),
+		netip.MustParsePrefix("1.2.3.8/30"),
+		netip.MustParsePrefix("1.2.3.13/32"),
+	}, 0, "r"))
+}
+
+func TestFormatFromPath(t *testing.T) {
+	for path, want := range map[string]bans.Format{"bans.cfg": bans.FormatDDNet, "x/bans.CSV": bans.FormatCSV, "bans.json": bans.FormatJSON} {
+		got, err := bans.FormatFromPath(path)
+		require.NoError(t, err, path)
+		assert.Equal(t, want, got, path)
+	}
+
+	for _, path := range []string{"bans", "bans.txt"} {
+		_, err := bans.FormatFromPath(path)
+		require.Error(t, err, path)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBansImport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bans.cfg")
+	require.NoError(t, os.WriteFile(path, []byte(`ban 1.2.3.4 5 spamming
+ban 5.6.7.8 0 cheating
+ban_range 1.2.3.0 1.2.3.255 60 bots
+`), 0o644))
+
+	tests := []struct {
+		name string
+		opts bansImportOptions
+		want string
+	}{
+		{
+			name: "permanent bans only",
+			want: "5.6.7.8 # cheating\n",
+		},
+		{
+			name: "include temporary",
+			opts: bansImportOptions{includeTemporary: true},
+			want: "1.2.3.4 # spamming\n5.6.7.8 # cheating\n1.2.3.0/24 # bots\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			require.NoError(t, tt.opts.run(&stdout, []string{path}))
+			assert.Equal(t, tt.want, stdout.String())
+		})
+	}
+}
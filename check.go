@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/jxsl13/banserver/config"
+	"github.com/jxsl13/banserver/econ"
+	"github.com/jxsl13/banserver/model"
+	"github.com/jxsl13/cli-config-boilerplate/cliconfig"
+	"github.com/spf13/cobra"
+)
+
+type checkOptions struct {
+	ctx     context.Context
+	cfg     *config.Config
+	connect bool
+	timeout time.Duration
+}
+
+func NewCheckCommand(ctx context.Context) *cobra.Command {
+	opts := checkOptions{
+		ctx: ctx,
+		cfg: config.New(),
+	}
+
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Validate the configuration and blacklists without starting the banserver",
+		Long: `Validates the configuration, compiles every regular expression of the chat blacklists,
+parses every ip blacklist and reports invalid lines as well as CIDRs that are already contained in other CIDRs.
+With --connect every econ server is connected to in order to verify its address and password.
+Exits with a non-zero exit code in case that any problem was found, redundant CIDRs are only reported as warnings.
+
+`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+	}
+
+	cfgParser := cliconfig.RegisterFlags(opts.cfg, false, cmd)
+	cmd.Flags().BoolVar(&opts.connect, "connect", false, "connect to every econ server in order to verify its address and password")
+	cmd.Flags().DurationVar(&opts.timeout, "timeout", 10*time.Second, "timeout of every econ connection and blacklist download")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		log.SetOutput(cmd.ErrOrStderr())
+		report := &checkReport{w: cmd.OutOrStdout()}
+
+		err := config.LoadEnvFiles()
+		if err == nil {
+			err = cfgParser()
+		}
+		if err != nil {
+			report.problem("config: %v", err)
+			return report.Err()
+		}
+		report.ok("config: %d econ servers", len(opts.cfg.EconServers))
+
+		opts.run(report)
+		return report.Err()
+	}
+	return cmd
+}
+
+func (o *checkOptions) run(report *checkReport) {
+	client := &http.Client{Timeout: o.timeout}
+
+	for _, source := range o.cfg.ChatBlacklists {
+		o.checkChatBlacklist(report, client, source)
+	}
+
+	var prefixes []model.SourcedPrefix
+	for _, source := range o.cfg.IPBlacklists {
+		prefixes = append(prefixes, o.checkIPBlacklist(report, client, source)...)
+	}
+
+	for _, overlap := range model.FindOverlaps(prefixes) {
+		report.warning("ip blacklist %s:%d: %s is already contained in %s",
+			overlap.Redundant.Source, overlap.Redundant.Line, overlap.Redundant.Prefix, overlap.Container)
+	}
+
+	if o.cfg.GeoIPCountryDB != "" || o.cfg.GeoIPASNDB != "" {
+		geo, err := model.OpenGeoIP(o.cfg.GeoIPCountryDB, o.cfg.GeoIPASNDB)
+		if err != nil {
+			report.problem("geoip: %v", err)
+		} else {
+			geo.Close()
+			report.ok("geoip: databases are valid")
+		}
+	}
+
+	if !o.connect {
+		return
+	}
+
+	for idx, addrPort := range o.cfg.EconServers {
+		name := o.cfg.EconNames[idx]
+		if name != addrPort {
+			name = fmt.Sprintf("%s (%s)", name, addrPort)
+		}
+
+		ctx, cancel := context.WithTimeout(o.ctx, o.timeout)
+		err := econ.Check(ctx, addrPort, o.cfg.EconPasswords[idx], econOptions(o.cfg, idx)...)
+		cancel()
+		if err != nil {
+			report.problem("econ %s: %v", name, err)
+			continue
+		}
+		report.ok("econ %s: connected", name)
+	}
+}
+
+func (o *checkOptions) checkChatBlacklist(report *checkReport, client *http.Client, source string) {
+	data, err := model.ReadBlacklist(o.ctx, client, source, o.cfg.BlacklistsMaxSize)
+	if err != nil {
+		report.problem("chat blacklist %s: %v", source, err)
+		return
+	}
+
+	rules, err := model.ValidateChatBlacklist(bytes.NewReader(data))
+	invalid := report.lineErrors("chat blacklist", source, err)
+	if invalid > 0 {
+		return
+	}
+	report.ok("chat blacklist %s: %d rules", source, rules)
+}
+
+func (o *checkOptions) checkIPBlacklist(report *checkReport, client *http.Client, source string) []model.SourcedPrefix {
+	data, err := model.ReadBlacklist(o.ctx, client, source, o.cfg.BlacklistsMaxSize)
+	if err != nil {
+		report.problem("ip blacklist %s: %v", source, err)
+		return nil
+	}
+
+	entries, err := model.ParseBlacklist(bytes.NewReader(data))
+	invalid := report.lineErrors("ip blacklist", source, err)
+	if len(entries) == 0 {
+		report.problem("ip blacklist %s: no valid entries", source)
+		return nil
+	}
+
+	if invalid == 0 {
+		report.ok("ip blacklist %s: %d entries", source, len(entries))
+	}
+	return model.SourcedPrefixes(source, entries)
+}
+
+// checkReport writes the results of the checks and counts the problems and warnings.
+type checkReport struct {
+	w        io.Writer
+	problems int
+	warnings int
+}
+
+func (r *checkReport) ok(format string, args ...any) {
+	fmt.Fprintf(r.w, "ok:      "+format+"\n", args...)
+}
+
+func (r *checkReport) warning(format string, args ...any) {
+	r.warnings++
+	fmt.Fprintf(r.w, "warning: "+format+"\n", args...)
+}
+
+func (r *checkReport) problem(format string, args ...any) {
+	r.problems++
+	fmt.Fprintf(r.w, "error:   "+format+"\n", args...)
+}
+
+// lineErrors reports every invalid line of a blacklist and returns the number of invalid lines.
+func (r *checkReport) lineErrors(kind, source string, err error) (invalid int) {
+	if err == nil {
+		return 0
+	}
+
+	for _, err := range unjoin(err) {
+		var lineErr *model.LineError
+		if errors.As(err, &lineErr) {
+			r.problem("%s %s:%d: %v: %q", kind, source, lineErr.Line, lineErr.Err, lineErr.Text)
+			invalid++
+			continue
+		}
+		r.problem("%s %s: %v", kind, source, err)
+	}
+	return invalid
+}
+
+// Err returns an error in case that any problem was found.
+func (r *checkReport) Err() error {
+	fmt.Fprintf(r.w, "%d problems, %d warnings\n", r.problems, r.warnings)
+	if r.problems > 0 {
+		return fmt.Errorf("found %d problems", r.problems)
+	}
+	return nil
+}
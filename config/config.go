@@ -3,15 +3,27 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net/netip"
+	"net/url"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/jxsl13/banserver/model"
+	"github.com/jxsl13/banserver/parser"
+	"github.com/jxsl13/banserver/secret"
+	"github.com/jxsl13/banserver/sshtunnel"
 )
 
 var (
-	errAddressPasswordMismatch = errors.New("the number of ECON_PASSWORD doesn't match the number of ECON_ADDRESSES, either provide one password for all addresses or one password per address")
+	errAddressPasswordMismatch = errors.New("the number of ECON_PASSWORDS doesn't match the number of ECON_ADDRESSES, either provide one password for all addresses or one password per address, commas in passwords must be escaped as \\,")
+	errAddressFlavorMismatch   = errors.New("the number of ECON_FLAVORS doesn't match the number of ECON_ADDRESSES, either provide one flavor for all addresses or one flavor per address")
+	errAddressNameMismatch     = errors.New("the number of ECON_NAMES doesn't match the number of ECON_ADDRESSES, provide one name per address")
+	errAddressGroupMismatch    = errors.New("the number of ECON_GROUPS doesn't match the number of ECON_ADDRESSES, either provide one group for all addresses or one group per address")
+	errAddressSSHMismatch      = errors.New("the number of ECON_SSH_HOSTS doesn't match the number of ECON_ADDRESSES, either provide one ssh host for all addresses or one ssh host per address")
+	errAddressSSHJumpMismatch  = errors.New("the number of ECON_SSH_JUMP_HOSTS doesn't match the number of ECON_ADDRESSES, either provide one jump host for all addresses or one jump host per address")
 )
 
 // New creates a new configuration file based on
@@ -22,36 +34,118 @@ func New() *Config {
 	return &Config{
 		EconReconnectDelay:   10 * time.Second,
 		EconReconnectTimeout: 24 * time.Hour,
+		EconFlavorsString:    string(parser.FlavorAuto),
 		PermaBanReason:       "permanently banned",
 		PermaBanDuration:     24 * time.Hour,
+		BanConfirmTimeout:    5 * time.Second,
+		BanRetries:           2,
 		ChatBanReason:        "prohibited chat message",
 		ChatBanDuration:      24 * time.Hour,
+		RconBanWindow:        10 * time.Minute,
+		RconBanReason:        "too many failed rcon logins",
+		RconBanDuration:      24 * time.Hour,
+		VoteWindow:           5 * time.Minute,
+		VoteAction:           "log",
+		VoteBanReason:        "vote abuse",
+		VoteBanDuration:      30 * time.Minute,
+		WhisperWindow:        time.Minute,
+		WhisperAction:        "log",
+		WhisperBanReason:     "whisper spam",
+		WhisperBanDuration:   30 * time.Minute,
+		GeoIPAction:          "log",
+		GeoIPBanReason:       "joins from your location are not allowed",
+		GeoIPBanDuration:     24 * time.Hour,
+		BlacklistsRefresh:    time.Hour,
+		BlacklistsMaxSize:    64 << 20,
+		ReconcileMode:        model.ReconcileReport,
 	}
 }
 
 // Config represents the application configuration
 type Config struct {
-	EconServersString string `koanf:"econ.addresses" validate:"required" description:"comma separated list of econ addresses (<ip/hostname>:port)"`
+	ServersFile string `koanf:"servers.file" description:"yaml file that describes every econ server with its own settings, alternative to the econ addresses"`
+
+	EconServersString string `koanf:"econ.addresses" description:"comma separated list of econ addresses (<ip/hostname>:port)"`
 	EconServers       []string
+	EconNamesString   string `koanf:"econ.names" description:"comma separated list of human friendly server names that are used in logs, one per econ address, defaults to the addresses"`
+	EconNames         []string
+	ServerRules       map[string]model.ServerRules // econ address -> rules
+
+	EconPasswordsString     string `koanf:"econ.passwords" description:"comma separated list of econ passwords, either one for all or one per econ address, commas and backslashes in passwords are escaped with a backslash (\\, and \\\\)"`
+	EconPasswordFilesString string `koanf:"econ.password.files" description:"comma separated list of files that contain the econ password, either one for all or one per econ address, alternative to econ passwords"`
+	EconPasswords           []secret.Secret
+	EconFlavorsString       string `koanf:"econ.flavors" description:"comma separated list of server flavors (auto, ddnet, ddnet-legacy, vanilla-0.6, vanilla-0.7, zcatch, infclass, fng), either one for all or one per econ address"`
+	EconFlavors             []parser.Flavor
+	EconGroupsString        string `koanf:"econ.groups" description:"comma separated list of server groups, either one for all or one per econ address"`
+	EconGroups              []string
+	EconReconnectDelay      time.Duration `koanf:"econ.reconnect.delay" validate:"required"`
+	EconReconnectTimeout    time.Duration `koanf:"econ.reconnect.timeout" validate:"required"`
 
-	EconPasswordsString  string `koanf:"econ.passwords" validate:"required" description:"comma separated list of econ passwords"`
-	EconPasswords        []string
-	EconReconnectDelay   time.Duration `koanf:"econ.reconnect.delay" validate:"required"`
-	EconReconnectTimeout time.Duration `koanf:"econ.reconnect.timeout" validate:"required"`
+	EconSSHHostsString     string `koanf:"econ.ssh.hosts" description:"comma separated list of ssh servers ([user@]host[:port]) that tunnel the econ connections, either one for all or one per econ address, empty entries connect directly"`
+	EconSSHJumpHostsString string `koanf:"econ.ssh.jump.hosts" description:"comma separated list of ssh jump hosts ([user@]host[:port]) that are used to reach the ssh servers, either one for all or one per econ address"`
+	EconSSH                []*sshtunnel.Config
+	EconSSHUser            string `koanf:"econ.ssh.user" description:"ssh user for ssh servers and jump hosts without user"`
+	EconSSHKeyFile         string `koanf:"econ.ssh.key.file" description:"unencrypted private key file that is used to authenticate at the ssh servers"`
+	EconSSHKnownHosts      string `koanf:"econ.ssh.known.hosts" description:"known_hosts file that is used to verify the host keys of the ssh servers, defaults to ~/.ssh/known_hosts"`
 
-	IPBlacklistsString  string `koanf:"ip.blacklists" description:"comma separated list of files containing ip ranges to blacklist"`
+	IPBlacklistsString  string `koanf:"ip.blacklists" description:"comma separated list of files or http(s) urls containing ip ranges to blacklist"`
 	IPBlacklists        []string
-	ChatBlacklistString string `koanf:"chat.blacklists" description:"comma separated list that contains regular expressions to check message blacklists"`
+	ChatBlacklistString string `koanf:"chat.blacklists" description:"comma separated list of files or http(s) urls that contain regular expressions to check message blacklists"`
 
 	ChatBlacklists []string
 
+	BlacklistsRefresh  time.Duration `koanf:"blacklists.refresh" description:"interval in which http(s) blacklists are refreshed, 0 disables refreshing"`
+	BlacklistsCacheDir string        `koanf:"blacklists.cache.dir" description:"directory for cached copies of http(s) blacklists, defaults to the user cache directory"`
+	BlacklistsMaxSize  int           `koanf:"blacklists.max.size" description:"maximum size in bytes of http(s) blacklists"`
+
 	Propagate bool `koanf:"propagate" description:"propagate bans and unbans from one game server to all other game servers"`
+	BanOrigin bool `koanf:"ban.origin" description:"prefix the reasons of propagated bans with the name of the game server the ban originated from"`
+
+	BanConfirmTimeout time.Duration `koanf:"ban.confirm.timeout" description:"time a game server has to log a ban before the ban is retried, 0 disables the confirmation of bans"`
+	BanRetries        int           `koanf:"ban.retries" description:"number of retries of bans that were not confirmed by a game server before they are reported as failed"`
+
+	ReconcileInterval time.Duration `koanf:"reconcile.interval" description:"interval in which the ban lists of all game servers are compared, 0 disables reconciliation"`
+	ReconcileMode     string        `koanf:"reconcile.mode" description:"either report, which only logs differences between the ban lists, or enforce, which bans and unbans ips until all ban lists are equal"`
 
 	PermaBanReason   string        `koanf:"perma.ban.reason" description:"default reason for permabans"`
 	PermaBanDuration time.Duration `koanf:"perma.ban.duration" description:"default duration for permabans"`
 
 	ChatBanReason   string        `koanf:"chat.ban.reason" description:"default reason for chat bans"`
 	ChatBanDuration time.Duration `koanf:"chat.ban.duration" description:"default duration for chat bans"`
+
+	RconBanAttempts int           `koanf:"rcon.ban.attempts" description:"ban an ip on all servers after this many failed rcon logins within the rcon ban window, 0 disables this feature"`
+	RconBanWindow   time.Duration `koanf:"rcon.ban.window" description:"time window in which failed rcon logins are counted"`
+	RconBanReason   string        `koanf:"rcon.ban.reason" description:"reason for bans due to failed rcon logins"`
+	RconBanDuration time.Duration `koanf:"rcon.ban.duration" description:"duration of bans due to failed rcon logins"`
+
+	RconAlert            bool   `koanf:"rcon.alert" description:"log an alert when someone logs into the rcon from an unfamiliar ip"`
+	RconTrustedIPsString string `koanf:"rcon.trusted.ips" description:"comma separated list of ips or ip ranges that are familiar rcon login ips"`
+	RconTrustedIPs       []string
+
+	VoteMax         int           `koanf:"vote.max" description:"maximum number of votes a client may call within the vote window, 0 disables this check"`
+	VoteKickMax     int           `koanf:"vote.kick.max" description:"maximum number of kick votes a client may call against the same player within the vote window, 0 disables this check"`
+	VoteWindow      time.Duration `koanf:"vote.window" description:"time window in which called votes are counted"`
+	VoteAction      string        `koanf:"vote.action" description:"action that is applied to clients abusing votes, one of log, kick or ban"`
+	VoteBanReason   string        `koanf:"vote.ban.reason" description:"reason for kicks and bans due to vote abuse"`
+	VoteBanDuration time.Duration `koanf:"vote.ban.duration" description:"duration of bans due to vote abuse"`
+
+	WhisperMaxTargets  int           `koanf:"whisper.max.targets" description:"maximum number of distinct clients a client may whisper to within the whisper window, 0 disables this check"`
+	WhisperWindow      time.Duration `koanf:"whisper.window" description:"time window in which whisper targets are counted"`
+	WhisperAction      string        `koanf:"whisper.action" description:"action that is applied to clients spamming whispers, one of log, kick or ban"`
+	WhisperBanReason   string        `koanf:"whisper.ban.reason" description:"reason for kicks and bans due to whisper spam"`
+	WhisperBanDuration time.Duration `koanf:"whisper.ban.duration" description:"duration of bans due to whisper spam"`
+
+	GeoIPCountryDB            string `koanf:"geoip.country.db" description:"path to a MaxMind-format (.mmdb) country or city database"`
+	GeoIPASNDB                string `koanf:"geoip.asn.db" description:"path to a MaxMind-format (.mmdb) asn database"`
+	GeoIPDenyASNsString       string `koanf:"geoip.deny.asns" description:"comma separated list of autonomous system numbers (e.g. AS24940) whose clients are not allowed to join, requires the asn database"`
+	GeoIPDenyASNs             []uint
+	GeoIPAllowCountriesString string `koanf:"geoip.allow.countries" description:"comma separated list of country allowlists per server group (e.g. eu:DE|AT|CH,na:US|CA), an allowlist without group applies to all groups without allowlist, requires the country database"`
+	GeoIPAllowCountries       map[string][]string
+	GeoIPAction               string        `koanf:"geoip.action" description:"action that is applied to clients joining from denied locations, one of log, kick or ban"`
+	GeoIPBanReason            string        `koanf:"geoip.ban.reason" description:"reason for kicks and bans due to denied locations"`
+	GeoIPBanDuration          time.Duration `koanf:"geoip.ban.duration" description:"duration of bans due to denied locations"`
+
+	AdminSocket string `koanf:"admin.socket" description:"unix socket of the admin interface of the running banserver, e.g. used by test-ip --live and console, empty disables the admin interface"`
 }
 
 func (c *Config) Validate() error {
@@ -68,6 +162,14 @@ func (c *Config) Validate() error {
 		return errors.New("perma ban reason must not be empty")
 	}
 
+	if c.BanConfirmTimeout < 0 {
+		return errors.New("ban confirm timeout must not be negative")
+	}
+
+	if c.BanRetries < 0 {
+		return errors.New("ban retries must not be negative")
+	}
+
 	if c.ChatBanDuration < time.Minute {
 		return errors.New("chat ban duration must be at least 1m")
 	}
@@ -76,32 +178,85 @@ func (c *Config) Validate() error {
 		return errors.New("chat ban reason must not be empty")
 	}
 
-	if len(c.EconServersString) == 0 {
-		return errors.New("econ addresses must not be empty")
+	if c.RconBanAttempts < 0 {
+		return errors.New("rcon ban attempts must not be negative")
 	}
 
-	c.EconServers = strings.Split(c.EconServersString, ",")
-	c.EconPasswords = strings.Split(c.EconPasswordsString, ",")
+	if c.RconBanAttempts > 0 {
+		if c.RconBanWindow <= 0 {
+			return errors.New("rcon ban window must be positive")
+		}
 
-	// add password for every econ server.
-	if len(c.EconServers) != len(c.EconPasswords) {
-		if len(c.EconServers) > 1 && len(c.EconPasswords) > 1 {
-			return errAddressPasswordMismatch
+		if c.RconBanDuration < time.Minute {
+			return errors.New("rcon ban duration must be at least 1m")
 		}
-		if len(c.EconServers) > 1 && len(c.EconPasswords) == 1 {
-			for len(c.EconPasswords) < len(c.EconServers) {
-				c.EconPasswords = append(c.EconPasswords, c.EconPasswords[0])
+
+		if len(c.RconBanReason) == 0 {
+			return errors.New("rcon ban reason must not be empty")
+		}
+	}
+
+	if c.VoteMax < 0 || c.VoteKickMax < 0 {
+		return errors.New("vote limits must not be negative")
+	}
+
+	voteProtection := c.VoteMax > 0 || c.VoteKickMax > 0
+	if voteProtection {
+		if c.VoteWindow <= 0 {
+			return errors.New("vote window must be positive")
+		}
+
+		if err := validateAction("vote", c.VoteAction, c.VoteBanDuration, c.VoteBanReason); err != nil {
+			return err
+		}
+	}
+
+	if c.WhisperMaxTargets < 0 {
+		return errors.New("whisper max targets must not be negative")
+	}
+
+	whisperProtection := c.WhisperMaxTargets > 0
+	if whisperProtection {
+		if c.WhisperWindow <= 0 {
+			return errors.New("whisper window must be positive")
+		}
+
+		if err := validateAction("whisper", c.WhisperAction, c.WhisperBanDuration, c.WhisperBanReason); err != nil {
+			return err
+		}
+	}
+
+	if len(c.RconTrustedIPsString) > 0 {
+		c.RconTrustedIPs = strings.Split(c.RconTrustedIPsString, ",")
+
+		for _, ip := range c.RconTrustedIPs {
+			if err := ipOrCIDRMustBeValid(ip); err != nil {
+				return fmt.Errorf("invalid rcon trusted ip %s: %w", ip, err)
 			}
 		}
 	}
 
+	if len(c.ServersFile) > 0 {
+		err = c.validateServersFile()
+	} else {
+		err = c.validateServerList()
+	}
+	if err != nil {
+		return err
+	}
+
+	geoProtection, err := c.validateGeoIP()
+	if err != nil {
+		return err
+	}
+
 	if len(c.IPBlacklistsString) > 0 {
 		c.IPBlacklists = strings.Split(c.IPBlacklistsString, ",")
 
 		// check if all files exist
 		for _, file := range c.IPBlacklists {
-			if err := fileMustExist(file); err != nil {
-				return fmt.Errorf("ip blacklist file %s does not exist: %w", file, err)
+			if err := blacklistMustExist(file); err != nil {
+				return fmt.Errorf("ip blacklist %s does not exist: %w", file, err)
 			}
 		}
 	}
@@ -111,21 +266,380 @@ func (c *Config) Validate() error {
 
 		// check if all files exist
 		for _, file := range c.ChatBlacklists {
-			if err := fileMustExist(file); err != nil {
-				return fmt.Errorf("chat blacklist file %s does not exist: %w", file, err)
+			if err := blacklistMustExist(file); err != nil {
+				return fmt.Errorf("chat blacklist %s does not exist: %w", file, err)
+			}
+		}
+	}
+
+	if c.BlacklistsRefresh < 0 {
+		return errors.New("blacklists refresh interval must not be negative")
+	}
+
+	if c.BlacklistsMaxSize <= 0 {
+		return errors.New("blacklists max size must be positive")
+	}
+
+	if c.ReconcileInterval < 0 {
+		return errors.New("reconcile interval must not be negative")
+	}
+
+	reconcile := c.ReconcileInterval > 0
+	switch c.ReconcileMode {
+	case model.ReconcileReport, model.ReconcileEnforce:
+	default:
+		return fmt.Errorf("invalid reconcile mode %q, must be one of report or enforce", c.ReconcileMode)
+	}
+
+	protection := c.RconBanAttempts > 0 || c.RconAlert || voteProtection || whisperProtection || geoProtection
+	if !c.Propagate && !reconcile && len(c.ChatBlacklists) == 0 && len(c.IPBlacklists) == 0 && !protection {
+		return fmt.Errorf("pointless configuration, you need to have at least propagate bans or reconciliation enabled or chat blacklist or ip blacklist or rcon, vote, whisper or geoip protection defined")
+	} else if len(c.ChatBlacklists) == 0 && len(c.IPBlacklists) == 0 && !protection && (c.Propagate || reconcile) && len(c.EconServers) < 2 {
+		return fmt.Errorf("pointless configuration, you need to have at least two game servers (= econ addresses) to propagate or reconcile bans")
+	}
+
+	return nil
+}
+
+// validateServerList creates the configuration of every econ server from the comma separated lists.
+func (c *Config) validateServerList() error {
+	if len(c.EconServersString) == 0 {
+		return errors.New("either econ addresses or a servers file must be provided")
+	}
+
+	c.EconServers = strings.Split(c.EconServersString, ",")
+
+	err := c.validatePasswords()
+	if err != nil {
+		return err
+	}
+
+	err = c.validateNames()
+	if err != nil {
+		return err
+	}
+
+	for _, f := range strings.Split(c.EconFlavorsString, ",") {
+		flavor, err := parser.ParseFlavor(f)
+		if err != nil {
+			return err
+		}
+		c.EconFlavors = append(c.EconFlavors, flavor)
+	}
+
+	// add flavor for every econ server.
+	if len(c.EconServers) != len(c.EconFlavors) {
+		if len(c.EconFlavors) > 1 {
+			return errAddressFlavorMismatch
+		}
+		for len(c.EconFlavors) < len(c.EconServers) {
+			c.EconFlavors = append(c.EconFlavors, c.EconFlavors[0])
+		}
+	}
+
+	for _, g := range strings.Split(c.EconGroupsString, ",") {
+		g, err := parseGroup(g)
+		if err != nil {
+			return err
+		}
+		c.EconGroups = append(c.EconGroups, g)
+	}
+
+	// add group for every econ server.
+	if len(c.EconServers) != len(c.EconGroups) {
+		if len(c.EconGroups) > 1 {
+			return errAddressGroupMismatch
+		}
+		for len(c.EconGroups) < len(c.EconServers) {
+			c.EconGroups = append(c.EconGroups, c.EconGroups[0])
+		}
+	}
+
+	return c.validateSSH()
+}
+
+// validateNames assigns a unique name to every econ server, which defaults to its address.
+func (c *Config) validateNames() error {
+	c.EconNames = make([]string, 0, len(c.EconServers))
+	if len(c.EconNamesString) == 0 {
+		c.EconNames = append(c.EconNames, c.EconServers...)
+		return nil
+	}
+
+	names := strings.Split(c.EconNamesString, ",")
+	if len(names) != len(c.EconServers) {
+		return errAddressNameMismatch
+	}
+
+	for idx, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			name = c.EconServers[idx]
+		}
+		if slices.Contains(c.EconNames, name) {
+			return fmt.Errorf("duplicate econ server name %q", name)
+		}
+		c.EconNames = append(c.EconNames, name)
+	}
+	return nil
+}
+
+// validatePasswords reads the econ passwords either from the passwords list or from the password files.
+func (c *Config) validatePasswords() error {
+	c.EconPasswords = c.EconPasswords[:0]
+
+	switch {
+	case len(c.EconPasswordsString) > 0 && len(c.EconPasswordFilesString) > 0:
+		return errors.New("either provide econ passwords or econ password files, not both")
+	case len(c.EconPasswordFilesString) > 0:
+		for _, file := range strings.Split(c.EconPasswordFilesString, ",") {
+			password, err := readPasswordFile(file)
+			if err != nil {
+				return err
 			}
+			c.EconPasswords = append(c.EconPasswords, password)
+		}
+	case len(c.EconPasswordsString) > 0:
+		for _, password := range splitEscaped(c.EconPasswordsString, ',') {
+			c.EconPasswords = append(c.EconPasswords, secret.Secret(password))
+		}
+	default:
+		return errors.New("econ passwords or econ password files must not be empty")
+	}
+
+	// add password for every econ server.
+	if len(c.EconServers) != len(c.EconPasswords) {
+		if len(c.EconPasswords) > 1 {
+			return errAddressPasswordMismatch
+		}
+		for len(c.EconPasswords) < len(c.EconServers) {
+			c.EconPasswords = append(c.EconPasswords, c.EconPasswords[0])
 		}
 	}
+	return nil
+}
 
-	if !c.Propagate && len(c.ChatBlacklists) == 0 && len(c.IPBlacklists) == 0 {
-		return fmt.Errorf("pointless configuration, you need to have at least propagate bans enabled or chat blacklist or ip blacklist defined")
-	} else if len(c.ChatBlacklists) == 0 && len(c.IPBlacklists) == 0 && c.Propagate && len(c.EconServers) < 2 {
-		return fmt.Errorf("pointless configuration, you need to have at least two game servers (= econ addresses) to propagate bans")
+// readPasswordFile reads a password file, e.g. a docker secret, without its trailing line break.
+func readPasswordFile(file string) (secret.Secret, error) {
+	data, err := os.ReadFile(strings.TrimSpace(file))
+	if err != nil {
+		return "", fmt.Errorf("failed to read econ password file: %w", err)
 	}
 
+	password := strings.TrimRight(string(data), "\r\n")
+	if password == "" {
+		return "", fmt.Errorf("econ password file %s is empty", file)
+	}
+	return secret.Secret(password), nil
+}
+
+// splitEscaped splits s at every sep that is not escaped with a backslash.
+// A backslash escapes sep and itself, any other backslash is kept as is.
+func splitEscaped(s string, sep byte) []string {
+	var (
+		parts []string
+		sb    strings.Builder
+	)
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && (s[i+1] == sep || s[i+1] == '\\'):
+			i++
+			sb.WriteByte(s[i])
+		case s[i] == sep:
+			parts = append(parts, sb.String())
+			sb.Reset()
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return append(parts, sb.String())
+}
+
+// validateSSH creates the ssh tunnel configuration of every econ server, nil for direct connections.
+func (c *Config) validateSSH() error {
+	if len(c.EconSSHHostsString) == 0 {
+		if len(c.EconSSHJumpHostsString) > 0 {
+			return errors.New("ssh jump hosts require ssh hosts")
+		}
+		return nil
+	}
+
+	hosts := strings.Split(c.EconSSHHostsString, ",")
+	if len(hosts) != len(c.EconServers) {
+		if len(hosts) > 1 {
+			return errAddressSSHMismatch
+		}
+		for len(hosts) < len(c.EconServers) {
+			hosts = append(hosts, hosts[0])
+		}
+	}
+
+	jumps := strings.Split(c.EconSSHJumpHostsString, ",")
+	if len(jumps) != len(c.EconServers) {
+		if len(jumps) > 1 {
+			return errAddressSSHJumpMismatch
+		}
+		for len(jumps) < len(c.EconServers) {
+			jumps = append(jumps, jumps[0])
+		}
+	}
+
+	var err error
+	c.EconSSH, err = c.sshTunnels(hosts, jumps)
+	return err
+}
+
+// sshTunnels returns the ssh tunnel configuration of every ssh host and its jump host, nil for empty hosts.
+func (c *Config) sshTunnels(hosts, jumps []string) ([]*sshtunnel.Config, error) {
+	if err := fileMustExist(c.EconSSHKeyFile); err != nil {
+		return nil, fmt.Errorf("ssh key file %q does not exist: %w", c.EconSSHKeyFile, err)
+	}
+
+	if c.EconSSHKnownHosts == "" {
+		c.EconSSHKnownHosts = sshtunnel.DefaultKnownHostsFile()
+	}
+
+	if err := fileMustExist(c.EconSSHKnownHosts); err != nil {
+		return nil, fmt.Errorf("ssh known hosts file %q does not exist: %w", c.EconSSHKnownHosts, err)
+	}
+
+	tunnels := make([]*sshtunnel.Config, len(hosts))
+	for idx := range hosts {
+		if strings.TrimSpace(hosts[idx]) == "" {
+			if strings.TrimSpace(jumps[idx]) != "" {
+				return nil, fmt.Errorf("ssh jump host %s requires an ssh host", jumps[idx])
+			}
+			continue
+		}
+
+		cfg, err := c.sshConfig(hosts[idx])
+		if err != nil {
+			return nil, err
+		}
+
+		if strings.TrimSpace(jumps[idx]) != "" {
+			cfg.Jump, err = c.sshConfig(jumps[idx])
+			if err != nil {
+				return nil, err
+			}
+		}
+		tunnels[idx] = cfg
+	}
+	return tunnels, nil
+}
+
+func (c *Config) sshConfig(address string) (*sshtunnel.Config, error) {
+	user, addr, err := sshtunnel.ParseAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == "" {
+		user = c.EconSSHUser
+	}
+	if user == "" {
+		return nil, fmt.Errorf("missing ssh user for ssh host %s, either provide it as user@host or via the ssh user", address)
+	}
+
+	return &sshtunnel.Config{
+		Address:        addr,
+		User:           user,
+		KeyFile:        c.EconSSHKeyFile,
+		KnownHostsFile: c.EconSSHKnownHosts,
+	}, nil
+}
+
+// validateGeoIP returns true in case that any geoip rule is configured.
+func (c *Config) validateGeoIP() (bool, error) {
+	if c.GeoIPCountryDB != "" {
+		if err := fileMustExist(c.GeoIPCountryDB); err != nil {
+			return false, fmt.Errorf("geoip country database %s does not exist: %w", c.GeoIPCountryDB, err)
+		}
+	}
+
+	if c.GeoIPASNDB != "" {
+		if err := fileMustExist(c.GeoIPASNDB); err != nil {
+			return false, fmt.Errorf("geoip asn database %s does not exist: %w", c.GeoIPASNDB, err)
+		}
+	}
+
+	if len(c.GeoIPDenyASNsString) > 0 {
+		if c.GeoIPASNDB == "" {
+			return false, errors.New("geoip deny asns require a geoip asn database")
+		}
+
+		for _, s := range strings.Split(c.GeoIPDenyASNsString, ",") {
+			asn, err := model.ParseASN(s)
+			if err != nil {
+				return false, err
+			}
+			c.GeoIPDenyASNs = append(c.GeoIPDenyASNs, asn)
+		}
+	}
+
+	if len(c.GeoIPAllowCountriesString) > 0 {
+		if c.GeoIPCountryDB == "" {
+			return false, errors.New("geoip allow countries require a geoip country database")
+		}
+
+		c.GeoIPAllowCountries = make(map[string][]string)
+		for _, allowlist := range strings.Split(c.GeoIPAllowCountriesString, ",") {
+			group, countries, found := strings.Cut(allowlist, ":")
+			if !found {
+				group, countries = model.AllGroups, allowlist
+			}
+			group = strings.TrimSpace(group)
+
+			if group != model.AllGroups && !slices.Contains(c.EconGroups, group) {
+				return false, fmt.Errorf("geoip allow countries contain unknown econ group %q", group)
+			}
+			if _, ok := c.GeoIPAllowCountries[group]; ok {
+				return false, fmt.Errorf("geoip allow countries contain group %q more than once", group)
+			}
+
+			for _, country := range strings.Split(countries, "|") {
+				country = strings.ToUpper(strings.TrimSpace(country))
+				if len(country) != 2 {
+					return false, fmt.Errorf("invalid geoip country %q, must be an ISO 3166-1 alpha-2 code, e.g. DE", country)
+				}
+				c.GeoIPAllowCountries[group] = append(c.GeoIPAllowCountries[group], country)
+			}
+		}
+	}
+
+	geoProtection := len(c.GeoIPDenyASNs) > 0 || len(c.GeoIPAllowCountries) > 0
+	if geoProtection {
+		if err := validateAction("geoip", c.GeoIPAction, c.GeoIPBanDuration, c.GeoIPBanReason); err != nil {
+			return false, err
+		}
+	}
+	return geoProtection, nil
+}
+
+// blacklistMustExist checks that local blacklist files exist and that remote blacklists are valid http(s) urls.
+func blacklistMustExist(path string) error {
+	if !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+		return fileMustExist(path)
+	}
+
+	u, err := url.Parse(path)
+	if err != nil {
+		return err
+	}
+	if u.Host == "" {
+		return fmt.Errorf("url %s has no host", path)
+	}
 	return nil
 }
 
+func parseGroup(group string) (string, error) {
+	group = strings.TrimSpace(group)
+	if strings.ContainsAny(group, ":|") {
+		return "", fmt.Errorf("invalid econ group %q, must not contain : or |", group)
+	}
+	return group, nil
+}
+
 func fileMustExist(path string) error {
 	fi, err := os.Stat(path)
 	if err != nil {
@@ -137,3 +651,31 @@ func fileMustExist(path string) error {
 	}
 	return nil
 }
+
+func validateAction(name, action string, banDuration time.Duration, banReason string) error {
+	switch action {
+	case "log", "kick":
+	case "ban":
+		if banDuration < time.Minute {
+			return fmt.Errorf("%s ban duration must be at least 1m", name)
+		}
+	default:
+		return fmt.Errorf("invalid %s action %q, must be one of log, kick or ban", name, action)
+	}
+
+	if len(banReason) == 0 {
+		return fmt.Errorf("%s ban reason must not be empty", name)
+	}
+	return nil
+}
+
+func ipOrCIDRMustBeValid(s string) error {
+	if _, err := netip.ParsePrefix(s); err == nil {
+		return nil
+	}
+
+	if _, err := parser.ParseIP(s); err != nil {
+		return errors.New("neither an ip nor an ip range")
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jxsl13/banserver/secret"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitEscaped(t *testing.T) {
+	tests := []struct {
+		s    string
+		want []string
+	}{
+		{"", []string{""}},
+		{"a", []string{"a"}},
+		{"a,b", []string{"a", "b"}},
+		{`a\,b`, []string{"a,b"}},
+		{`a\\,b`, []string{`a\`, "b"}},
+		{`a\b,c\`, []string{`a\b`, `c\`}},
+		{`,\,,`, []string{"", ",", ""}},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, splitEscaped(tt.s, ','), tt.s)
+	}
+}
+
+func TestValidatePasswords(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+	first := writeFile("first", "pass,word\n")
+	second := writeFile("second", "secret")
+	empty := writeFile("empty", "\n")
+
+	tests := []struct {
+		name      string
+		addresses string
+		passwords string
+		files     string
+		want      []secret.Secret
+		wantErr   bool
+	}{
+		{name: "one for all", addresses: "a:1,b:2", passwords: "pw", want: []secret.Secret{"pw", "pw"}},
+		{name: "one per address", addresses: "a:1,b:2", passwords: "pw1,pw2", want: []secret.Secret{"pw1", "pw2"}},
+		{name: "escaped comma", addresses: "a:1,b:2", passwords: `pw\,1`, want: []secret.Secret{"pw,1", "pw,1"}},
+		{name: "unescaped comma", addresses: "a:1", passwords: "pw,1", wantErr: true},
+		{name: "mismatch", addresses: "a:1,b:2,c:3", passwords: "pw1,pw2", wantErr: true},
+		{name: "files", addresses: "a:1,b:2", files: first + "," + second, want: []secret.Secret{"pass,word", "secret"}},
+		{name: "one file for all", addresses: "a:1,b:2", files: second, want: []secret.Secret{"secret", "secret"}},
+		{name: "empty file", addresses: "a:1", files: empty, wantErr: true},
+		{name: "missing file", addresses: "a:1", files: filepath.Join(dir, "missing"), wantErr: true},
+		{name: "passwords and files", addresses: "a:1", passwords: "pw", files: second, wantErr: true},
+		{name: "no passwords", addresses: "a:1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := New()
+			cfg.EconServersString = tt.addresses
+			cfg.EconPasswordsString = tt.passwords
+			cfg.EconPasswordFilesString = tt.files
+			cfg.Propagate = true
+
+			err := cfg.Validate()
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cfg.EconPasswords)
+		})
+	}
+}
+
+func TestValidatePasswordsRedacted(t *testing.T) {
+	cfg := New()
+	cfg.EconServersString = "a:1,b:2,c:3"
+	cfg.EconPasswordsString = "hunter2,hunter3"
+	cfg.Propagate = true
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "hunter")
+}
+
+func TestLoadEnvFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "passwords")
+	require.NoError(t, os.WriteFile(path, []byte(`pw\,1,pw2`+"\n"), 0o600))
+
+	// restored after the test
+	t.Setenv("ECON_PASSWORDS", "")
+	os.Unsetenv("ECON_PASSWORDS")
+
+	t.Setenv("ECON_PASSWORDS_FILE", path)
+	require.NoError(t, LoadEnvFiles())
+	assert.Equal(t, `pw\,1,pw2`, os.Getenv("ECON_PASSWORDS"))
+
+	// the variable itself must not be set as well
+	require.Error(t, LoadEnvFiles())
+
+	t.Setenv("ECON_ADDRESSES_FILE", filepath.Join(t.TempDir(), "missing"))
+	os.Unsetenv("ECON_PASSWORDS_FILE")
+	require.Error(t, LoadEnvFiles())
+}
+
+func TestValidateNames(t *testing.T) {
+	tests := []struct {
+		name      string
+		addresses string
+		names     string
+		want      []string
+		wantErr   bool
+	}{
+		{name: "defaults to addresses", addresses: "a:1,b:2", want: []string{"a:1", "b:2"}},
+		{name: "one per address", addresses: "a:1,b:2", names: "Novice #1, Novice #2", want: []string{"Novice #1", "Novice #2"}},
+		{name: "empty name", addresses: "a:1,b:2", names: "Novice #1,", want: []string{"Novice #1", "b:2"}},
+		{name: "mismatch", addresses: "a:1,b:2", names: "Novice", wantErr: true},
+		{name: "duplicate", addresses: "a:1,b:2", names: "Novice,Novice", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := New()
+			cfg.EconServersString = tt.addresses
+			cfg.EconNamesString = tt.names
+			cfg.EconPasswordsString = "pw"
+			cfg.Propagate = true
+
+			err := cfg.Validate()
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cfg.EconNames)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// fileEnvSuffix is appended to environment variables whose value is read from a file,
+// e.g. docker or kubernetes secrets.
+const fileEnvSuffix = "_FILE"
+
+// LoadEnvFiles sets every environment variable of the configuration to the content of the file that is referenced
+// by the same variable with _FILE suffix, e.g. ECON_PASSWORDS_FILE=/run/secrets/econ_passwords sets ECON_PASSWORDS.
+// A trailing line break is removed from the file content.
+func LoadEnvFiles() error {
+	for _, name := range envNames() {
+		file, ok := os.LookupEnv(name + fileEnvSuffix)
+		if !ok {
+			continue
+		}
+
+		if _, ok := os.LookupEnv(name); ok {
+			return fmt.Errorf("either set %s or %s%s, not both", name, name, fileEnvSuffix)
+		}
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s%s: %w", name, fileEnvSuffix, err)
+		}
+
+		err = os.Setenv(name, strings.TrimRight(string(data), "\r\n"))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// envNames returns the environment variable names of all configuration keys.
+func envNames() []string {
+	ct := reflect.TypeOf(Config{})
+	names := make([]string, 0, ct.NumField())
+	for i := 0; i < ct.NumField(); i++ {
+		key, ok := ct.Field(i).Tag.Lookup("koanf")
+		if !ok || key == "-" {
+			continue
+		}
+		names = append(names, strings.ToUpper(strings.ReplaceAll(key, ".", "_")))
+	}
+	return names
+}
@@ -0,0 +1,203 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jxsl13/banserver/model"
+	"github.com/jxsl13/banserver/parser"
+	"github.com/jxsl13/banserver/secret"
+	"gopkg.in/yaml.v3"
+)
+
+// serversFile describes every econ server with its own settings.
+//
+//	servers:
+//	  - address: 127.0.0.1:8303
+//	    password_file: /run/secrets/econ_password
+//	    name: "DDNet Novice #1"
+//	    group: eu
+//	    flavor: ddnet
+//	    rules: [ip-blacklist, chat-blacklist]
+//	    bans:
+//	      chat-blacklist:
+//	        reason: no advertising
+//	        duration: 1h
+type serversFile struct {
+	Servers []serverConfig `yaml:"servers"`
+}
+
+type serverConfig struct {
+	Address      string `yaml:"address"`
+	Password     string `yaml:"password"`
+	PasswordFile string `yaml:"password_file"`
+	Name         string `yaml:"name"`
+	Group        string `yaml:"group"`
+	Flavor       string `yaml:"flavor"`
+	SSH          string `yaml:"ssh"`
+	SSHJump      string `yaml:"ssh_jump"`
+	// nil enables all configured rules
+	Rules []string               `yaml:"rules"`
+	Bans  map[string]banOverride `yaml:"bans"`
+}
+
+type banOverride struct {
+	Reason   string        `yaml:"reason"`
+	Duration time.Duration `yaml:"duration"`
+}
+
+// validateServersFile creates the configuration of every econ server from the servers file.
+func (c *Config) validateServersFile() error {
+	if len(c.EconServersString) > 0 || len(c.EconNamesString) > 0 || len(c.EconPasswordsString) > 0 || len(c.EconPasswordFilesString) > 0 ||
+		len(c.EconGroupsString) > 0 || len(c.EconSSHHostsString) > 0 || len(c.EconSSHJumpHostsString) > 0 ||
+		(len(c.EconFlavorsString) > 0 && c.EconFlavorsString != string(parser.FlavorAuto)) {
+		return errors.New("econ addresses, names, passwords, password files, flavors, groups and ssh hosts must be configured in the servers file")
+	}
+
+	servers, err := readServersFile(c.ServersFile)
+	if err != nil {
+		return err
+	}
+
+	c.ServerRules = make(map[string]model.ServerRules, len(servers))
+	var (
+		hosts    []string
+		jumps    []string
+		tunneled bool
+	)
+	for _, server := range servers {
+		err = c.addServer(server)
+		if err != nil {
+			return fmt.Errorf("servers file %s: server %s: %w", c.ServersFile, server.Address, err)
+		}
+
+		hosts = append(hosts, server.SSH)
+		jumps = append(jumps, server.SSHJump)
+		tunneled = tunneled || server.SSH != "" || server.SSHJump != ""
+	}
+
+	if tunneled {
+		c.EconSSH, err = c.sshTunnels(hosts, jumps)
+		if err != nil {
+			return fmt.Errorf("servers file %s: %w", c.ServersFile, err)
+		}
+	}
+	return nil
+}
+
+func (c *Config) addServer(server serverConfig) error {
+	if _, ok := c.ServerRules[server.Address]; ok {
+		return errors.New("duplicate server address")
+	}
+
+	password, err := server.password()
+	if err != nil {
+		return err
+	}
+
+	flavor, err := parser.ParseFlavor(server.Flavor)
+	if err != nil {
+		return err
+	}
+
+	group, err := parseGroup(server.Group)
+	if err != nil {
+		return err
+	}
+
+	rules, err := server.rules()
+	if err != nil {
+		return err
+	}
+
+	name := strings.TrimSpace(server.Name)
+	if name == "" {
+		name = server.Address
+	}
+	if slices.Contains(c.EconNames, name) {
+		return fmt.Errorf("duplicate server name %q", name)
+	}
+
+	c.EconServers = append(c.EconServers, server.Address)
+	c.EconPasswords = append(c.EconPasswords, password)
+	c.EconNames = append(c.EconNames, name)
+	c.EconFlavors = append(c.EconFlavors, flavor)
+	c.EconGroups = append(c.EconGroups, group)
+	c.ServerRules[server.Address] = rules
+	return nil
+}
+
+func readServersFile(path string) ([]serverConfig, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open servers file: %w", err)
+	}
+	defer f.Close()
+
+	var file serversFile
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	err = dec.Decode(&file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse servers file %s: %w", path, err)
+	}
+
+	if len(file.Servers) == 0 {
+		return nil, fmt.Errorf("servers file %s does not contain any servers", path)
+	}
+
+	for idx, server := range file.Servers {
+		if strings.TrimSpace(server.Address) == "" {
+			return nil, fmt.Errorf("servers file %s: server %d: missing address", path, idx+1)
+		}
+		file.Servers[idx].Address = strings.TrimSpace(server.Address)
+	}
+	return file.Servers, nil
+}
+
+func (s serverConfig) password() (secret.Secret, error) {
+	switch {
+	case s.Password != "" && s.PasswordFile != "":
+		return "", errors.New("either provide a password or a password file, not both")
+	case s.PasswordFile != "":
+		return readPasswordFile(s.PasswordFile)
+	case s.Password != "":
+		return secret.Secret(s.Password), nil
+	default:
+		return "", errors.New("missing password or password file")
+	}
+}
+
+func (s serverConfig) rules() (model.ServerRules, error) {
+	var rules model.ServerRules
+	if s.Rules != nil {
+		rules.Enabled = make([]string, 0, len(s.Rules))
+		for _, r := range s.Rules {
+			rule, err := model.ParseRule(r)
+			if err != nil {
+				return rules, err
+			}
+			rules.Enabled = append(rules.Enabled, rule)
+		}
+	}
+
+	if len(s.Bans) > 0 {
+		rules.Bans = make(map[string]model.BanOverride, len(s.Bans))
+		for r, ban := range s.Bans {
+			rule, err := model.ParseRule(r)
+			if err != nil {
+				return rules, err
+			}
+
+			if ban.Duration != 0 && ban.Duration < time.Minute {
+				return rules, fmt.Errorf("ban duration of rule %s must be at least 1m", rule)
+			}
+			rules.Bans[rule] = model.BanOverride{Reason: ban.Reason, Duration: ban.Duration}
+		}
+	}
+	return rules, nil
+}
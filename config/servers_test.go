@@ -0,0 +1,99 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jxsl13/banserver/model"
+	"github.com/jxsl13/banserver/parser"
+	"github.com/jxsl13/banserver/secret"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateServersFile(t *testing.T) {
+	dir := t.TempDir()
+	passwordFile := filepath.Join(dir, "password")
+	require.NoError(t, os.WriteFile(passwordFile, []byte("pass,word\n"), 0o600))
+
+	writeServersFile := func(content string) string {
+		path := filepath.Join(t.TempDir(), "servers.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	cfg := New()
+	cfg.Propagate = true
+	cfg.ServersFile = writeServersFile(`
+servers:
+  - address: 127.0.0.1:8303
+    password: secret
+    name: "DDNet Novice #1"
+    group: eu
+    flavor: ddnet
+    rules: [ip-blacklist, chat-blacklist]
+    bans:
+      chat-blacklist:
+        reason: no advertising
+        duration: 1h
+  - address: 127.0.0.1:8304
+    password_file: ` + passwordFile + `
+    rules: []
+`)
+	require.NoError(t, cfg.Validate())
+
+	assert.Equal(t, []string{"127.0.0.1:8303", "127.0.0.1:8304"}, cfg.EconServers)
+	assert.Equal(t, []secret.Secret{"secret", "pass,word"}, cfg.EconPasswords)
+	assert.Equal(t, []string{"DDNet Novice #1", "127.0.0.1:8304"}, cfg.EconNames)
+	assert.Equal(t, []parser.Flavor{parser.FlavorDDNet, parser.FlavorAuto}, cfg.EconFlavors)
+	assert.Equal(t, []string{"eu", ""}, cfg.EconGroups)
+	assert.Nil(t, cfg.EconSSH)
+	assert.Equal(t, map[string]model.ServerRules{
+		"127.0.0.1:8303": {
+			Enabled: []string{model.RuleIPBlacklist, model.RuleChatBlacklist},
+			Bans: map[string]model.BanOverride{
+				model.RuleChatBlacklist: {Reason: "no advertising", Duration: time.Hour},
+			},
+		},
+		"127.0.0.1:8304": {Enabled: []string{}},
+	}, cfg.ServerRules)
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"no servers", "servers: []"},
+		{"unknown field", "servers:\n  - address: a:1\n    password: pw\n    passwort: pw"},
+		{"missing address", "servers:\n  - password: pw"},
+		{"missing password", "servers:\n  - address: a:1"},
+		{"password and password file", "servers:\n  - address: a:1\n    password: pw\n    password_file: " + passwordFile},
+		{"duplicate address", "servers:\n  - address: a:1\n    password: pw\n  - address: a:1\n    password: pw"},
+		{"duplicate name", "servers:\n  - address: a:1\n    password: pw\n    name: x\n  - address: a:2\n    password: pw\n    name: x"},
+		{"unknown flavor", "servers:\n  - address: a:1\n    password: pw\n    flavor: unknown"},
+		{"invalid group", "servers:\n  - address: a:1\n    password: pw\n    group: a:b"},
+		{"unknown rule", "servers:\n  - address: a:1\n    password: pw\n    rules: [chat]"},
+		{"unknown ban rule", "servers:\n  - address: a:1\n    password: pw\n    bans: {chat: {reason: x}}"},
+		{"short ban", "servers:\n  - address: a:1\n    password: pw\n    bans: {vote: {duration: 10s}}"},
+		{"jump host without ssh host", "servers:\n  - address: a:1\n    password: pw\n    ssh_jump: user@jump"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := New()
+			cfg.Propagate = true
+			cfg.EconSSHKeyFile = passwordFile
+			cfg.EconSSHKnownHosts = passwordFile
+			cfg.ServersFile = writeServersFile(tt.content)
+			require.Error(t, cfg.Validate())
+		})
+	}
+
+	// the servers file replaces the econ addresses
+	cfg = New()
+	cfg.Propagate = true
+	cfg.ServersFile = writeServersFile("servers:\n  - address: a:1\n    password: pw")
+	cfg.EconServersString = "a:1"
+	require.Error(t, cfg.Validate())
+}
@@ -0,0 +1,460 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/netip"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jxsl13/banserver/admin"
+	"github.com/jxsl13/banserver/model"
+	"github.com/jxsl13/cli-config-boilerplate/cliconfig"
+	"github.com/spf13/cobra"
+)
+
+const consoleHelp = `commands:
+  servers                                   list all game servers
+  players [server]                          list the players of all or one game server
+  ban [@server] <ip> [duration] [reason]    ban an ip on all or one game server, duration 0 is permanent
+  unban [@server] <ip>                      unban an ip on all or one game server
+  say [@server] <message>                   send a chat message to all or one game server
+  broadcast [@server] <message>             broadcast a message to all or one game server
+  events [server]                           follow the events of all or one game server, press enter to stop
+  reload                                    reload all ip and chat blacklists
+  rcon [@server|@group ...] <command>       execute a console command on all or the selected game servers
+  help                                      show this help
+  exit                                      leave the console
+
+Servers are selected by their name or address, names with spaces must be quoted, e.g. @"DDNet Novice #1".
+`
+
+// consoleConfig only requires the admin socket of the running banserver.
+type consoleConfig struct {
+	AdminSocket string        `koanf:"admin.socket" description:"unix socket of the admin interface of the running banserver"`
+	RconTimeout time.Duration `koanf:"rcon.timeout" description:"time to collect the output of rcon commands on the game servers"`
+}
+
+func (c *consoleConfig) Validate() error {
+	if c.AdminSocket == "" {
+		return errors.New("missing admin socket of the running banserver")
+	}
+	if c.RconTimeout <= 0 || c.RconTimeout > maxRconTimeout {
+		return fmt.Errorf("rcon timeout must be between 0 and %s", maxRconTimeout)
+	}
+	return nil
+}
+
+func NewConsoleCommand(ctx context.Context) *cobra.Command {
+	cfg := &consoleConfig{
+		RconTimeout: defaultRconTimeout,
+	}
+	cmd := &cobra.Command{
+		Use:   "console [command]",
+		Short: "Interactive admin console of the running banserver",
+		Long: `Connects to the admin socket of the running banserver and allows to list game servers and players,
+ban and unban ips, send messages, follow events live and reload blacklists without rcon access to every game server.
+A single command can be passed as arguments, e.g. 'banserver console players', otherwise an interactive shell is started.
+
+` + consoleHelp + `
+`,
+		SilenceUsage: true,
+	}
+
+	cfgParser := cliconfig.RegisterFlags(cfg, false, cmd)
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		log.SetOutput(cmd.ErrOrStderr())
+		err := cfgParser()
+		if err != nil {
+			return err
+		}
+
+		client, err := admin.Dial(ctx, cfg.AdminSocket)
+		if err != nil {
+			return err
+		}
+
+		c := &console{
+			ctx:         ctx,
+			path:        cfg.AdminSocket,
+			rconTimeout: cfg.RconTimeout,
+			client:      client,
+			out:         cmd.OutOrStdout(),
+			lines:       readLines(cmd.InOrStdin()),
+		}
+		defer func() {
+			c.client.Close()
+		}()
+
+		if len(args) > 0 {
+			return c.exec(args)
+		}
+		return c.run()
+	}
+	return cmd
+}
+
+type console struct {
+	ctx         context.Context
+	path        string
+	rconTimeout time.Duration
+	client      *admin.Client
+	out         io.Writer
+	// lines of the standard input, closed at the end of the input
+	lines <-chan string
+}
+
+// readLines reads the lines of r in the background, as following events is stopped by any input.
+func readLines(r io.Reader) <-chan string {
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+	return lines
+}
+
+func (c *console) run() error {
+	fmt.Fprintf(c.out, "connected to banserver at %s, type help for a list of commands\n", c.path)
+	for {
+		fmt.Fprint(c.out, "banserver> ")
+
+		var (
+			line string
+			ok   bool
+		)
+		select {
+		case <-c.ctx.Done():
+			fmt.Fprintln(c.out)
+			return nil
+		case line, ok = <-c.lines:
+		}
+		if !ok {
+			fmt.Fprintln(c.out)
+			return nil
+		}
+
+		words, err := splitWords(line)
+		if err != nil {
+			fmt.Fprintf(c.out, "error: %v\n", err)
+			continue
+		}
+		if len(words) == 0 {
+			continue
+		}
+		if words[0] == "exit" || words[0] == "quit" {
+			return nil
+		}
+
+		err = c.exec(words)
+		if err != nil {
+			fmt.Fprintf(c.out, "error: %v\n", err)
+		}
+		if c.ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+func (c *console) exec(words []string) error {
+	command, args := words[0], words[1:]
+	switch command {
+	case "help":
+		fmt.Fprint(c.out, consoleHelp)
+		return nil
+	case adminServers:
+		return c.servers()
+	case adminPlayers:
+		return c.players(args)
+	case adminBan:
+		return c.ban(args)
+	case adminUnban:
+		return c.unban(args)
+	case adminSay, adminBroadcast:
+		return c.message(command, args)
+	case adminEvents:
+		return c.events(args)
+	case adminReload:
+		err := c.client.Call(c.ctx, adminReload, nil, nil)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(c.out, "reloaded all blacklists")
+		return nil
+	case adminRcon:
+		return c.rcon(args)
+	default:
+		return fmt.Errorf("unknown command %q, type help for a list of commands", command)
+	}
+}
+
+func (c *console) servers() error {
+	var servers []serverInfo
+	err := c.client.Call(c.ctx, adminServers, nil, &servers)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tADDRESS\tGROUP\tFLAVOR\tPLAYERS")
+	for _, s := range servers {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\n", s.Name, s.Address, s.Group, s.Flavor, s.Players)
+	}
+	return w.Flush()
+}
+
+func (c *console) players(args []string) error {
+	if len(args) > 1 {
+		return errors.New("usage: players [server]")
+	}
+	var p serverParams
+	if len(args) == 1 {
+		p.Server = strings.TrimPrefix(args[0], "@")
+	}
+
+	var servers []serverPlayers
+	err := c.client.Call(c.ctx, adminPlayers, p, &servers)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SERVER\tID\tIP\tNAME")
+	for _, s := range servers {
+		for _, player := range s.Players {
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", s.Server, player.ID, player.IP, player.Name)
+		}
+	}
+	return w.Flush()
+}
+
+// serverArg removes the optional @server argument.
+func serverArg(args []string) (server string, rest []string) {
+	if len(args) > 0 && strings.HasPrefix(args[0], "@") {
+		return strings.TrimPrefix(args[0], "@"), args[1:]
+	}
+	return "", args
+}
+
+func (c *console) ban(args []string) error {
+	server, args := serverArg(args)
+	if len(args) == 0 {
+		return errors.New("usage: ban [@server] <ip> [duration] [reason]")
+	}
+
+	ip, err := netip.ParseAddr(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid ip: %w", err)
+	}
+	p := banParams{Server: server, IP: ip}
+
+	args = args[1:]
+	if len(args) > 0 {
+		if _, err := time.ParseDuration(args[0]); err == nil {
+			p.Duration = args[0]
+			args = args[1:]
+		}
+	}
+	p.Reason = strings.Join(args, " ")
+
+	var result actionResult
+	err = c.client.Call(c.ctx, adminBan, p, &result)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.out, "banned %s on %s\n", ip, strings.Join(result.Servers, ", "))
+	return nil
+}
+
+func (c *console) unban(args []string) error {
+	server, args := serverArg(args)
+	if len(args) != 1 {
+		return errors.New("usage: unban [@server] <ip>")
+	}
+
+	ip, err := netip.ParseAddr(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid ip: %w", err)
+	}
+
+	var result actionResult
+	err = c.client.Call(c.ctx, adminUnban, banParams{Server: server, IP: ip}, &result)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.out, "unbanned %s on %s\n", ip, strings.Join(result.Servers, ", "))
+	return nil
+}
+
+func (c *console) message(command string, args []string) error {
+	server, args := serverArg(args)
+	if len(args) == 0 {
+		return fmt.Errorf("usage: %s [@server] <message>", command)
+	}
+
+	var result actionResult
+	err := c.client.Call(c.ctx, command, messageParams{Server: server, Message: strings.Join(args, " ")}, &result)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.out, "sent message to %s\n", strings.Join(result.Servers, ", "))
+	return nil
+}
+
+func (c *console) rcon(args []string) error {
+	var servers []string
+	for len(args) > 0 && strings.HasPrefix(args[0], "@") {
+		servers = append(servers, strings.TrimPrefix(args[0], "@"))
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return errors.New("usage: rcon [@server|@group ...] <command>")
+	}
+
+	p := rconParams{
+		Servers: servers,
+		Command: joinCommand(args),
+		Timeout: c.rconTimeout.String(),
+	}
+
+	var results []model.ExecResult
+	err := c.client.Call(c.ctx, adminRcon, p, &results)
+	if err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		if result.Error != "" {
+			fmt.Fprintf(c.out, "%s: error: %s\n", result.Server, result.Error)
+		}
+		for _, line := range result.Lines {
+			fmt.Fprintf(c.out, "%s: %s\n", result.Server, line)
+		}
+	}
+	return nil
+}
+
+// joinCommand quotes the words that splitWords unquoted, so that e.g. sv_motd "hello world" is sent as is.
+func joinCommand(words []string) string {
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	quoted := make([]string, 0, len(words))
+	for _, word := range words {
+		if word == "" || strings.ContainsAny(word, " \t;\"\\") {
+			word = `"` + escape.Replace(word) + `"`
+		}
+		quoted = append(quoted, word)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// events follows the events until enter is pressed or the console is interrupted.
+func (c *console) events(args []string) error {
+	if len(args) > 1 {
+		return errors.New("usage: events [server]")
+	}
+	var p serverParams
+	if len(args) == 1 {
+		p.Server = strings.TrimPrefix(args[0], "@")
+	}
+
+	// the stream closes its connection
+	client, err := admin.Dial(c.ctx, c.path)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(c.ctx)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- client.Stream(ctx, adminEvents, p, func(data json.RawMessage) error {
+			var event struct {
+				Time   time.Time       `json:"time"`
+				Server string          `json:"server"`
+				Type   string          `json:"type"`
+				Data   json.RawMessage `json:"data"`
+			}
+			if err := json.Unmarshal(data, &event); err != nil {
+				return err
+			}
+			fmt.Fprintf(c.out, "%s %s %s %s\n", event.Time.Format(time.TimeOnly), event.Server, event.Type, event.Data)
+			return nil
+		})
+	}()
+
+	lines := c.lines
+	for {
+		select {
+		case err = <-done:
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+			return err
+		case _, ok := <-lines:
+			if !ok {
+				// no more input, follow the events until the console is interrupted
+				lines = nil
+				continue
+			}
+			cancel()
+		}
+	}
+}
+
+// splitWords splits a command line at spaces, quoted parts may contain spaces, e.g. @"DDNet Novice #1".
+func splitWords(line string) ([]string, error) {
+	var (
+		words   []string
+		word    strings.Builder
+		inWord  bool
+		quote   rune
+		escaped bool
+	)
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+			inWord = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("missing closing quote %c", quote)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
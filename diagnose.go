@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/netip"
+	"strings"
+
+	"github.com/jxsl13/banserver/admin"
+	"github.com/jxsl13/banserver/config"
+	"github.com/jxsl13/banserver/model"
+	"github.com/jxsl13/cli-config-boilerplate/cliconfig"
+	"github.com/spf13/cobra"
+)
+
+// diagnoseOptions either loads the configured blacklists or asks the running banserver via its admin socket.
+type diagnoseOptions struct {
+	ctx  context.Context
+	cfg  *config.Config
+	live bool
+}
+
+func newDiagnoseOptions(ctx context.Context, cmd *cobra.Command) (*diagnoseOptions, func() error) {
+	opts := &diagnoseOptions{
+		ctx: ctx,
+		cfg: config.New(),
+	}
+
+	cfgParser := cliconfig.RegisterFlags(opts.cfg, false, cmd)
+	cmd.Flags().BoolVar(&opts.live, "live", false, "ask the running banserver via its admin socket instead of loading the blacklists")
+
+	return opts, func() error {
+		log.SetOutput(cmd.ErrOrStderr())
+		err := config.LoadEnvFiles()
+		if err != nil {
+			return err
+		}
+		return cfgParser()
+	}
+}
+
+// call executes the admin command either against the running banserver or against the loaded blacklists.
+func call[P, R any](o *diagnoseOptions, command string, params P, local func(*model.Broker, P) (R, error)) (result R, err error) {
+	if !o.live {
+		broker := newBroker(o.cfg)
+		defer func() {
+			err = errors.Join(err, broker.Close())
+		}()
+
+		err = loadBlacklists(o.ctx, broker, o.cfg)
+		if err != nil {
+			return result, err
+		}
+		return local(broker, params)
+	}
+
+	if o.cfg.AdminSocket == "" {
+		return result, errors.New("--live requires the admin socket of the running banserver")
+	}
+
+	client, err := admin.Dial(o.ctx, o.cfg.AdminSocket)
+	if err != nil {
+		return result, err
+	}
+	defer client.Close()
+
+	err = client.Call(o.ctx, command, params, &result)
+	return result, err
+}
+
+func NewTestIPCommand(ctx context.Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "test-ip [flags] <ip>...",
+		Short: "Show the ip blacklist entries that contain the given ips",
+		Long: `Loads the configured ip blacklists and shows every entry that contains the given ips together with
+the blacklist and line it was loaded from. With --live the running banserver is asked via its admin socket,
+which also includes manual bans and the current state of refreshed http(s) blacklists.
+
+`,
+		Args:         cobra.MinimumNArgs(1),
+		SilenceUsage: true,
+	}
+
+	opts, parse := newDiagnoseOptions(ctx, cmd)
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ips := make([]netip.Addr, 0, len(args))
+		for _, arg := range args {
+			ip, err := netip.ParseAddr(strings.Trim(arg, "[]"))
+			if err != nil {
+				return fmt.Errorf("invalid ip %q: %w", arg, err)
+			}
+			ips = append(ips, ip)
+		}
+
+		err := parse()
+		if err != nil {
+			return err
+		}
+
+		results, err := call(opts, adminTestIP, testIPParams{IPs: ips}, testIP)
+		if err != nil {
+			return err
+		}
+
+		for _, result := range results {
+			printTestIP(cmd.OutOrStdout(), result)
+		}
+		return nil
+	}
+	return cmd
+}
+
+func printTestIP(w io.Writer, result testIPResult) {
+	if len(result.Matches) == 0 {
+		fmt.Fprintf(w, "%s is not contained in any ip blacklist\n", result.IP)
+		return
+	}
+
+	fmt.Fprintf(w, "%s is contained in %d ip blacklist entries:\n", result.IP, len(result.Matches))
+	for _, match := range result.Matches {
+		fmt.Fprintf(w, "  %s\n", match)
+	}
+}
+
+func NewTestChatCommand(ctx context.Context) *cobra.Command {
+	var scope string
+	cmd := &cobra.Command{
+		Use:   "test-chat [flags] <message or nickname>",
+		Short: "Show the chat blacklist rules that match the given message or nickname",
+		Long: `Loads the configured chat blacklists and shows every regular expression that matches the given text together
+with the blacklist and line it was loaded from. The text is checked like a chat message of the given scope.
+With --live the running banserver is asked via its admin socket.
+
+`,
+		Args:         cobra.MinimumNArgs(1),
+		SilenceUsage: true,
+	}
+
+	opts, parse := newDiagnoseOptions(ctx, cmd)
+	cmd.Flags().StringVar(&scope, "scope", model.ChatScopePublic, "scope of the message, one of public, team or whisper")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		err := parse()
+		if err != nil {
+			return err
+		}
+
+		params := testChatParams{Message: strings.Join(args, " "), Scope: scope}
+		result, err := call(opts, adminTestChat, params, testChat)
+		if err != nil {
+			return err
+		}
+
+		w := cmd.OutOrStdout()
+		if len(result.Matches) == 0 {
+			fmt.Fprintln(w, "no chat blacklist rule matches")
+			return nil
+		}
+
+		fmt.Fprintf(w, "%d chat blacklist rules match:\n", len(result.Matches))
+		for _, match := range result.Matches {
+			fmt.Fprintf(w, "  %s\n", match)
+		}
+		return nil
+	}
+	return cmd
+}
@@ -0,0 +1,59 @@
+package econ
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/jxsl13/banserver/parser"
+)
+
+// BanList is dispatched after the complete output of the bans command has been received.
+// It contains all bans of the server at that time, even if the server lists its bans in multiple pages.
+type BanList struct {
+	Bans []parser.BanListEntry
+}
+
+// ListBans requests the ban list of the server, which is dispatched as BanList event.
+func (s *Server) ListBans() error {
+	s.mu.Lock()
+	s.listingBans = true
+	s.mu.Unlock()
+
+	return s.send("bans")
+}
+
+// collectBans collects the listed bans until the summary line of the ban list has been received.
+// Bans that are listed by an admin are dispatched as well, as long as the listing is complete.
+// Only accessed by the line processor.
+func (s *Server) collectBans(process EventHandler, line string, event parser.Event) {
+	switch e := event.(type) {
+	case parser.BanListEntry:
+		s.banList = append(s.banList, e)
+	case parser.BanListSummary:
+		s.mu.Lock()
+		listing := s.listingBans
+		s.mu.Unlock()
+
+		if len(s.banList) < e.Total && e.HasNextPage() && listing {
+			err := s.send(fmt.Sprintf("bans %d", e.NextPage()))
+			if err == nil {
+				return
+			}
+			log.Printf("failed to request next page of the ban list of %s: %v", s, err)
+		}
+
+		bans := s.banList
+		s.banList = nil
+		s.mu.Lock()
+		s.listingBans = false
+		s.mu.Unlock()
+
+		if len(bans) != e.Total {
+			if listing {
+				log.Printf("incomplete ban list of %s: received %d of %d bans", s, len(bans), e.Total)
+			}
+			return
+		}
+		s.safeProcess(process, line, BanList{Bans: bans})
+	}
+}
@@ -0,0 +1,99 @@
+package econ_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jxsl13/banserver/econ"
+	"github.com/jxsl13/banserver/econ/econtest"
+	"github.com/jxsl13/banserver/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ddnetLine(system, message string) string {
+	return fmt.Sprintf("2024-11-25 01:12:00 I %s: %s", system, message)
+}
+
+// newTestServer connects to a fake ddnet server and passes all events to the returned channel.
+func newTestServer(t *testing.T) (*econ.Server, *econtest.Conn, <-chan parser.Event) {
+	t.Helper()
+	events := make(chan parser.Event, 64)
+	conn := econtest.NewConn()
+	s := econ.NewServer(context.Background(), "127.0.0.1:8303", conn, func(_ *econ.Server, event parser.Event) {
+		events <- event
+	}, econ.WithFlavor(parser.FlavorDDNet))
+	t.Cleanup(func() {
+		require.NoError(t, s.Close())
+	})
+
+	require.NoError(t, s.ListBans())
+	require.Equal(t, "bans", conn.Command(t))
+	return s, conn, events
+}
+
+func TestBanListPages(t *testing.T) {
+	_, conn, events := newTestServer(t)
+
+	entry := func(index int) string {
+		return ddnetLine("net_ban", fmt.Sprintf("#%d '1.2.3.%d' banned for 120 minutes (spam)", index, index))
+	}
+
+	conn.Log(entry(0), entry(1), ddnetLine("net_ban", "5 bans, showing entries 0 - 1"))
+	assert.Equal(t, "bans 1", conn.Command(t))
+	conn.Log(entry(2), entry(3), ddnetLine("net_ban", "5 bans, showing entries 2 - 3"))
+	assert.Equal(t, "bans 2", conn.Command(t))
+	// last page
+	conn.Log(entry(4), ddnetLine("net_ban", "5 bans, showing entries 4 - 4"))
+
+	var lists []econ.BanList
+	timeout := time.After(200 * time.Millisecond)
+	for done := false; !done; {
+		select {
+		case event := <-events:
+			if list, ok := event.(econ.BanList); ok {
+				lists = append(lists, list)
+			}
+		case <-timeout:
+			done = true
+		}
+	}
+
+	require.Len(t, lists, 1)
+	require.Len(t, lists[0].Bans, 5)
+	for i, ban := range lists[0].Bans {
+		assert.Equal(t, i, ban.Index)
+		assert.Equal(t, fmt.Sprintf("1.2.3.%d", i), ban.IP.String())
+		assert.Equal(t, 120*time.Minute, ban.Duration)
+	}
+}
+
+func TestBanListIncomplete(t *testing.T) {
+	_, conn, events := newTestServer(t)
+
+	conn.Log(
+		ddnetLine("net_ban", "#0 '1.2.3.0' banned for life (spam)"),
+		ddnetLine("net_ban", "3 bans, showing entries 0 - 0"),
+	)
+	assert.Equal(t, "bans 1", conn.Command(t))
+	// the entry of the second page is missing
+	conn.Log(ddnetLine("net_ban", "3 bans, showing entries 1 - 1"))
+	assert.Equal(t, "bans 2", conn.Command(t))
+	conn.Log(
+		ddnetLine("net_ban", "#2 '1.2.3.2' banned for life (spam)"),
+		ddnetLine("net_ban", "3 bans, showing entries 2 - 2"),
+	)
+
+	timeout := time.After(200 * time.Millisecond)
+	for {
+		select {
+		case event := <-events:
+			_, ok := event.(econ.BanList)
+			require.False(t, ok, "incomplete ban lists must not be dispatched")
+		case <-timeout:
+			return
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package econ
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jxsl13/banserver/parser"
+)
+
+// ErrNotConfirmed is returned in case that the server did not confirm a command in time.
+var ErrNotConfirmed = errors.New("not confirmed")
+
+// expectation is resolved by the first event of the server that matches.
+type expectation struct {
+	match func(parser.Event) bool
+	done  chan struct{}
+}
+
+// Confirmation waits for the event that confirms a command, e.g. the ban line of a ban command.
+type Confirmation struct {
+	s *Server
+	e *expectation
+}
+
+// expect registers the expectation before the command is sent, so that a fast answer cannot be missed.
+func (s *Server) expect(match func(parser.Event) bool) *Confirmation {
+	e := &expectation{
+		match: match,
+		done:  make(chan struct{}),
+	}
+
+	s.outputMu.Lock()
+	s.expectations[e] = struct{}{}
+	s.outputMu.Unlock()
+	return &Confirmation{s: s, e: e}
+}
+
+// confirm resolves all expectations that match the event, only called by the line processor.
+func (s *Server) confirm(event parser.Event) {
+	s.outputMu.Lock()
+	defer s.outputMu.Unlock()
+
+	for e := range s.expectations {
+		if e.match(event) {
+			close(e.done)
+			delete(s.expectations, e)
+		}
+	}
+}
+
+// Wait blocks until the command was confirmed, the timeout expired or the server was closed.
+// The confirmation is cancelled afterwards.
+func (c *Confirmation) Wait(ctx context.Context, timeout time.Duration) error {
+	defer c.Cancel()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-c.e.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.s.ctx.Done():
+		return fmt.Errorf("%s: %w", c.s, ErrClosed)
+	case <-timer.C:
+		return fmt.Errorf("%s did not confirm the command within %s: %w", c.s, timeout, ErrNotConfirmed)
+	}
+}
+
+// Cancel stops waiting for the confirmation.
+func (c *Confirmation) Cancel() {
+	c.s.outputMu.Lock()
+	delete(c.s.expectations, c.e)
+	c.s.outputMu.Unlock()
+}
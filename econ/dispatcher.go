@@ -0,0 +1,42 @@
+package econ
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/jxsl13/banserver/parser"
+)
+
+// Dispatcher passes parsed events to all subscribers of the event's type.
+type Dispatcher struct {
+	mu       sync.RWMutex
+	handlers map[reflect.Type][]EventHandler
+}
+
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{
+		handlers: make(map[reflect.Type][]EventHandler),
+	}
+}
+
+// Subscribe registers a handler for all events of type E.
+func Subscribe[E any](d *Dispatcher, handler func(s *Server, event E)) {
+	t := reflect.TypeFor[E]()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.handlers[t] = append(d.handlers[t], func(s *Server, event parser.Event) {
+		handler(s, event.(E))
+	})
+}
+
+// Dispatch passes the event to every subscriber of the event's type.
+func (d *Dispatcher) Dispatch(s *Server, event parser.Event) {
+	d.mu.RLock()
+	handlers := d.handlers[reflect.TypeOf(event)]
+	d.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(s, event)
+	}
+}
@@ -0,0 +1,42 @@
+package econ_test
+
+import (
+	"testing"
+
+	"github.com/jxsl13/banserver/econ"
+	"github.com/jxsl13/banserver/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDispatcher(t *testing.T) {
+	d := econ.NewDispatcher()
+
+	var (
+		chats    []parser.ChatMessage
+		entered  int
+		unbanned int
+	)
+	econ.Subscribe(d, func(_ *econ.Server, e parser.ChatMessage) {
+		chats = append(chats, e)
+	})
+	econ.Subscribe(d, func(_ *econ.Server, e parser.ClientEntered) {
+		entered++
+	})
+	econ.Subscribe(d, func(_ *econ.Server, e parser.ClientEntered) {
+		entered++
+	})
+
+	chat := parser.ChatMessage{ClientID: 1, Message: "hi"}
+	d.Dispatch(nil, chat)
+	d.Dispatch(nil, parser.ClientEntered{ClientID: 2})
+	// no subscribers
+	d.Dispatch(nil, parser.ClientUnbanned{})
+	econ.Subscribe(d, func(_ *econ.Server, e parser.ClientUnbanned) {
+		unbanned++
+	})
+	d.Dispatch(nil, parser.ClientUnbanned{})
+
+	assert.Equal(t, []parser.ChatMessage{chat}, chats)
+	assert.Equal(t, 2, entered)
+	assert.Equal(t, 1, unbanned)
+}
@@ -5,38 +5,116 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"net/netip"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/jxsl13/banserver/parser"
+	"github.com/jxsl13/banserver/secret"
+	"github.com/jxsl13/banserver/sshtunnel"
 	"github.com/teeworlds-go/econ"
 )
 
-func DialTo(ctx context.Context, addrPort, password string, handler LineHandler) (_ *Server, err error) {
-	ctx, cancel := context.WithCancel(ctx)
+// ErrClosed is returned in case that a command is sent to or awaited from a closed server.
+var ErrClosed = errors.New("connection closed")
+
+type Option func(*Server)
+
+// WithFlavor restricts the parsing of log lines to the patterns of the given server flavor.
+func WithFlavor(flavor parser.Flavor) Option {
+	return func(s *Server) {
+		s.flavor = flavor
+	}
+}
+
+// WithGroup assigns the server to a group, e.g. servers of the same region that share rules.
+func WithGroup(group string) Option {
+	return func(s *Server) {
+		s.group = group
+	}
+}
+
+// WithName sets a human friendly name of the server, e.g. "DDNet Novice #1", which is used instead of the address.
+func WithName(name string) Option {
+	return func(s *Server) {
+		s.name = name
+	}
+}
+
+// WithSSHTunnel establishes the econ connection through an ssh tunnel, the econ address is dialed by the ssh server.
+func WithSSHTunnel(cfg sshtunnel.Config) Option {
+	return func(s *Server) {
+		s.sshConfig = &cfg
+	}
+}
+
+// Conn is an established econ connection, e.g. a fake game server in tests.
+type Conn interface {
+	ReadLine() (string, error)
+	WriteLine(line string) error
+	Close() error
+}
+
+// DialTo connects to the econ server and processes its log lines, see NewServer.
+func DialTo(ctx context.Context, addrPort string, password secret.Secret, handler EventHandler, opts ...Option) (_ *Server, err error) {
+	s := newServer(ctx, addrPort, password, opts...)
 	defer func() {
 		if err != nil {
-			cancel()
+			s.cancel()
 		}
 	}()
 
-	conn, err := econ.DialTo(addrPort, password, econ.WithContext(ctx))
+	conn, tunnel, err := s.dial(s.ctx)
 	if err != nil {
 		return nil, err
 	}
+	s.tunnel = tunnel
+
+	s.start(conn, handler)
+	return s, nil
+}
 
+// NewServer processes the log lines of an already established econ connection, which is closed together
+// with the server. The ssh tunnel option is ignored.
+func NewServer(ctx context.Context, addrPort string, conn Conn, handler EventHandler, opts ...Option) *Server {
+	s := newServer(ctx, addrPort, "", opts...)
+	s.start(conn, handler)
+	return s
+}
+
+func newServer(ctx context.Context, addrPort string, password secret.Secret, opts ...Option) *Server {
+	ctx, cancel := context.WithCancel(ctx)
 	s := &Server{
 		ctx:         ctx,
 		cancel:      cancel,
 		addrPort:    addrPort,
 		password:    password,
-		conn:        conn,
 		lineChan:    make(chan string),
-		commandChan: make(chan string),
-		clients:     make(map[int]string),
+		commandChan: make(chan command),
+		clients:     make(map[int]Client),
+
+		outputWriters: make(map[chan string]struct{}),
+		expectations:  make(map[*expectation]struct{}),
+
+		ignoredBanPropagation:   make(map[string]map[netip.Addr]int),
+		ignoredUnbanPropagation: make(map[string]map[netip.Addr]int),
+		flavor:                  parser.FlavorAuto,
+	}
 
-		ignoredBanPropagation:   make(map[string]map[string]struct{}),
-		ignoredUnbanPropagation: make(map[string]map[string]struct{}),
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// start processes the lines of the connection and requests the version of servers with unknown flavor.
+// The ban list is requested by the caller with ListBans, as soon as it is ready to process it.
+func (s *Server) start(conn Conn, handler EventHandler) {
+	s.conn = conn
+	if s.flavor == parser.FlavorAuto {
+		s.detector = &parser.FlavorDetector{}
 	}
 
 	s.wg.Add(3) // 3 goroutines are started
@@ -44,7 +122,60 @@ func DialTo(ctx context.Context, addrPort, password string, handler LineHandler)
 	go s.asyncWriteLine()
 	go s.asyncProcess(handler)
 
-	return s, nil
+	if s.flavor == parser.FlavorAuto {
+		// the version output allows to distinguish between flavors with the same line prefix
+		err := s.send("version")
+		if err != nil {
+			log.Printf("failed to request version of %s for flavor detection: %v", s, err)
+		}
+	}
+}
+
+// Check connects to the econ server and disconnects again, which verifies that the server is reachable
+// and that the password is correct.
+func Check(ctx context.Context, addrPort string, password secret.Secret, opts ...Option) error {
+	s := &Server{
+		addrPort: addrPort,
+		password: password,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	conn, tunnel, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = conn.Close()
+	if tunnel != nil {
+		err = errors.Join(err, tunnel.Close())
+	}
+	return err
+}
+
+// dial connects to the econ server, either directly or through an ssh tunnel, which is nil for direct connections.
+func (s *Server) dial(ctx context.Context) (_ *econ.Conn, tunnel *sshtunnel.Tunnel, err error) {
+	dialAddr := s.addrPort
+	if s.sshConfig != nil {
+		tunnel, err = sshtunnel.Open(*s.sshConfig, s.addrPort)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open ssh tunnel to %s: %w", s, err)
+		}
+		defer func() {
+			if err != nil {
+				tunnel.Close()
+			}
+		}()
+		dialAddr = tunnel.Addr()
+	}
+
+	conn, err := econ.DialTo(dialAddr, s.password.Value(), econ.WithContext(ctx))
+	if err != nil {
+		return nil, nil, secret.RedactError(err, s.password)
+	}
+	return conn, tunnel, nil
 }
 
 type Server struct {
@@ -53,26 +184,52 @@ type Server struct {
 	wg     sync.WaitGroup
 
 	addrPort string
-	password string
+	password secret.Secret
+	name     string
 
-	conn *econ.Conn
+	group string
+
+	// configured or detected flavor
+	flavor parser.Flavor
+	// nil in case that the flavor is known, only accessed by the line processor
+	detector *parser.FlavorDetector
+
+	conn Conn
+
+	// nil in case that the server is dialed directly
+	sshConfig *sshtunnel.Config
+	tunnel    *sshtunnel.Tunnel
 
 	lineChan    chan string
-	commandChan chan string
+	commandChan chan command
 
-	// ID -> IP
+	// ID -> client
 	mu      sync.Mutex
-	clients map[int]string
-
-	// server -> ip
-	ignoredBanPropagation   map[string]map[string]struct{}
-	ignoredUnbanPropagation map[string]map[string]struct{}
+	clients map[int]Client
+
+	// the ban list is requested by the banserver and not by an admin
+	listingBans bool
+	// listed bans until the summary line was received, only accessed by the line processor
+	banList []parser.BanListEntry
+
+	// receive all lines of the server while a command is executed
+	// and wait for the events that confirm commands
+	outputMu      sync.Mutex
+	outputWriters map[chan string]struct{}
+	expectations  map[*expectation]struct{}
+
+	// server -> ip -> number of ignored lines
+	ignoredBanPropagation   map[string]map[netip.Addr]int
+	ignoredUnbanPropagation map[string]map[netip.Addr]int
 }
 
 func (s *Server) Close() error {
 	s.cancel()
 	err := s.conn.Close()
 	s.wg.Wait()
+	if s.tunnel != nil {
+		err = errors.Join(err, s.tunnel.Close())
+	}
 	return err
 }
 
@@ -80,37 +237,128 @@ func (s *Server) AddressPort() string {
 	return s.addrPort
 }
 
-func (s *Server) ClientIP(id int) (ip string, ok bool) {
+// Name returns the configured name of the server or its address in case that no name was configured.
+func (s *Server) Name() string {
+	if s.name == "" {
+		return s.addrPort
+	}
+	return s.name
+}
+
+// String returns the name of the server followed by its address, which is used in log messages.
+func (s *Server) String() string {
+	if s.name == "" || s.name == s.addrPort {
+		return s.addrPort
+	}
+	return fmt.Sprintf("%s (%s)", s.name, s.addrPort)
+}
+
+// Group returns the group the server belongs to, empty if it was not assigned to any group.
+func (s *Server) Group() string {
+	return s.group
+}
+
+// Flavor returns the configured or detected flavor of the server, FlavorAuto if it is still unknown.
+func (s *Server) Flavor() parser.Flavor {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.flavor
+}
+
+// parseFlavors returns the flavors whose patterns are used to parse the given line.
+// As long as the flavor is unknown, every line is used to narrow down the possible flavors.
+func (s *Server) parseFlavors(line string) []parser.Flavor {
+	if s.detector == nil {
+		return []parser.Flavor{s.flavor}
+	}
+
+	flavor, detected := s.detector.Detect(line)
+	if !detected {
+		return s.detector.Candidates()
+	}
+
+	s.mu.Lock()
+	s.flavor = flavor
+	s.mu.Unlock()
+
+	// a different server implementation requires a new connection, so the flavor cannot change anymore
+	s.detector = nil
+	log.Printf("detected server flavor %s of %s", flavor, s)
+	return []parser.Flavor{flavor}
+}
+
+func (s *Server) ClientIP(id int) (ip netip.Addr, ok bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	ip, ok = s.clients[id]
-	return ip, ok
+	client, ok := s.clients[id]
+	return client.IP, ok
+}
+
+// Client is a player that is connected to the server.
+type Client struct {
+	ID   int        `json:"id"`
+	IP   netip.Addr `json:"ip"`
+	Name string     `json:"name,omitempty"` // nickname of the last chat message, empty until the player chats
+}
+
+// Clients returns all connected clients ordered by their ids.
+func (s *Server) Clients() []Client {
+	s.mu.Lock()
+	clients := make([]Client, 0, len(s.clients))
+	for _, client := range s.clients {
+		clients = append(clients, client)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(clients, func(i, j int) bool {
+		return clients[i].ID < clients[j].ID
+	})
+	return clients
+}
+
+// command is a line that is sent to the server, the writer reports whether writing it failed.
+type command struct {
+	line    string
+	written chan error
 }
 
-func (s *Server) send(command string) error {
+// send writes the command to the server and waits until it was written.
+func (s *Server) send(line string) error {
+	c := command{line: line, written: make(chan error, 1)}
 
 	select {
 	case <-s.ctx.Done():
-		return fmt.Errorf("failed to send commands %q to %s: %v", command, s.addrPort, s.ctx.Err())
-	case s.commandChan <- command:
+		return fmt.Errorf("failed to send command %q to %s: %w", line, s, ErrClosed)
+	case s.commandChan <- c:
+	}
+
+	select {
+	case <-s.ctx.Done():
+		return fmt.Errorf("failed to send command %q to %s: %w", line, s, ErrClosed)
+	case err := <-c.written:
+		if err != nil {
+			return fmt.Errorf("failed to send command %q to %s: %w", line, s, err)
+		}
 		return nil
 	}
 }
 
-func (s *Server) IgnoreBanPrapagation(bannedIP string, sourceServers ...string) {
+// IgnoreBanPrapagation ignores the next ban line of the ip on each of the source servers.
+// Every call ignores one more line, e.g. of a retried ban.
+func (s *Server) IgnoreBanPrapagation(bannedIP netip.Addr, sourceServers ...string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	for _, server := range sourceServers {
 
 		if _, ok := s.ignoredBanPropagation[server]; !ok {
-			s.ignoredBanPropagation[server] = make(map[string]struct{})
+			s.ignoredBanPropagation[server] = make(map[netip.Addr]int)
 		}
-		s.ignoredBanPropagation[server][bannedIP] = struct{}{}
+		s.ignoredBanPropagation[server][bannedIP]++
 	}
 }
 
-func (s *Server) IsIgnoredBanPropagation(triggeringServer, bannedIP string) bool {
+func (s *Server) IsIgnoredBanPropagation(triggeringServer string, bannedIP netip.Addr) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -123,27 +371,32 @@ func (s *Server) IsIgnoredBanPropagation(triggeringServer, bannedIP string) bool
 		return false
 	}
 
-	_, ok = ignoredIPs[bannedIP]
-	if ok {
+	n := ignoredIPs[bannedIP]
+	switch {
+	case n == 0:
+		return false
+	case n == 1:
 		delete(ignoredIPs, bannedIP)
+	default:
+		ignoredIPs[bannedIP] = n - 1
 	}
-	return ok
+	return true
 }
 
-func (s *Server) IgnoreUnbanPrapagation(unbannedIP string, sourceServers ...string) {
+func (s *Server) IgnoreUnbanPrapagation(unbannedIP netip.Addr, sourceServers ...string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	for _, server := range sourceServers {
 		if _, ok := s.ignoredUnbanPropagation[server]; !ok {
-			s.ignoredUnbanPropagation[server] = make(map[string]struct{})
+			s.ignoredUnbanPropagation[server] = make(map[netip.Addr]int)
 		}
 
-		s.ignoredUnbanPropagation[server][unbannedIP] = struct{}{}
+		s.ignoredUnbanPropagation[server][unbannedIP]++
 	}
 }
 
-func (s *Server) IsIgnoredUnbanPropagation(triggeringServer, unbannedIP string) bool {
+func (s *Server) IsIgnoredUnbanPropagation(triggeringServer string, unbannedIP netip.Addr) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -156,34 +409,147 @@ func (s *Server) IsIgnoredUnbanPropagation(triggeringServer, unbannedIP string)
 		return false
 	}
 
-	_, ok = ignoredIPs[unbannedIP]
-	if ok {
+	n := ignoredIPs[unbannedIP]
+	switch {
+	case n == 0:
+		return false
+	case n == 1:
 		delete(ignoredIPs, unbannedIP)
+	default:
+		ignoredIPs[unbannedIP] = n - 1
+	}
+	return true
+}
+
+func (s *Server) BanIP(triggeringServer string, playerIP netip.Addr, duration time.Duration, reason string) error {
+	if !playerIP.IsValid() {
+		return fmt.Errorf("ban failed on server %s: invalid player ip", s)
+	}
+
+	return s.send(fmt.Sprintf("ban %s %d%s", parser.FormatIP(playerIP), int(duration.Minutes()), reasonArg(reason)))
+}
+
+// BanIPConfirmed bans the ip like BanIP and returns a confirmation that is resolved as soon as the
+// server logged the ban or the update of an existing ban. Bans that the server rejects are never confirmed.
+func (s *Server) BanIPConfirmed(triggeringServer string, playerIP netip.Addr, duration time.Duration, reason string) (*Confirmation, error) {
+	c := s.expect(func(event parser.Event) bool {
+		banned, ok := event.(parser.ClientBanned)
+		return ok && banned.IP.Unmap() == playerIP.Unmap()
+	})
+
+	err := s.BanIP(triggeringServer, playerIP, duration, reason)
+	if err != nil {
+		c.Cancel()
+		return nil, err
 	}
-	return ok
+	return c, nil
 }
 
-func (s *Server) BanIP(triggeringServer string, playerIP string, duration time.Duration, reason string) error {
-	if playerIP == "" {
-		return fmt.Errorf("ban failed on server %s: empty player ip", s.addrPort)
+func (s *Server) UnbanIP(triggeringServer string, playerIP netip.Addr) error {
+	if !playerIP.IsValid() {
+		return fmt.Errorf("unban failed on server %s: invalid player ip", s)
 	}
 
-	return s.send(fmt.Sprintf("ban %s %d %s", playerIP, int(duration.Minutes()), reason))
+	return s.send(fmt.Sprintf("unban %s", parser.FormatIP(playerIP)))
+}
+
+func (s *Server) Kick(clientID int, reason string) error {
+	return s.send(fmt.Sprintf("kick %d%s", clientID, reasonArg(reason)))
 }
 
-func (s *Server) UnbanIP(triggeringServer string, playerIP string) error {
-	if playerIP == "" {
-		return fmt.Errorf("unban failed on server %s: empty player ip", s.addrPort)
+// Say sends a chat message to all players of the server.
+func (s *Server) Say(message string) error {
+	return s.send("say " + quote(message))
+}
+
+// Broadcast shows a message in the center of the screen of all players of the server.
+func (s *Server) Broadcast(message string) error {
+	return s.send("broadcast " + quote(message))
+}
+
+// reasonArg returns the quoted reason argument of ban and kick commands, so that e.g. the "#" of
+// a server name in the reason does not start a comment. Empty reasons are omitted.
+func reasonArg(reason string) string {
+	if reason == "" {
+		return ""
 	}
+	return " " + quote(reason)
+}
 
-	return s.send(fmt.Sprintf("unban %s", playerIP))
+// quote returns a single quoted argument of a console command, so that e.g. semicolons
+// do not start a new command and line breaks do not end it.
+func quote(arg string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", " ", "\r", " ")
+	return `"` + r.Replace(arg) + `"`
+}
+
+// Exec sends an arbitrary console command to the server and returns all lines the server
+// printed until the timeout expired. The econ protocol does not relate output to commands,
+// so the lines may contain unrelated log lines, e.g. chat messages of players.
+func (s *Server) Exec(ctx context.Context, command string, timeout time.Duration) ([]string, error) {
+	if strings.TrimSpace(command) == "" {
+		return nil, errors.New("empty command")
+	}
+	if strings.ContainsAny(command, "\r\n") {
+		return nil, errors.New("command must not contain line breaks")
+	}
+
+	// large enough for e.g. the status of a full server
+	output := make(chan string, 256)
+	s.outputMu.Lock()
+	s.outputWriters[output] = struct{}{}
+	s.outputMu.Unlock()
+	defer func() {
+		s.outputMu.Lock()
+		delete(s.outputWriters, output)
+		s.outputMu.Unlock()
+	}()
+
+	err := s.send(command)
+	if err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var lines []string
+	for {
+		select {
+		case <-ctx.Done():
+			return lines, ctx.Err()
+		case <-s.ctx.Done():
+			return lines, fmt.Errorf("failed to execute command %q on %s: %w", command, s, ErrClosed)
+		case <-timer.C:
+			return lines, nil
+		case line := <-output:
+			lines = append(lines, line)
+		}
+	}
+}
+
+// output passes a line of the server to all running Exec calls.
+func (s *Server) output(line string) {
+	if line == "" {
+		return
+	}
+
+	s.outputMu.Lock()
+	defer s.outputMu.Unlock()
+	for ch := range s.outputWriters {
+		select {
+		case ch <- line:
+		default:
+			// the output of long running commands is truncated
+		}
+	}
 }
 
 func (s *Server) asyncReadLine() {
 	defer func() {
 		close(s.lineChan)
 		s.wg.Done()
-		log.Println("line reader closed")
+		log.Printf("line reader of %s closed", s)
 	}()
 
 	var (
@@ -193,16 +559,16 @@ func (s *Server) asyncReadLine() {
 	for {
 		select {
 		case <-s.ctx.Done():
-			log.Printf("closing line reader: %v", s.ctx.Err())
+			log.Printf("closing line reader of %s: %v", s, s.ctx.Err())
 			return
 		default:
 			line, err = s.conn.ReadLine()
 			if err != nil {
 				if errors.Is(err, context.Canceled) {
-					log.Printf("closing line reader: %v", err)
+					log.Printf("closing line reader of %s: %v", s, err)
 					return
 				}
-				log.Printf("failed to read line: %v", err)
+				log.Printf("failed to read line of %s: %v", s, secret.RedactError(err, s.password))
 			}
 			s.lineChan <- line
 		}
@@ -212,39 +578,44 @@ func (s *Server) asyncReadLine() {
 func (s *Server) asyncWriteLine() {
 	defer func() {
 		s.wg.Done()
-		log.Printf("command writer of %s closed", s.addrPort)
+		log.Printf("command writer of %s closed", s)
 	}()
 
 	var err error
 	for {
 		select {
 		case <-s.ctx.Done():
-			log.Printf("closing command writer of %s: %v", s.addrPort, s.ctx.Err())
+			log.Printf("closing command writer of %s: %v", s, s.ctx.Err())
 			return
-		case command, ok := <-s.commandChan:
+		case c, ok := <-s.commandChan:
 			if !ok {
-				log.Printf("command channel of %s closed", s.addrPort)
+				log.Printf("command channel of %s closed", s)
 				return
 			}
-			err = s.conn.WriteLine(command)
+			err = s.conn.WriteLine(c.line)
+			if err != nil {
+				err = secret.RedactError(err, s.password)
+			}
+			c.written <- err
 			if err != nil {
 				if errors.Is(err, context.Canceled) {
-					log.Printf("closing command writer of %s: %v", s.addrPort, s.ctx.Err())
+					log.Printf("closing command writer of %s: %v", s, s.ctx.Err())
 					return
 				}
-				log.Printf("failed to write line to %s: %v", s.addrPort, err)
+				log.Printf("failed to write line to %s: %v", s, err)
 			}
 		}
 	}
 }
 
-type LineHandler func(server *Server, line string)
+// EventHandler is called for every parsed line of a server.
+type EventHandler func(server *Server, event parser.Event)
 
-func (s *Server) asyncProcess(process func(s *Server, line string)) {
+func (s *Server) asyncProcess(process EventHandler) {
 	defer func() {
-		log.Printf("closing line processor of %s", s.addrPort)
+		log.Printf("closing line processor of %s", s)
 		s.wg.Done()
-		log.Printf("line processor of %s closed", s.addrPort)
+		log.Printf("line processor of %s closed", s)
 	}()
 
 	for {
@@ -252,25 +623,56 @@ func (s *Server) asyncProcess(process func(s *Server, line string)) {
 		if !ok {
 			break
 		}
+		s.output(line)
+
+		// every line is parsed exactly once
+		event, ok := parser.Parse(line, s.parseFlavors(line)...)
+		if !ok {
+			continue
+		}
 
-		if entered, ok := parser.ParseClientEntered(line); ok {
+		switch e := event.(type) {
+		case parser.ClientEntered:
 			s.mu.Lock()
-			s.clients[entered.ClientID] = entered.IP
+			s.clients[e.ClientID] = Client{ID: e.ClientID, IP: e.IP}
 			s.mu.Unlock()
-
-			// allow the handler to process the line as well
-		} else if dropped, ok := parser.ParseClientDropped(line); ok {
+		case parser.ClientDropped:
 			s.mu.Lock()
-			delete(s.clients, dropped.ClientID)
+			delete(s.clients, e.ClientID)
+			s.mu.Unlock()
+		case parser.ChatMessage:
+			s.mu.Lock()
+			if client, ok := s.clients[e.ClientID]; ok {
+				client.Name = e.Nickname
+				s.clients[e.ClientID] = client
+			}
 			s.mu.Unlock()
-			// allow the handler to process the line as well
 		}
 
-		process(s, line)
+		s.confirm(event)
+
+		// allow the handler to process the event as well
+		s.safeProcess(process, line, event)
+
+		switch event.(type) {
+		case parser.BanListEntry, parser.BanListSummary:
+			s.collectBans(process, line, event)
+		}
 	}
 
 }
 
+// safeProcess makes sure that no log line can crash the banserver, even if a handler has a bug.
+func (s *Server) safeProcess(process EventHandler, line string, event parser.Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("recovered from panic while processing line of %s: %v: %q", s, r, line)
+		}
+	}()
+
+	process(s, event)
+}
+
 func tryRead(ctx context.Context, lineChan <-chan string) (line string, ok bool) {
 	select {
 	case <-ctx.Done():
@@ -0,0 +1,86 @@
+package econ_test
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/jxsl13/banserver/econ"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandArguments(t *testing.T) {
+	s, conn, _ := newTestServer(t)
+
+	ip := netip.MustParseAddr("1.2.3.4")
+	require.NoError(t, s.BanIP("", ip, time.Hour, "banned on DDNet Novice #1: spam; shutdown"))
+	assert.Equal(t, `ban 1.2.3.4 60 "banned on DDNet Novice #1: spam; shutdown"`, conn.Command(t))
+
+	require.NoError(t, s.BanIP("", netip.MustParseAddr("2001:db8::1"), 0, ""))
+	assert.Equal(t, `ban [2001:db8::1] 0`, conn.Command(t))
+
+	require.NoError(t, s.Kick(3, `say "hi" \ bye`))
+	assert.Equal(t, `kick 3 "say \"hi\" \\ bye"`, conn.Command(t))
+
+	require.NoError(t, s.Say("multi\nline"))
+	assert.Equal(t, `say "multi line"`, conn.Command(t))
+
+	require.Error(t, s.BanIP("", netip.Addr{}, time.Hour, "invalid"))
+}
+
+func TestExec(t *testing.T) {
+	s, conn, _ := newTestServer(t)
+
+	type result struct {
+		lines []string
+		err   error
+	}
+	exec := func(ctx context.Context, command string) <-chan result {
+		done := make(chan result, 1)
+		go func() {
+			lines, err := s.Exec(ctx, command, 200*time.Millisecond)
+			done <- result{lines, err}
+		}()
+		return done
+	}
+
+	// the output is collected until the timeout expired
+	done := exec(context.Background(), "sv_motd")
+	assert.Equal(t, "sv_motd", conn.Command(t))
+	conn.Log(
+		ddnetLine("console", "Value: welcome"),
+		"",
+		ddnetLine("chat", "0:-2:nameless tee: hi"),
+	)
+	r := <-done
+	require.NoError(t, r.err)
+	assert.Equal(t, []string{
+		ddnetLine("console", "Value: welcome"),
+		ddnetLine("chat", "0:-2:nameless tee: hi"),
+	}, r.lines)
+
+	// servers may not answer at all
+	done = exec(context.Background(), "status")
+	assert.Equal(t, "status", conn.Command(t))
+	r = <-done
+	require.NoError(t, r.err)
+	assert.Empty(t, r.lines)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done = exec(ctx, "status")
+	assert.Equal(t, "status", conn.Command(t))
+	cancel()
+	r = <-done
+	assert.ErrorIs(t, r.err, context.Canceled)
+
+	_, err := s.Exec(context.Background(), " ", time.Second)
+	assert.Error(t, err)
+	_, err = s.Exec(context.Background(), "status\nshutdown", time.Second)
+	assert.Error(t, err)
+
+	require.NoError(t, s.Close())
+	_, err = s.Exec(context.Background(), "status", time.Second)
+	assert.ErrorIs(t, err, econ.ErrClosed)
+}
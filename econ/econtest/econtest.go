@@ -0,0 +1,101 @@
+// Package econtest provides an in-memory econ connection of a fake game server,
+// which allows to test the processing of log lines and the sent commands without network.
+package econtest
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Conn is the econ connection of a fake game server, see econ.NewServer.
+type Conn struct {
+	lines    chan string
+	commands chan string
+	done     chan struct{}
+	once     sync.Once
+
+	mu       sync.Mutex
+	writeErr error
+}
+
+func NewConn() *Conn {
+	return &Conn{
+		lines:    make(chan string),
+		commands: make(chan string, 64),
+		done:     make(chan struct{}),
+	}
+}
+
+// ReadLine returns the next line that was logged by the fake game server.
+func (c *Conn) ReadLine() (string, error) {
+	select {
+	case <-c.done:
+		// like econ connections whose context was canceled
+		return "", context.Canceled
+	case line := <-c.lines:
+		return line, nil
+	}
+}
+
+// WriteLine sends a command to the fake game server.
+func (c *Conn) WriteLine(line string) error {
+	c.mu.Lock()
+	err := c.writeErr
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-c.done:
+		return net.ErrClosed
+	case c.commands <- line:
+		return nil
+	}
+}
+
+func (c *Conn) Close() error {
+	c.once.Do(func() {
+		close(c.done)
+	})
+	return nil
+}
+
+// Log logs lines of the fake game server and blocks until they were read.
+func (c *Conn) Log(lines ...string) {
+	for _, line := range lines {
+		select {
+		case <-c.done:
+			return
+		case c.lines <- line:
+		}
+	}
+}
+
+// Commands returns the commands that were sent to the fake game server.
+func (c *Conn) Commands() <-chan string {
+	return c.commands
+}
+
+// Command returns the next command that was sent to the fake game server
+// and fails the test in case that no command is sent within a second.
+func (c *Conn) Command(t testing.TB) string {
+	t.Helper()
+	select {
+	case command := <-c.commands:
+		return command
+	case <-time.After(time.Second):
+		t.Fatal("no command was sent to the fake game server")
+		return ""
+	}
+}
+
+// FailWrites lets all following commands fail with err, nil lets them succeed again.
+func (c *Conn) FailWrites(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writeErr = err
+}
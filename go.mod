@@ -4,6 +4,7 @@ go 1.23.5
 
 require (
 	github.com/go-playground/validator/v10 v10.25.0
+	github.com/oschwald/maxminddb-golang v1.13.1
 	github.com/stretchr/testify v1.10.0
 	github.com/teeworlds-go/econ v0.1.0
 	github.com/yl2chen/cidranger v1.0.2
@@ -39,9 +40,9 @@ require (
 	github.com/reiver/go-oi v1.0.0 // indirect
 	github.com/reiver/go-telnet v0.0.0-20180421082511-9ff0b2ab096e // indirect
 	github.com/spf13/cobra v1.9.1
-	golang.org/x/crypto v0.36.0 // indirect
+	golang.org/x/crypto v0.36.0
 	golang.org/x/net v0.37.0 // indirect
 	golang.org/x/sys v0.31.0 // indirect
 	golang.org/x/text v0.23.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -9,7 +9,9 @@ import (
 	"path/filepath"
 	"syscall"
 
+	"github.com/jxsl13/banserver/admin"
 	"github.com/jxsl13/banserver/config"
+	"github.com/jxsl13/banserver/econ"
 	"github.com/jxsl13/banserver/model"
 	"github.com/jxsl13/cli-config-boilerplate/cliconfig"
 	"github.com/spf13/cobra"
@@ -39,6 +41,11 @@ func NewRootCmd(ctx context.Context) *cobra.Command {
 	cmd.PreRunE = root.PreRunE(&cmd)
 	cmd.RunE = root.RunE
 	cmd.AddCommand(NewCompletionCommand(&cmd))
+	cmd.AddCommand(NewBansCommand())
+	cmd.AddCommand(NewCheckCommand(ctx))
+	cmd.AddCommand(NewTestIPCommand(ctx))
+	cmd.AddCommand(NewTestChatCommand(ctx))
+	cmd.AddCommand(NewConsoleCommand(ctx))
 
 	return &cmd
 }
@@ -52,6 +59,12 @@ func (cli *RootContext) PreRunE(cmd *cobra.Command) func(*cobra.Command, []strin
 	cfgParser := cliconfig.RegisterFlags(cli.cfg, false, cmd)
 	return func(cmd *cobra.Command, args []string) error {
 		log.SetOutput(cmd.ErrOrStderr()) // redirect log output to stderr
+
+		// secrets may be mounted as files
+		err := config.LoadEnvFiles()
+		if err != nil {
+			return err
+		}
 		return cfgParser()
 	}
 }
@@ -64,35 +77,93 @@ func (cli *RootContext) PostRunE(*cobra.Command, []string) error {
 func (cli *RootContext) RunE(*cobra.Command, []string) (err error) {
 	log.Println("starting banserver...")
 
-	broker := model.NewBroker(
-		cli.cfg.Propagate,
-		cli.cfg.PermaBanDuration,
-		cli.cfg.PermaBanReason,
-		cli.cfg.ChatBanDuration,
-		cli.cfg.ChatBanReason,
-	)
+	opts := []model.Option{}
+	if cli.cfg.RconBanAttempts > 0 {
+		opts = append(opts, model.WithRconBruteforceProtection(
+			cli.cfg.RconBanAttempts,
+			cli.cfg.RconBanWindow,
+			cli.cfg.RconBanDuration,
+			cli.cfg.RconBanReason,
+		))
+	}
+	if cli.cfg.RconAlert {
+		opts = append(opts, model.WithRconLoginAlert(cli.cfg.RconTrustedIPs...))
+	}
+	if cli.cfg.VoteMax > 0 || cli.cfg.VoteKickMax > 0 {
+		opts = append(opts, model.WithVoteAbuseProtection(
+			cli.cfg.VoteMax,
+			cli.cfg.VoteKickMax,
+			cli.cfg.VoteWindow,
+			cli.cfg.VoteAction,
+			cli.cfg.VoteBanDuration,
+			cli.cfg.VoteBanReason,
+		))
+	}
+	if cli.cfg.WhisperMaxTargets > 0 {
+		opts = append(opts, model.WithWhisperSpamProtection(
+			cli.cfg.WhisperMaxTargets,
+			cli.cfg.WhisperWindow,
+			cli.cfg.WhisperAction,
+			cli.cfg.WhisperBanDuration,
+			cli.cfg.WhisperBanReason,
+		))
+	}
+
+	if cli.cfg.GeoIPCountryDB != "" || cli.cfg.GeoIPASNDB != "" {
+		geo, err := model.OpenGeoIP(cli.cfg.GeoIPCountryDB, cli.cfg.GeoIPASNDB)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			err = errors.Join(err, geo.Close())
+		}()
+
+		opts = append(opts, model.WithGeoIP(
+			geo,
+			cli.cfg.GeoIPDenyASNs,
+			cli.cfg.GeoIPAllowCountries,
+			cli.cfg.GeoIPAction,
+			cli.cfg.GeoIPBanDuration,
+			cli.cfg.GeoIPBanReason,
+		))
+	}
+	if cli.cfg.BanOrigin {
+		opts = append(opts, model.WithBanOrigin())
+	}
+	if cli.cfg.BanConfirmTimeout > 0 {
+		opts = append(opts, model.WithBanConfirmation(cli.cfg.BanConfirmTimeout, cli.cfg.BanRetries))
+	}
+	for addrPort, rules := range cli.cfg.ServerRules {
+		opts = append(opts, model.WithServerRules(addrPort, rules))
+	}
+	if cli.cfg.ReconcileInterval > 0 {
+		opts = append(opts, model.WithReconcileMode(cli.cfg.ReconcileMode))
+	}
+	broker := newBroker(cli.cfg, opts...)
 	defer func() {
 		err = errors.Join(err, broker.Close())
 	}()
 
-	if len(cli.cfg.IPBlacklists) > 0 {
-		log.Println("loading ip blacklists...")
-		for _, filePath := range cli.cfg.IPBlacklists {
-			err = broker.AddBlacklistCIDRFile(filePath)
-			if err != nil {
-				return err
-			}
-		}
+	err = loadBlacklists(cli.ctx, broker, cli.cfg)
+	if err != nil {
+		return err
 	}
 
-	if len(cli.cfg.ChatBlacklists) > 0 {
-		log.Println("loading chat blacklists...")
-		for _, filePath := range cli.cfg.ChatBlacklists {
-			err = broker.AddBlacklistChatFile(filePath)
+	go broker.RefreshBlacklists(cli.ctx, cli.cfg.BlacklistsRefresh)
+
+	if cli.cfg.AdminSocket != "" {
+		listener, err := admin.Listen(cli.cfg.AdminSocket)
+		if err != nil {
+			return err
+		}
+		log.Printf("admin interface listening on %s", cli.cfg.AdminSocket)
+
+		go func() {
+			err := newAdminServer(broker, cli.cfg).Serve(cli.ctx, listener)
 			if err != nil {
-				return err
+				log.Printf("admin interface stopped: %v", err)
 			}
-		}
+		}()
 	}
 
 	log.Println("connecting to econ servers...")
@@ -101,15 +172,71 @@ func (cli *RootContext) RunE(*cobra.Command, []string) (err error) {
 			cli.ctx,
 			addrPort,
 			cli.cfg.EconPasswords[idx],
+			econOptions(cli.cfg, idx)...,
 		)
 		if err != nil {
 			return err
 		}
 	}
 
+	go broker.Reconcile(cli.ctx, cli.cfg.ReconcileInterval)
+
 	// block until context is done
 	log.Println("banserver started successfully")
 	<-cli.ctx.Done()
 	log.Println("shutting down banserver...")
 	return nil
 }
+
+// econOptions returns the options of the econ server with the given index.
+func econOptions(cfg *config.Config, idx int) []econ.Option {
+	opts := []econ.Option{
+		econ.WithName(cfg.EconNames[idx]),
+		econ.WithFlavor(cfg.EconFlavors[idx]),
+		econ.WithGroup(cfg.EconGroups[idx]),
+	}
+	if len(cfg.EconSSH) > 0 && cfg.EconSSH[idx] != nil {
+		opts = append(opts, econ.WithSSHTunnel(*cfg.EconSSH[idx]))
+	}
+	return opts
+}
+
+// newBroker creates a broker with the configured ban defaults and blacklist settings.
+func newBroker(cfg *config.Config, opts ...model.Option) *model.Broker {
+	opts = append(opts, model.WithBlacklistMaxSize(cfg.BlacklistsMaxSize))
+	if cfg.BlacklistsCacheDir != "" {
+		opts = append(opts, model.WithBlacklistCache(cfg.BlacklistsCacheDir))
+	}
+
+	return model.NewBroker(
+		cfg.Propagate,
+		cfg.PermaBanDuration,
+		cfg.PermaBanReason,
+		cfg.ChatBanDuration,
+		cfg.ChatBanReason,
+		opts...,
+	)
+}
+
+func loadBlacklists(ctx context.Context, broker *model.Broker, cfg *config.Config) error {
+	if len(cfg.IPBlacklists) > 0 {
+		log.Println("loading ip blacklists...")
+		for _, filePath := range cfg.IPBlacklists {
+			err := broker.AddBlacklistCIDRFile(ctx, filePath)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(cfg.ChatBlacklists) > 0 {
+		log.Println("loading chat blacklists...")
+		for _, filePath := range cfg.ChatBlacklists {
+			err := broker.AddBlacklistChatFile(ctx, filePath)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
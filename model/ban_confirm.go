@@ -0,0 +1,90 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/netip"
+	"time"
+
+	"github.com/jxsl13/banserver/econ"
+)
+
+// BanFailed is published to the event subscribers in case that a game server did not confirm a ban.
+type BanFailed struct {
+	IP       netip.Addr    `json:"ip"`
+	Duration time.Duration `json:"duration"`
+	Reason   string        `json:"reason"`
+	Attempts int           `json:"attempts"`
+	Error    string        `json:"error"`
+}
+
+// ban bans the ip on the given server. The resulting ban line of the server is not propagated to the ignoring
+// servers. Write errors are returned immediately, whereas the confirmation of the server is awaited in the
+// background, so that the line processors of the servers are never blocked.
+func (p *Broker) ban(s *econ.Server, triggeringServer string, ip netip.Addr, duration time.Duration, reason string, ignoring ...*econ.Server) error {
+	ignoreBan(s, ip, ignoring)
+	if p.banConfirmTimeout <= 0 {
+		return s.BanIP(triggeringServer, ip, duration, reason)
+	}
+
+	c, err := s.BanIPConfirmed(triggeringServer, ip, duration, reason)
+	if err != nil {
+		return err
+	}
+
+	go p.confirmBan(s, c, triggeringServer, ip, duration, reason, ignoring)
+	return nil
+}
+
+// ignoreBan ignores the next ban line of the ip on the server on all ignoring servers.
+func ignoreBan(s *econ.Server, ip netip.Addr, ignoring []*econ.Server) {
+	for _, other := range ignoring {
+		other.IgnoreBanPrapagation(ip, s.AddressPort())
+	}
+}
+
+// BanOn bans the ip on a single game server.
+func (p *Broker) BanOn(s *econ.Server, ip netip.Addr, duration time.Duration, reason string) error {
+	return p.ban(s, s.AddressPort(), ip, duration, reason)
+}
+
+// confirmBan retries the ban until the server confirmed it or all retries failed.
+func (p *Broker) confirmBan(s *econ.Server, c *econ.Confirmation, triggeringServer string, ip netip.Addr, duration time.Duration, reason string, ignoring []*econ.Server) {
+	attempts := 1
+	for {
+		err := c.Wait(context.Background(), p.banConfirmTimeout)
+		if err == nil {
+			return
+		}
+		if errors.Is(err, econ.ErrClosed) {
+			// the server is shutting down or reconnecting
+			log.Printf("ban of ip %s on server %s was not confirmed: %v", ip, s, err)
+			return
+		}
+
+		if attempts <= p.banRetries {
+			log.Printf("retrying ban of ip %s on server %s: %v", ip, s, err)
+			attempts++
+			// the unconfirmed ban may still be logged, so both ban lines must be ignored
+			ignoreBan(s, ip, ignoring)
+			c, err = s.BanIPConfirmed(triggeringServer, ip, duration, reason)
+			if err == nil {
+				continue
+			}
+			if errors.Is(err, econ.ErrClosed) {
+				return
+			}
+		}
+
+		log.Printf("failed to ban ip %s on server %s after %d attempts: %v", ip, s, attempts, err)
+		p.publish(s, BanFailed{
+			IP:       ip,
+			Duration: duration,
+			Reason:   reason,
+			Attempts: attempts,
+			Error:    err.Error(),
+		})
+		return
+	}
+}
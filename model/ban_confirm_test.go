@@ -0,0 +1,128 @@
+package model
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testConfirmTimeout = 50 * time.Millisecond
+
+// banFailed returns the first BanFailed event that is published within the given time.
+func banFailed(events <-chan Event, wait time.Duration) (BanFailed, bool) {
+	timeout := time.After(wait)
+	for {
+		select {
+		case e := <-events:
+			if failed, ok := e.Data.(BanFailed); ok {
+				return failed, true
+			}
+		case <-timeout:
+			return BanFailed{}, false
+		}
+	}
+}
+
+func TestBanConfirmation(t *testing.T) {
+	ip := netip.MustParseAddr("1.2.3.4")
+	setup := func(t *testing.T) (*Broker, <-chan Event) {
+		p := NewBroker(false, time.Hour, "permaban", time.Hour, "chat ban", WithBanConfirmation(testConfirmTimeout, 1))
+		events, unsubscribe := p.SubscribeEvents(64)
+		t.Cleanup(unsubscribe)
+		return p, events
+	}
+
+	t.Run("confirmed", func(t *testing.T) {
+		p, events := setup(t)
+		s, conn := addFakeServer(t, p, "127.0.0.1:8303")
+
+		require.NoError(t, p.BanOn(s, ip, time.Hour, "spam"))
+		assert.Equal(t, `ban 1.2.3.4 60 "spam"`, conn.Command(t))
+		conn.Log(ddnetLine("net_ban", "banned '1.2.3.4' for 60 minutes (spam)"))
+
+		requireNoCommand(t, conn)
+		_, failed := banFailed(events, testConfirmTimeout)
+		assert.False(t, failed)
+	})
+
+	t.Run("updated ban confirmed", func(t *testing.T) {
+		p, events := setup(t)
+		s, conn := addFakeServer(t, p, "127.0.0.1:8303")
+
+		require.NoError(t, p.BanOn(s, ip, time.Hour, "spam"))
+		assert.Equal(t, `ban 1.2.3.4 60 "spam"`, conn.Command(t))
+		// the ip was already banned
+		conn.Log(ddnetLine("net_ban", "'1.2.3.4' banned for 60 minutes (spam)"))
+
+		requireNoCommand(t, conn)
+		_, failed := banFailed(events, testConfirmTimeout)
+		assert.False(t, failed)
+	})
+
+	t.Run("retried", func(t *testing.T) {
+		p, events := setup(t)
+		s, conn := addFakeServer(t, p, "127.0.0.1:8303")
+
+		require.NoError(t, p.BanOn(s, ip, time.Hour, "spam"))
+		assert.Equal(t, `ban 1.2.3.4 60 "spam"`, conn.Command(t))
+		assert.Equal(t, `ban 1.2.3.4 60 "spam"`, conn.Command(t))
+		conn.Log(ddnetLine("net_ban", "banned '1.2.3.4' for 60 minutes (spam)"))
+
+		requireNoCommand(t, conn)
+		_, failed := banFailed(events, testConfirmTimeout)
+		assert.False(t, failed)
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		p, events := setup(t)
+		s, conn := addFakeServer(t, p, "127.0.0.1:8303")
+
+		require.NoError(t, p.BanOn(s, ip, time.Hour, "spam"))
+		assert.Equal(t, `ban 1.2.3.4 60 "spam"`, conn.Command(t))
+		require.NoError(t, s.Close())
+
+		_, failed := banFailed(events, 3*testConfirmTimeout)
+		assert.False(t, failed)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		p, events := setup(t)
+		s, conn := addFakeServer(t, p, "127.0.0.1:8303")
+
+		require.NoError(t, p.BanOn(s, ip, time.Hour, "spam"))
+		assert.Equal(t, `ban 1.2.3.4 60 "spam"`, conn.Command(t))
+		assert.Equal(t, `ban 1.2.3.4 60 "spam"`, conn.Command(t))
+
+		failed, ok := banFailed(events, time.Second)
+		require.True(t, ok)
+		assert.Equal(t, ip, failed.IP)
+		assert.Equal(t, time.Hour, failed.Duration)
+		assert.Equal(t, "spam", failed.Reason)
+		assert.Equal(t, 2, failed.Attempts)
+		assert.Contains(t, failed.Error, "not confirmed")
+		requireNoCommand(t, conn)
+	})
+}
+
+func TestBanConfirmationRetryNotPropagated(t *testing.T) {
+	p := NewBroker(true, time.Hour, "permaban", time.Hour, "chat ban", WithBanConfirmation(testConfirmTimeout, 1))
+	a, connA := addFakeServer(t, p, "127.0.0.1:8303")
+	_, connB := addFakeServer(t, p, "127.0.0.1:8304")
+
+	connA.Log(ddnetLine("net_ban", "banned '1.2.3.4' for 60 minutes (spam)"))
+	assert.Equal(t, `ban 1.2.3.4 60 "spam"`, connB.Command(t))
+	// retried after the timeout, the first ban is logged late
+	assert.Equal(t, `ban 1.2.3.4 60 "spam"`, connB.Command(t))
+	connB.Log(
+		ddnetLine("net_ban", "banned '1.2.3.4' for 60 minutes (spam)"),
+		ddnetLine("net_ban", "'1.2.3.4' banned for 60 minutes (spam)"),
+	)
+
+	// neither ban line is propagated back
+	requireNoCommand(t, connA)
+	requireNoCommand(t, connB)
+	assert.False(t, a.IsIgnoredBanPropagation("127.0.0.1:8304", netip.MustParseAddr("1.2.3.4")))
+}
@@ -1,79 +1,165 @@
 package model
 
 import (
-	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"log"
-	"net"
+	"net/netip"
 	"os"
-	"strings"
+	"slices"
+	"sort"
 	"sync"
-
-	"github.com/yl2chen/cidranger"
 )
 
+// manualSource contains all CIDRs that were added with AddBannedCIDR
+const manualSource = ""
+
 type BanServer struct {
 	mu sync.RWMutex
-	r  cidranger.Ranger
+	// source (e.g. blacklist file) -> banned prefixes with their lines
+	sources map[string][]SourcedPrefix
+
+	// set is rebuilt lazily from all sources on the first lookup after a modification,
+	// so loading millions of prefixes does not rebuild the set millions of times.
+	set   *prefixSet
+	dirty bool
 }
 
 func NewBanServer() *BanServer {
 	return &BanServer{
-		r: cidranger.NewPCTrieRanger(),
+		sources: make(map[string][]SourcedPrefix),
+		set:     newPrefixSet(),
 	}
 }
 
 func (b *BanServer) HasIPs() bool {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
-	return b.r.Len() > 0
+	for _, prefixes := range b.sources {
+		if len(prefixes) > 0 {
+			return true
+		}
+	}
+	return false
 }
 
-// AddBannedCIDR adds a new CIDR to the ban server, e.g. 192.168.1.0/24
+// AddBannedCIDR adds a new CIDR to the ban server, e.g. 192.168.1.0/24, 1.2.3.4-1.2.3.200 or 1.2.3.*
 func (b *BanServer) AddBannedCIDR(cidr string) error {
-	_, network, err := net.ParseCIDR(cidr)
+	prefixes, err := parseCIDRs(cidr)
 	if err != nil {
-		return err
+		return fmt.Errorf("invalid cidr: %s: %w", cidr, err)
 	}
 
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	b.r.Insert(cidranger.NewBasicRangerEntry(*network))
+	for _, prefix := range prefixes {
+		b.sources[manualSource] = append(b.sources[manualSource], SourcedPrefix{Prefix: prefix.Masked()})
+	}
+	b.dirty = true
 	return nil
 }
 
-// RemoveBannedCIDR removes a CIDR from the ban server
+// RemoveBannedCIDR removes a CIDR from all sources of the ban server
 func (b *BanServer) RemoveBannedCIDR(cidr string) error {
-	_, network, err := net.ParseCIDR(cidr)
+	removed, err := parseCIDRs(cidr)
 	if err != nil {
-		return err
+		return fmt.Errorf("invalid cidr: %s: %w", cidr, err)
+	}
+
+	for idx := range removed {
+		removed[idx] = removed[idx].Masked()
 	}
 
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	b.r.Remove(*network)
+	for source, prefixes := range b.sources {
+		b.sources[source] = slices.DeleteFunc(prefixes, func(p SourcedPrefix) bool {
+			return slices.Contains(removed, p.Prefix)
+		})
+	}
+	b.dirty = true
 	return nil
 }
 
+// setSource replaces all prefixes of the given source, e.g. after a blacklist file was reloaded.
+func (b *BanServer) setSource(source string, prefixes []SourcedPrefix) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(prefixes) == 0 {
+		delete(b.sources, source)
+	} else {
+		b.sources[source] = prefixes
+	}
+	b.dirty = true
+}
+
 // IsBanned checks if an IP is banned
-func (b *BanServer) IsBanned(ip string) (banned bool, err error) {
-	defer func() {
-		if err != nil {
-			err = fmt.Errorf("failed to check if ip is banned: %w", err)
-		}
-	}()
+func (b *BanServer) IsBanned(ip netip.Addr) (banned bool, err error) {
+	if !ip.IsValid() {
+		return false, fmt.Errorf("failed to check if ip is banned: invalid ip address: %s", ip)
+	}
+
+	return b.prefixSet().Contains(ip.Unmap()), nil
+}
 
-	// teeworlds ipv6 addresses are enclosed in square brackets
-	netIP := net.ParseIP(strings.Trim(ip, "[]"))
-	if netIP == nil {
-		return false, fmt.Errorf("invalid ip address: %s", ip)
+// Lookup returns every banned prefix that contains the ip together with the blacklist and line it was loaded from.
+func (b *BanServer) Lookup(ip netip.Addr) ([]SourcedPrefix, error) {
+	if !ip.IsValid() {
+		return nil, fmt.Errorf("failed to look up ip: invalid ip address: %s", ip)
 	}
+	ip = ip.Unmap()
 
 	b.mu.RLock()
 	defer b.mu.RUnlock()
-	return b.r.Contains(netIP)
+
+	var matches []SourcedPrefix
+	for _, prefixes := range b.sources {
+		for _, p := range prefixes {
+			if p.Prefix.Contains(ip) {
+				matches = append(matches, p)
+			}
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Source != matches[j].Source {
+			return matches[i].Source < matches[j].Source
+		}
+		return matches[i].Line < matches[j].Line
+	})
+	return matches, nil
+}
+
+// prefixSet returns the current set, rebuilding it in case that any source was modified.
+func (b *BanServer) prefixSet() *prefixSet {
+	b.mu.RLock()
+	set, dirty := b.set, b.dirty
+	b.mu.RUnlock()
+	if !dirty {
+		return set
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// another goroutine might have rebuilt the set in the meantime
+	if b.dirty {
+		prefixes := make([][]netip.Prefix, 0, len(b.sources))
+		for _, ps := range b.sources {
+			set := make([]netip.Prefix, 0, len(ps))
+			for _, p := range ps {
+				set = append(set, p.Prefix)
+			}
+			prefixes = append(prefixes, set)
+		}
+		b.set = newPrefixSet(prefixes...)
+		b.dirty = false
+	}
+	return b.set
 }
 
 func (b *BanServer) AddBlacklistCIDRFile(filePath string) error {
@@ -83,31 +169,46 @@ func (b *BanServer) AddBlacklistCIDRFile(filePath string) error {
 	}
 	defer f.Close()
 
-	cidrs := []*net.IPNet{}
-
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		line := scanner.Text()
+	return b.AddBlacklist(filePath, f)
+}
 
-		cidr, ok := parseCIDR(line)
-		if !ok {
-			continue
+// AddBlacklist replaces all entries of the given source with the entries of the blacklist.
+// Invalid lines are logged and skipped. A blacklist that consists of invalid lines only
+// is rejected and the previous entries of the source are kept.
+func (b *BanServer) AddBlacklist(source string, r io.Reader) error {
+	entries, err := ParseBlacklist(r)
+	if err != nil {
+		// invalid lines are reported, but do not prevent the valid ones from being banned
+		var (
+			lineErr *LineError
+			invalid int
+		)
+		for _, err := range unjoin(err) {
+			if !errors.As(err, &lineErr) {
+				return fmt.Errorf("failed to read blacklist %s: %w", source, err)
+			}
+			log.Printf("skipping invalid entry of blacklist %s: %v", source, err)
+			invalid++
 		}
 
-		cidrs = append(cidrs, cidr)
-	}
-
-	if len(cidrs) == 0 {
-		return nil
+		if len(entries) == 0 {
+			return fmt.Errorf("blacklist %s does not contain any valid entries, but %d invalid ones", source, invalid)
+		}
 	}
 
-	b.mu.Lock()
-	defer b.mu.Unlock()
+	cidrs := SourcedPrefixes(source, entries)
 
-	for _, cidr := range cidrs {
-		b.r.Insert(cidranger.NewBasicRangerEntry(*cidr))
-	}
+	// an empty blacklist removes all previously loaded entries of the source
+	b.setSource(source, cidrs)
 
-	log.Printf("added %d CIDRs from file %s", len(cidrs), filePath)
+	log.Printf("added %d CIDRs from %d entries of blacklist %s", len(cidrs), len(entries), source)
 	return nil
 }
+
+// unjoin returns the errors that were joined with errors.Join.
+func unjoin(err error) []error {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap()
+	}
+	return []error{err}
+}
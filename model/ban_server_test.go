@@ -0,0 +1,329 @@
+package model_test
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/jxsl13/banserver/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yl2chen/cidranger"
+)
+
+func TestBanServer(t *testing.T) {
+	b := model.NewBanServer()
+	assert.False(t, b.HasIPs())
+
+	for _, cidr := range []string{
+		"10.0.0.0/8",
+		"192.168.1.0/24",
+		"192.168.2.0/24", // adjacent to the previous range
+		"1.2.3.4",
+		"2001:db8::/32",
+		"::ffff:100.64.0.0/106", // ipv4-mapped 100.64.0.0/10
+		"[2001:db9::1]",
+		"0.0.0.0/32",
+		"255.255.255.255",
+	} {
+		require.NoError(t, b.AddBannedCIDR(cidr), cidr)
+	}
+	assert.True(t, b.HasIPs())
+	require.Error(t, b.AddBannedCIDR("not an ip"))
+
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"10.0.0.0", true},
+		{"10.255.255.255", true},
+		{"11.0.0.0", false},
+		{"9.255.255.255", false},
+		{"192.168.0.255", false},
+		{"192.168.1.0", true},
+		{"192.168.2.255", true},
+		{"192.168.3.0", false},
+		{"1.2.3.4", true},
+		{"1.2.3.5", false},
+		{"::ffff:1.2.3.4", true},
+		{"100.127.255.255", true},
+		{"100.128.0.0", false},
+		{"2001:db8::", true},
+		{"2001:db8:ffff:ffff:ffff:ffff:ffff:ffff", true},
+		{"2001:db9::", false},
+		{"2001:db9::1", true},
+		{"2001:db9::2", false},
+		{"0.0.0.0", true},
+		{"0.0.0.1", false},
+		{"255.255.255.255", true},
+		{"::", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			banned, err := b.IsBanned(netip.MustParseAddr(tt.ip))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, banned)
+		})
+	}
+
+	require.NoError(t, b.RemoveBannedCIDR("192.168.1.0/24"))
+	banned, err := b.IsBanned(netip.MustParseAddr("192.168.1.1"))
+	require.NoError(t, err)
+	assert.False(t, banned)
+
+	banned, err = b.IsBanned(netip.MustParseAddr("192.168.2.1"))
+	require.NoError(t, err)
+	assert.True(t, banned)
+
+	_, err = b.IsBanned(netip.Addr{})
+	require.Error(t, err)
+}
+
+func TestBanServerBlacklistReload(t *testing.T) {
+	file := writeBlacklist(t, []string{"1.2.3.0/24 # comment", "# 5.6.7.8", ""})
+
+	b := model.NewBanServer()
+	require.NoError(t, b.AddBlacklistCIDRFile(file))
+	require.NoError(t, b.AddBannedCIDR("9.9.9.9"))
+
+	banned, err := b.IsBanned(netip.MustParseAddr("1.2.3.255"))
+	require.NoError(t, err)
+	assert.True(t, banned)
+
+	banned, err = b.IsBanned(netip.MustParseAddr("5.6.7.8"))
+	require.NoError(t, err)
+	assert.False(t, banned)
+
+	// reloading a file replaces its previous entries
+	require.NoError(t, os.WriteFile(file, []byte("5.6.7.8\n"), 0o600))
+	require.NoError(t, b.AddBlacklistCIDRFile(file))
+
+	for ip, want := range map[string]bool{"1.2.3.255": false, "5.6.7.8": true, "9.9.9.9": true} {
+		banned, err = b.IsBanned(netip.MustParseAddr(ip))
+		require.NoError(t, err)
+		assert.Equal(t, want, banned, ip)
+	}
+}
+
+func TestBanServerLookup(t *testing.T) {
+	file := writeBlacklist(t, []string{"# comment", "1.2.0.0/16", "1.2.3.0/24 # nested", "5.6.7.8"})
+
+	b := model.NewBanServer()
+	require.NoError(t, b.AddBlacklistCIDRFile(file))
+	require.NoError(t, b.AddBannedCIDR("1.2.3.4"))
+
+	matches, err := b.Lookup(netip.MustParseAddr("::ffff:1.2.3.4"))
+	require.NoError(t, err)
+	assert.Equal(t, []model.SourcedPrefix{
+		{Prefix: netip.MustParsePrefix("1.2.3.4/32")},
+		{Prefix: netip.MustParsePrefix("1.2.0.0/16"), Source: file, Line: 2},
+		{Prefix: netip.MustParsePrefix("1.2.3.0/24"), Source: file, Line: 3},
+	}, matches)
+	assert.Equal(t, "1.2.3.4/32 (manual)", matches[0].String())
+
+	matches, err = b.Lookup(netip.MustParseAddr("5.6.7.9"))
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+
+	_, err = b.Lookup(netip.Addr{})
+	require.Error(t, err)
+}
+
+// TestBanServerRandom compares the ban server against cidranger.
+func TestBanServerRandom(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	prefixes := randomPrefixes(r, 10_000)
+
+	b := model.NewBanServer()
+	ranger := cidranger.NewPCTrieRanger()
+	for _, p := range prefixes {
+		require.NoError(t, b.AddBannedCIDR(p.String()))
+		require.NoError(t, ranger.Insert(cidranger.NewBasicRangerEntry(ipNet(p))))
+	}
+
+	for i := 0; i < 100_000; i++ {
+		var ip netip.Addr
+		if i%2 == 0 {
+			// ips at the borders of the prefixes
+			p := prefixes[r.IntN(len(prefixes))]
+			ip = p.Addr()
+			if i%4 == 0 {
+				ip = ip.Prev()
+			}
+		} else {
+			ip = randomAddr(r, i%3 == 0)
+		}
+		if !ip.IsValid() {
+			continue
+		}
+
+		want, err := ranger.Contains(net.IP(ip.AsSlice()))
+		require.NoError(t, err)
+		got, err := b.IsBanned(ip)
+		require.NoError(t, err)
+		require.Equal(t, want, got, ip.String())
+	}
+}
+
+func randomAddr(r *rand.Rand, v4 bool) netip.Addr {
+	if v4 {
+		var b [4]byte
+		for i := range b {
+			b[i] = byte(r.UintN(256))
+		}
+		return netip.AddrFrom4(b)
+	}
+
+	var b [16]byte
+	for i := range b {
+		b[i] = byte(r.UintN(256))
+	}
+	// avoid ipv4-mapped addresses
+	b[0] |= 0x20
+	return netip.AddrFrom16(b)
+}
+
+func randomPrefixes(r *rand.Rand, n int) []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0, n)
+	for i := 0; i < n; i++ {
+		if i%4 == 0 {
+			addr := randomAddr(r, false)
+			prefixes = append(prefixes, netip.PrefixFrom(addr, 16+r.IntN(113)).Masked())
+			continue
+		}
+		addr := randomAddr(r, true)
+		prefixes = append(prefixes, netip.PrefixFrom(addr, 8+r.IntN(25)).Masked())
+	}
+	return prefixes
+}
+
+func ipNet(p netip.Prefix) net.IPNet {
+	return net.IPNet{
+		IP:   p.Addr().AsSlice(),
+		Mask: net.CIDRMask(p.Bits(), p.Addr().BitLen()),
+	}
+}
+
+func writeBlacklist(tb testing.TB, lines []string) string {
+	file := filepath.Join(tb.TempDir(), "blacklist.txt")
+	f, err := os.Create(file)
+	require.NoError(tb, err)
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	for _, line := range lines {
+		_, err = fmt.Fprintln(w, line)
+		require.NoError(tb, err)
+	}
+	require.NoError(tb, w.Flush())
+	return file
+}
+
+func benchmarkBlacklist(b *testing.B, n int) (string, []netip.Prefix) {
+	r := rand.New(rand.NewPCG(3, 4))
+	prefixes := randomPrefixes(r, n)
+	lines := make([]string, 0, len(prefixes))
+	for _, p := range prefixes {
+		lines = append(lines, p.String())
+	}
+	return writeBlacklist(b, lines), prefixes
+}
+
+// loadCIDRanger loads a blacklist file the way the ban server did before it was based on net/netip.
+func loadCIDRanger(b *testing.B, file string) cidranger.Ranger {
+	f, err := os.Open(file)
+	require.NoError(b, err)
+	defer f.Close()
+
+	ranger := cidranger.NewPCTrieRanger()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		_, network, err := net.ParseCIDR(scanner.Text())
+		require.NoError(b, err)
+		require.NoError(b, ranger.Insert(cidranger.NewBasicRangerEntry(*network)))
+	}
+	return ranger
+}
+
+func loadBanServer(b *testing.B, file string) *model.BanServer {
+	bs := model.NewBanServer()
+	require.NoError(b, bs.AddBlacklistCIDRFile(file))
+	// the set is built lazily on the first lookup
+	_, err := bs.IsBanned(netip.MustParseAddr("1.2.3.4"))
+	require.NoError(b, err)
+	return bs
+}
+
+// reportHeap reports the heap that is retained by the value returned by load.
+func reportHeap[T any](b *testing.B, load func() T) {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	v := load()
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	runtime.KeepAlive(v)
+	b.ReportMetric(float64(after.HeapAlloc)-float64(before.HeapAlloc), "heap-bytes")
+}
+
+var benchmarkSizes = []int{10_000, 1_000_000}
+
+func BenchmarkBlacklistLoad(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		file, _ := benchmarkBlacklist(b, n)
+
+		b.Run(fmt.Sprintf("banserver/%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				loadBanServer(b, file)
+			}
+			reportHeap(b, func() *model.BanServer { return loadBanServer(b, file) })
+		})
+
+		b.Run(fmt.Sprintf("cidranger/%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				loadCIDRanger(b, file)
+			}
+			reportHeap(b, func() cidranger.Ranger { return loadCIDRanger(b, file) })
+		})
+	}
+}
+
+func BenchmarkBlacklistLookup(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		file, _ := benchmarkBlacklist(b, n)
+
+		r := rand.New(rand.NewPCG(5, 6))
+		ips := make([]netip.Addr, 1024)
+		for i := range ips {
+			ips[i] = randomAddr(r, i%4 != 0)
+		}
+
+		b.Run(fmt.Sprintf("banserver/%d", n), func(b *testing.B) {
+			bs := loadBanServer(b, file)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, _ = bs.IsBanned(ips[i%len(ips)])
+			}
+		})
+
+		b.Run(fmt.Sprintf("cidranger/%d", n), func(b *testing.B) {
+			ranger := loadCIDRanger(b, file)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				// the previous implementation parsed the ip string on every lookup
+				_, _ = ranger.Contains(net.ParseIP(ips[i%len(ips)].String()))
+			}
+		})
+	}
+}
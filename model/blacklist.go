@@ -0,0 +1,106 @@
+package model
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"regexp"
+	"strings"
+)
+
+var (
+	// 1: ip, CIDR or wildcard 2: optional end of a start-end range 3: optional comment
+	// 1.2.3.0/24 # comment
+	// 1.2.3.4 - 1.2.3.200 comment
+	// "1.2.3.*","comment"
+	blacklistLineRegex = regexp.MustCompile(`^"?([0-9a-fA-F:.\[\]*/]+)"?(?:\s*-\s*"?([0-9a-fA-F:.\[\]]+)"?)?(?:\s*[,;#\s]\s*"?(.*?)"?)?$`)
+)
+
+// BlacklistEntry is a single valid line of an ip blacklist.
+type BlacklistEntry struct {
+	Line     int
+	Prefixes []netip.Prefix
+	Comment  string
+}
+
+// LineError is returned for every line of a blacklist that cannot be parsed.
+type LineError struct {
+	Line int
+	Text string
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v: %q", e.Line, e.Err, e.Text)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+var errInvalidBlacklistLine = errors.New("neither an ip, CIDR, ip range nor wildcard")
+
+// ParseBlacklist parses ip blacklists with one entry per line. Supported are single ips, CIDRs,
+// start-end ranges and ipv4 wildcards, optionally followed by a comment, which makes FireHOL netsets as well as
+// CSV files with an ip and a comment column valid blacklists.
+// Lines starting with #, ; or // are comments. A CSV header in the first line is ignored.
+// All valid entries are returned, invalid lines are returned as joined *LineError.
+func ParseBlacklist(r io.Reader) (entries []BlacklistEntry, err error) {
+	var (
+		errs    []error
+		lineNum int
+		content bool
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "//") {
+			continue
+		}
+
+		isHeader := !content && strings.Contains(line, ",")
+		content = true
+
+		entry, err := parseBlacklistLine(line)
+		if err != nil {
+			if isHeader {
+				continue
+			}
+			errs = append(errs, &LineError{Line: lineNum, Text: line, Err: err})
+			continue
+		}
+		entry.Line = lineNum
+		entries = append(entries, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, err)
+	}
+	return entries, errors.Join(errs...)
+}
+
+func parseBlacklistLine(line string) (BlacklistEntry, error) {
+	matches := blacklistLineRegex.FindStringSubmatch(line)
+	if len(matches) == 0 {
+		return BlacklistEntry{}, errInvalidBlacklistLine
+	}
+
+	value := matches[1]
+	if matches[2] != "" {
+		value += "-" + matches[2]
+	}
+
+	prefixes, err := parseCIDRs(value)
+	if err != nil {
+		return BlacklistEntry{}, err
+	}
+
+	return BlacklistEntry{
+		Prefixes: prefixes,
+		Comment:  strings.TrimSpace(strings.TrimLeft(matches[3], "#;/ ")),
+	}, nil
+}
@@ -0,0 +1,88 @@
+package model_test
+
+import (
+	"errors"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/jxsl13/banserver/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func prefixes(cidrs ...string) []netip.Prefix {
+	result := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		result = append(result, netip.MustParsePrefix(cidr))
+	}
+	return result
+}
+
+func TestParseBlacklist(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want model.BlacklistEntry
+	}{
+		{"ip", "1.2.3.4", model.BlacklistEntry{Prefixes: prefixes("1.2.3.4/32")}},
+		{"ipv6", "[2001:db8::1]", model.BlacklistEntry{Prefixes: prefixes("2001:db8::1/128")}},
+		{"cidr", "1.2.3.0/24", model.BlacklistEntry{Prefixes: prefixes("1.2.3.0/24")}},
+		{"unmasked cidr", "1.2.3.4/24", model.BlacklistEntry{Prefixes: prefixes("1.2.3.0/24")}},
+		{"ipv4-mapped cidr", "::ffff:1.2.3.0/120", model.BlacklistEntry{Prefixes: prefixes("1.2.3.0/24")}},
+		{"comment", "1.2.3.0/24 # some vpn", model.BlacklistEntry{Prefixes: prefixes("1.2.3.0/24"), Comment: "some vpn"}},
+		{"text comment", "1.2.3.4 some vpn", model.BlacklistEntry{Prefixes: prefixes("1.2.3.4/32"), Comment: "some vpn"}},
+		{"csv", `1.2.3.0/24,"some, vpn"`, model.BlacklistEntry{Prefixes: prefixes("1.2.3.0/24"), Comment: "some, vpn"}},
+		{"quoted csv", `"1.2.3.0/24","some vpn"`, model.BlacklistEntry{Prefixes: prefixes("1.2.3.0/24"), Comment: "some vpn"}},
+		{"wildcard", "1.2.3.*", model.BlacklistEntry{Prefixes: prefixes("1.2.3.0/24")}},
+		{"wildcards", "10.*.*.*", model.BlacklistEntry{Prefixes: prefixes("10.0.0.0/8")}},
+		{"range", "1.2.3.4-1.2.3.200", model.BlacklistEntry{Prefixes: prefixes(
+			"1.2.3.4/30", "1.2.3.8/29", "1.2.3.16/28", "1.2.3.32/27", "1.2.3.64/26", "1.2.3.128/26", "1.2.3.192/29", "1.2.3.200/32",
+		)}},
+		{"range with spaces", "1.2.3.0 - 1.2.4.255 some vpn", model.BlacklistEntry{Prefixes: prefixes("1.2.3.0/24", "1.2.4.0/24"), Comment: "some vpn"}},
+		{"single ip range", "1.2.3.4-1.2.3.4", model.BlacklistEntry{Prefixes: prefixes("1.2.3.4/32")}},
+		{"full range", "0.0.0.0-255.255.255.255", model.BlacklistEntry{Prefixes: prefixes("0.0.0.0/0")}},
+		{"ipv6 range", "2001:db8::-2001:db8::1:0", model.BlacklistEntry{Prefixes: prefixes("2001:db8::/112", "2001:db8::1:0/128")}},
+		{"full ipv6 range", "::-ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", model.BlacklistEntry{Prefixes: prefixes("::/0")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := model.ParseBlacklist(strings.NewReader("# comment\n\n" + tt.line + "\n"))
+			require.NoError(t, err)
+			require.Len(t, entries, 1)
+			tt.want.Line = 3
+			assert.Equal(t, tt.want, entries[0])
+		})
+	}
+}
+
+func TestParseBlacklistErrors(t *testing.T) {
+	blacklist := strings.Join([]string{
+		"ip,comment",
+		"1.2.3.4,first",
+		"not an ip",
+		"; comment",
+		"1.2.3.300",
+		"1.2.3.200-1.2.3.4",
+		"1.2.3.4-2001:db8::1",
+		"1.*.3.*",
+		"2001:db8::*",
+		"1.2.3.4x",
+		"// comment",
+		"5.6.7.8",
+	}, "\n")
+
+	entries, err := model.ParseBlacklist(strings.NewReader(blacklist))
+	require.Len(t, entries, 2)
+	assert.Equal(t, 2, entries[0].Line)
+	assert.Equal(t, 12, entries[1].Line)
+
+	var lines []int
+	for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var lineErr *model.LineError
+		require.True(t, errors.As(err, &lineErr), err)
+		lines = append(lines, lineErr.Line)
+	}
+	assert.Equal(t, []int{3, 5, 6, 7, 8, 9, 10}, lines)
+}
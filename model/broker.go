@@ -1,26 +1,31 @@
 package model
 
 import (
-	"bufio"
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"log"
+	"maps"
+	"net/http"
+	"net/netip"
 	"os"
-	"regexp"
 	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/jxsl13/banserver/econ"
 	"github.com/jxsl13/banserver/parser"
+	"github.com/jxsl13/banserver/secret"
 )
 
 type Broker struct {
 	mu        sync.RWMutex
 	banserver *BanServer
-	// filepath -> blocklist
-	chatBlockRegex []*regexp.Regexp
+	chatRules []chatRule
 
 	// default reasons and durations for bans
 	permabanDuration time.Duration
@@ -29,11 +34,79 @@ type Broker struct {
 	chatBanReason    string
 
 	propagate bool
+	// confirmation of bans, disabled if the timeout is <= 0
+	banConfirmTimeout time.Duration
+	banRetries        int
+	// prefix the reasons of propagated bans with the name of the server the ban originated from
+	banOrigin bool
 
-	serverMap map[string]*econ.Server
+	serverMap  map[string]*econ.Server
+	dispatcher *econ.Dispatcher
+
+	// event subscribers, e.g. admins following the events live
+	subMu       sync.Mutex
+	subscribers map[chan Event]struct{}
+
+	// server -> ip bans of the last ban list, kept up to date with ban and unban events
+	serverBans  map[string]map[netip.Addr]parser.BanListEntry
+	banListedAt map[string]time.Time
+	banListed   chan struct{} // notifies the reconciler about received ban lists
+
+	// ban list reconciliation, disabled if reconcileMode is empty
+	reconcileMode string
+	// ips that were unbanned on any server and must not be banned on any other server
+	tombstones map[netip.Addr]struct{}
 
 	// server -> all others
 	others map[string][]string
+
+	// server -> enabled rules and ban overrides, servers without rules apply all rules
+	serverRules map[string]ServerRules
+
+	// rcon login bruteforce protection, disabled if attempts <= 0
+	rconBanAttempts int
+	rconFailures    *eventWindow
+	rconBanDuration time.Duration
+	rconBanReason   string
+
+	// alert on rcon logins from unfamiliar ips
+	rconAlert   bool
+	rconTrusted []netip.Prefix
+	// ips that successfully logged into the rcon before
+	rconFamiliar map[netip.Addr]struct{}
+
+	// vote abuse protection, disabled if both limits are <= 0
+	voteMax         int
+	voteKickMax     int
+	votes           *eventWindow
+	kickVotes       *eventWindow
+	voteAction      string
+	voteBanDuration time.Duration
+	voteBanReason   string
+
+	// whisper spam protection, disabled if whisperTargets is nil
+	whisperMaxTargets  int
+	whisperTargets     *distinctWindow
+	whisperAction      string
+	whisperBanDuration time.Duration
+	whisperBanReason   string
+
+	// geoip lookups of joining clients, disabled if geo is nil
+	geo                 *GeoIP
+	geoDeniedASNs       map[uint]struct{}
+	geoAllowedCountries map[string]map[string]struct{} // group -> countries
+	geoAction           string
+	geoBanDuration      time.Duration
+	geoBanReason        string
+
+	// remote http(s) blacklists
+	blacklistClient   *http.Client
+	blacklistCacheDir string // defaults to the user cache directory
+	blacklistMaxSize  int
+	remoteLists       []*remoteList
+	// local blacklist files that are read again on reload
+	ipBlacklistFiles   []string
+	chatBlacklistFiles []string
 }
 
 func NewBroker(
@@ -41,16 +114,48 @@ func NewBroker(
 	permaBanDuration time.Duration,
 	permabanReason string, chatBanDuration time.Duration,
 	chatBanReason string,
+	opts ...Option,
 ) *Broker {
-	return &Broker{
+	p := &Broker{
 		banserver:        NewBanServer(),
 		serverMap:        make(map[string]*econ.Server),
+		serverBans:       make(map[string]map[netip.Addr]parser.BanListEntry),
+		banListedAt:      make(map[string]time.Time),
+		banListed:        make(chan struct{}, 1),
+		tombstones:       make(map[netip.Addr]struct{}),
+		serverRules:      make(map[string]ServerRules),
+		dispatcher:       econ.NewDispatcher(),
+		subscribers:      make(map[chan Event]struct{}),
 		permabanDuration: permaBanDuration,
 		permabanReason:   permabanReason,
 		chatBanDuration:  chatBanDuration,
 		chatBanReason:    chatBanReason,
 		propagate:        propagate,
+		rconFamiliar:     make(map[netip.Addr]struct{}),
+		blacklistClient:  &http.Client{Timeout: defaultBlacklistTimeout},
+		blacklistMaxSize: DefaultBlacklistMaxSize,
+	}
+
+	for _, opt := range opts {
+		opt(p)
 	}
+
+	econ.Subscribe(p.dispatcher, p.handleChat)
+	econ.Subscribe(p.dispatcher, p.handleEntered)
+	econ.Subscribe(p.dispatcher, p.handleDropped)
+	econ.Subscribe(p.dispatcher, p.handleBanned)
+	econ.Subscribe(p.dispatcher, p.handleUnbanned)
+	econ.Subscribe(p.dispatcher, p.handleBanList)
+	econ.Subscribe(p.dispatcher, p.handleRconAuth)
+	econ.Subscribe(p.dispatcher, p.handleVoteCalled)
+	econ.Subscribe(p.dispatcher, p.handleVoteResult)
+	return p
+}
+
+// Dispatcher allows to subscribe additional handlers to parsed events of all servers,
+// e.g. for custom event types that have been registered in the parser.DefaultRegistry.
+func (p *Broker) Dispatcher() *econ.Dispatcher {
+	return p.dispatcher
 }
 
 func (p *Broker) Close() (err error) {
@@ -64,65 +169,189 @@ func (p *Broker) Close() (err error) {
 	return nil
 }
 
-func (p *Broker) AddBlacklistCIDRFile(file string) error {
-	return p.banserver.AddBlacklistCIDRFile(file)
+// AddBlacklistCIDRFile adds an ip blacklist, which is either a file path or an http(s) url.
+func (p *Broker) AddBlacklistCIDRFile(ctx context.Context, file string) error {
+	if IsRemoteBlacklist(file) {
+		return p.addRemoteBlacklist(ctx, file, p.banserver.AddBlacklist)
+	}
+
+	err := p.banserver.AddBlacklistCIDRFile(file)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !slices.Contains(p.ipBlacklistFiles, file) {
+		p.ipBlacklistFiles = append(p.ipBlacklistFiles, file)
+	}
+	return nil
 }
 
-func (p *Broker) AddBlacklistChatFile(file string) error {
+// AddBlacklistChatFile adds a chat blacklist, which is either a file path or an http(s) url.
+func (p *Broker) AddBlacklistChatFile(ctx context.Context, file string) error {
+	if IsRemoteBlacklist(file) {
+		return p.addRemoteBlacklist(ctx, file, p.addChatBlacklist)
+	}
+
 	f, err := os.Open(file)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
 
-	deduplicated := make(map[string]struct{})
-	list := make([]*regexp.Regexp, 0)
+	err = p.addChatBlacklist(file, f)
+	if err != nil {
+		return err
+	}
 
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			continue
-		}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !slices.Contains(p.chatBlacklistFiles, file) {
+		p.chatBlacklistFiles = append(p.chatBlacklistFiles, file)
+	}
+	return nil
+}
 
-		if strings.HasPrefix(line, "#") {
-			continue
-		}
+// ReloadBlacklists reads all blacklist files again and refreshes all http(s) blacklists.
+// Blacklists that cannot be loaded keep their previous entries.
+func (p *Broker) ReloadBlacklists(ctx context.Context) (err error) {
+	p.mu.RLock()
+	ipFiles := slices.Clone(p.ipBlacklistFiles)
+	chatFiles := slices.Clone(p.chatBlacklistFiles)
+	lists := slices.Clone(p.remoteLists)
+	p.mu.RUnlock()
 
-		if _, ok := deduplicated[line]; ok {
-			continue
+	for _, file := range ipFiles {
+		err = errors.Join(err, p.banserver.AddBlacklistCIDRFile(file))
+	}
+	for _, file := range chatFiles {
+		err = errors.Join(err, p.AddBlacklistChatFile(ctx, file))
+	}
+	for _, list := range lists {
+		if refreshErr := list.Refresh(ctx); refreshErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to refresh blacklist %s: %w", list.url, refreshErr))
 		}
-		deduplicated[line] = struct{}{}
+	}
+	return err
+}
 
-		re, err := regexp.Compile(line)
-		if err != nil {
-			return err
-		}
-		list = append(list, re)
+// addChatBlacklist replaces all rules of the given source with the rules of the blacklist.
+func (p *Broker) addChatBlacklist(source string, r io.Reader) error {
+	list, err := parseChatBlacklist(r)
+	if err != nil {
+		return fmt.Errorf("invalid chat blacklist %s: %w", source, err)
+	}
+	for idx := range list {
+		list[idx].source = source
 	}
 
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	p.chatBlockRegex = append(p.chatBlockRegex, list...)
+	// the rules are copied, as handlers may still iterate over the previous rules
+	rules := make([]chatRule, 0, len(p.chatRules)+len(list))
+	for _, rule := range p.chatRules {
+		if rule.source != source {
+			rules = append(rules, rule)
+		}
+	}
+	p.chatRules = append(rules, list...)
+
+	log.Printf("added %d regular expressions from %s", len(list), source)
+	return nil
+}
 
-	log.Printf("added %d regular expressions from file %s", len(list), file)
+func (p *Broker) addRemoteBlacklist(ctx context.Context, url string, apply func(source string, r io.Reader) error) error {
+	p.mu.Lock()
+	if p.blacklistCacheDir == "" {
+		p.blacklistCacheDir = defaultBlacklistCacheDir()
+	}
+	list := &remoteList{
+		url:      url,
+		client:   p.blacklistClient,
+		cacheDir: p.blacklistCacheDir,
+		maxSize:  p.blacklistMaxSize,
+		apply:    apply,
+	}
+	p.mu.Unlock()
+
+	err := list.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load blacklist %s: %w", url, err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.remoteLists = append(p.remoteLists, list)
 	return nil
 }
 
-func (p *Broker) DialTo(ctx context.Context, addrPort, password string) error {
+// RefreshBlacklists refreshes all http(s) blacklists in the given interval until the context is canceled.
+// Lists that cannot be fetched keep their previous entries.
+func (p *Broker) RefreshBlacklists(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// blacklists may have been added since the last refresh
+			p.mu.RLock()
+			lists := slices.Clone(p.remoteLists)
+			p.mu.RUnlock()
+
+			for _, list := range lists {
+				err := list.Refresh(ctx)
+				if err != nil {
+					log.Printf("failed to refresh blacklist %s, keeping previous entries: %v", list.url, err)
+				}
+			}
+		}
+	}
+}
+
+func (p *Broker) DialTo(ctx context.Context, addrPort string, password secret.Secret, opts ...econ.Option) error {
 	log.Printf("connecting to server %s...", addrPort)
-	server, err := econ.DialTo(ctx, addrPort, password, p.handle)
+	server, err := econ.DialTo(ctx, addrPort, password, p.dispatch, opts...)
 	if err != nil {
 		return err
 	}
 
+	p.addServer(server)
+	log.Printf("connected to server %s", server)
+	return nil
+}
+
+// addServer registers the server before it lists the bans that were made before the banserver connected,
+// so that even an immediate answer is propagated to and reconciled with all other servers.
+func (p *Broker) addServer(server *econ.Server) {
 	p.mu.Lock()
-	defer p.mu.Unlock()
-	p.serverMap[addrPort] = server
+	p.serverMap[server.AddressPort()] = server
 	p.setOthersMap()
+	p.mu.Unlock()
 
-	log.Printf("connected to server %s", addrPort)
-	return nil
+	err := server.ListBans()
+	if err != nil {
+		log.Printf("failed to request ban list of %s: %v", server, err)
+	}
+}
+
+// serverName returns the name and address of the server with the given address for log messages.
+func (p *Broker) serverName(addrPort string) string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	s, ok := p.serverMap[addrPort]
+	if !ok {
+		return addrPort
+	}
+	return s.String()
 }
 
 func (p *Broker) setOthersMap() {
@@ -143,88 +372,122 @@ func (p *Broker) setOthersMap() {
 	p.others = others
 }
 
-func (p *Broker) othersOf(server string) []string {
+// serverOthers is a server together with all other servers.
+type serverOthers struct {
+	server *econ.Server
+	others []*econ.Server
+}
+
+// othersNames returns the addresses of the other servers.
+func (so serverOthers) othersNames() []string {
+	names := make([]string, 0, len(so.others))
+	for _, other := range so.others {
+		names = append(names, other.AddressPort())
+	}
+	return names
+}
+
+// lookupServerOthers looks up the server together with its other servers under a single lock,
+// so that commands can be sent without holding it.
+func (p *Broker) lookupServerOthers(server string) (serverOthers, error) {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
-	return slices.Clone(p.others[server])
-}
+	s, ok := p.serverMap[server]
+	if !ok {
+		return serverOthers{}, fmt.Errorf("triggering server %s not found in server map", server)
+	}
 
-func (p *Broker) BanOnAll(triggeringServer string, playerIP string, duration time.Duration, reason string) (err error) {
-	defer func() {
-		if err != nil {
-			log.Printf("error banning ip %s on all servers triggered by %s: %v", playerIP, triggeringServer, err)
+	others := make([]*econ.Server, 0, len(p.others[server]))
+	for _, other := range p.others[server] {
+		o, ok := p.serverMap[other]
+		if !ok {
+			return serverOthers{}, fmt.Errorf("other server %s not found in server map", other)
 		}
-	}()
+		others = append(others, o)
+	}
+	return serverOthers{server: s, others: others}, nil
+}
 
+// allServerOthers returns every server together with its other servers, which are looked up under a single lock,
+// so that commands can be sent without holding it.
+func (p *Broker) allServerOthers() []serverOthers {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
-	for _, s := range p.serverMap {
-		others := p.othersOf(s.AddressPort())
-		s.IgnoreBanPrapagation(playerIP, others...)
 
-		err := s.BanIP(triggeringServer, playerIP, duration, reason)
-		if err != nil {
-			return err
+	all := make([]serverOthers, 0, len(p.serverMap))
+	for addrPort, s := range p.serverMap {
+		others := make([]*econ.Server, 0, len(p.others[addrPort]))
+		for _, other := range p.others[addrPort] {
+			if o, ok := p.serverMap[other]; ok {
+				others = append(others, o)
+			}
 		}
+		all = append(all, serverOthers{server: s, others: others})
 	}
-
-	return nil
+	return all
 }
 
-func (p *Broker) UnbanOnAll(triggeringServer, playerIP string) (err error) {
+func (p *Broker) BanOnAll(triggeringServer string, playerIP netip.Addr, duration time.Duration, reason string) (err error) {
 	defer func() {
 		if err != nil {
-			log.Printf("error unbanning ip %s on all servers triggered by %s: %v", playerIP, triggeringServer, err)
+			log.Printf("error banning ip %s on all servers triggered by %s: %v", playerIP, p.serverName(triggeringServer), err)
 		}
 	}()
 
-	p.mu.RLock()
-	defer p.mu.RUnlock()
-	for _, s := range p.serverMap {
-		others := p.othersOf(s.AddressPort())
-		s.IgnoreBanPrapagation(playerIP, others...)
-
-		err := s.UnbanIP(triggeringServer, playerIP)
+	for _, so := range p.allServerOthers() {
+		// the resulting bans of all servers must not be propagated
+		err := p.ban(so.server, triggeringServer, playerIP, duration, reason, so.others...)
 		if err != nil {
 			return err
 		}
 	}
+
 	return nil
 }
 
-func (p *Broker) BanOnOthers(triggeringServer, playerIP string, duration time.Duration, reason string) (err error) {
+func (p *Broker) UnbanOnAll(triggeringServer string, playerIP netip.Addr) (err error) {
 	defer func() {
 		if err != nil {
-			log.Printf("error banning ip %s on all other servers of %s: %v", playerIP, triggeringServer, err)
+			log.Printf("error unbanning ip %s on all servers triggered by %s: %v", playerIP, p.serverName(triggeringServer), err)
 		}
 	}()
 
-	p.mu.RLock()
-	defer p.mu.RUnlock()
+	for _, so := range p.allServerOthers() {
+		so.server.IgnoreUnbanPrapagation(playerIP, so.othersNames()...)
 
-	ts, ok := p.serverMap[triggeringServer]
-	if !ok {
-		panic("triggering server not found in server map: this is a programming error")
+		err := so.server.UnbanIP(triggeringServer, playerIP)
+		if err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	otherServers := p.othersOf(triggeringServer)
-	ts.IgnoreBanPrapagation(playerIP, otherServers...)
+// propagateBan bans the ip of the triggering server on the given targets, which are other servers of it.
+// The servers are looked up under the lock, the commands are sent without holding it.
+func (p *Broker) propagateBan(triggeringServer string, targets []string, playerIP netip.Addr, duration time.Duration, reason string) error {
+	so, err := p.lookupServerOthers(triggeringServer)
+	if err != nil {
+		return err
+	}
+	ts := so.server
 
-	for _, other := range otherServers {
-		s, ok := p.serverMap[other]
-		if !ok {
-			panic("other server not found in server map: this is a programming error")
-		}
+	if p.banOrigin {
+		reason = originReason(ts.Name(), reason)
+	}
 
-		// removes the ignore flag
+	for _, s := range so.others {
+		// the ban was propagated from the other server, removes the ignore flag
 		if s.IsIgnoredBanPropagation(triggeringServer, playerIP) {
-			// remove flag from current server
-			_ = ts.IsIgnoredBanPropagation(other, playerIP)
+			continue
+		}
+		if !slices.Contains(targets, s.AddressPort()) {
 			continue
 		}
 
-		err := s.BanIP(triggeringServer, playerIP, duration, reason)
+		// the resulting ban of the other server must not be propagated back
+		err := p.ban(s, triggeringServer, playerIP, duration, reason, ts)
 		if err != nil {
 			return err
 		}
@@ -232,34 +495,41 @@ func (p *Broker) BanOnOthers(triggeringServer, playerIP string, duration time.Du
 	return nil
 }
 
-func (p *Broker) UnbanOnOthers(triggeringServer, playerIP string) (err error) {
+// banOriginPrefix is prepended to the name of the server that a propagated ban originated from.
+const banOriginPrefix = "banned on "
+
+// originReason prefixes the reason of a propagated ban with the name of the server the ban originated from,
+// e.g. "banned on DDNet Novice #1: spam". Reasons that already contain an origin are kept as is.
+func originReason(origin, reason string) string {
+	if strings.HasPrefix(reason, banOriginPrefix) {
+		return reason
+	}
+	if reason == "" {
+		return banOriginPrefix + origin
+	}
+	return fmt.Sprintf("%s%s: %s", banOriginPrefix, origin, reason)
+}
+
+func (p *Broker) UnbanOnOthers(triggeringServer string, playerIP netip.Addr) (err error) {
 	defer func() {
 		if err != nil {
-			log.Printf("error unbanning ip %s on all other servers of %s: %v", playerIP, triggeringServer, err)
+			log.Printf("error unbanning ip %s on all other servers of %s: %v", playerIP, p.serverName(triggeringServer), err)
 		}
 	}()
 
-	p.mu.RLock()
-	defer p.mu.RUnlock()
-
-	ts, ok := p.serverMap[triggeringServer]
-	if !ok {
-		panic("triggering server not found in server map: this is a programming error")
+	so, err := p.lookupServerOthers(triggeringServer)
+	if err != nil {
+		return err
 	}
+	ts := so.server
 
-	otherServers := p.othersOf(triggeringServer)
-	ts.IgnoreUnbanPrapagation(playerIP, otherServers...)
-
-	for _, other := range otherServers {
-		s, ok := p.serverMap[other]
-		if !ok {
-			panic("other server not found in server map: this is a programming error")
-		}
+	ts.IgnoreUnbanPrapagation(playerIP, so.othersNames()...)
 
+	for _, s := range so.others {
 		// removes the ignore flag
 		if s.IsIgnoredUnbanPropagation(triggeringServer, playerIP) {
 			// remove flag from current server
-			_ = ts.IsIgnoredUnbanPropagation(other, playerIP)
+			_ = ts.IsIgnoredUnbanPropagation(s.AddressPort(), playerIP)
 			continue
 		}
 
@@ -271,40 +541,6 @@ func (p *Broker) UnbanOnOthers(triggeringServer, playerIP string) (err error) {
 	return nil
 }
 
-func (p *Broker) handle(s *econ.Server, line string) {
-	chat, ok := parser.ParseChatMessage(line)
-	if ok {
-		p.handleChat(s, chat)
-		return
-	}
-
-	entered, ok := parser.ParseClientEntered(line)
-	if ok {
-		p.handleEntered(s, entered)
-		return
-	}
-
-	dropped, ok := parser.ParseClientDropped(line)
-	if ok {
-		p.handleDropped(s, dropped)
-		return
-	}
-
-	banned, ok := parser.ParseClientBanned(line)
-	if ok {
-		p.handleBanned(s, banned)
-		return
-	}
-
-	unbanned, ok := parser.ParseClientUnbanned(line)
-	if ok {
-		p.handleUnbanned(s, unbanned)
-		return
-	}
-
-	return
-}
-
 func (p *Broker) handleEntered(s *econ.Server, entered parser.ClientEntered) {
 	banned, err := p.banserver.IsBanned(entered.IP)
 	if err != nil {
@@ -312,46 +548,121 @@ func (p *Broker) handleEntered(s *econ.Server, entered parser.ClientEntered) {
 		return
 	}
 
-	if banned {
-		log.Printf("banned client %s entered server %s", entered.IP, s.AddressPort())
+	client := entered.IP.String()
+	location, located := p.lookupGeoIP(entered.IP)
+	if located {
+		client = fmt.Sprintf("%s (%s)", entered.IP, location)
+	}
+
+	rules := p.rulesOf(s.AddressPort())
+	if banned && rules.IsEnabled(RuleIPBlacklist) {
+		log.Printf("banned client %s entered server %s", client, s)
 		// just ban on the server that the client tries to enter.
 		// we do not want to propagate the ban to all other servers.
 		// because we can just ban the IP once it tries to enter the other server.
 		// this way we do not spam the ban list of all other servers.
-		err := s.BanIP(s.AddressPort(), entered.IP, p.permabanDuration, p.permabanReason)
+		duration, reason := rules.Ban(RuleIPBlacklist, p.permabanDuration, p.permabanReason)
+		err := p.ban(s, s.AddressPort(), entered.IP, duration, reason)
 		if err != nil {
-			log.Printf("error banning client %s on server %s: %v", entered.IP, s.AddressPort(), err)
+			log.Printf("error banning client %s on server %s: %v", entered.IP, s, err)
 			return
 		}
 	} else {
-		log.Printf("client %s entered server %s", entered.IP, s.AddressPort())
+		log.Printf("client %s entered server %s", client, s)
+		if located && rules.IsEnabled(RuleGeoIP) {
+			p.checkGeoIP(s, entered, location)
+		}
+	}
+}
+
+func (p *Broker) lookupGeoIP(ip netip.Addr) (GeoInfo, bool) {
+	if p.geo == nil {
+		return GeoInfo{}, false
+	}
+
+	info, err := p.geo.Lookup(ip)
+	if err != nil {
+		log.Printf("error looking up location of client %s: %v", ip, err)
+		return GeoInfo{}, false
+	}
+	return info, true
+}
+
+// checkGeoIP applies the geoip action to clients that are not allowed to join from their location.
+func (p *Broker) checkGeoIP(s *econ.Server, entered parser.ClientEntered, location GeoInfo) {
+	violation, denied := p.geoViolation(s.Group(), location)
+	if denied {
+		duration, reason := p.rulesOf(s.AddressPort()).Ban(RuleGeoIP, p.geoBanDuration, p.geoBanReason)
+		p.punish(s, entered.ClientID, entered.IP, p.geoAction, duration, reason, violation)
 	}
 }
 
+// geoViolation returns a description of the violation in case that clients from the given location are either
+// from a denied autonomous system or from a country that is not allowed in the given server group.
+// Clients from unknown locations are always allowed.
+func (p *Broker) geoViolation(group string, location GeoInfo) (string, bool) {
+	if _, denied := p.geoDeniedASNs[location.ASN]; denied && location.ASN != 0 {
+		return fmt.Sprintf("join from denied asn AS%d %q", location.ASN, location.Organization), true
+	}
+
+	if location.Country == "" {
+		return "", false
+	}
+
+	allowed, ok := p.geoAllowedCountries[group]
+	if !ok {
+		allowed, ok = p.geoAllowedCountries[AllGroups]
+	}
+	if !ok {
+		return "", false
+	}
+
+	if _, ok := allowed[location.Country]; !ok {
+		return fmt.Sprintf("join from country %s that is not allowed in group %q", location.Country, group), true
+	}
+	return "", false
+}
+
 func (p *Broker) handleDropped(s *econ.Server, dropped parser.ClientDropped) {
-	log.Printf("client %s dropped from server %s", dropped.IP, s.AddressPort())
+	log.Printf("client %s dropped from server %s", dropped.IP, s)
 }
 
 func (p *Broker) handleBanned(s *econ.Server, banned parser.ClientBanned) {
+	p.mu.Lock()
+	if bans, ok := p.serverBans[s.AddressPort()]; ok {
+		bans[banned.IP] = parser.BanListEntry{IP: banned.IP, Duration: banned.Duration, Reason: banned.Reason}
+	}
+	// bans after an unban take precedence
+	delete(p.tombstones, banned.IP)
+	p.mu.Unlock()
+
 	if !p.propagate {
 		return
 	}
 
-	log.Printf("propagating client %s banned on server %s", banned.IP, s.AddressPort())
+	log.Printf("propagating client %s banned on server %s", banned.IP, s)
 
-	// propagate ban to other servers
-	err := p.BanOnOthers(s.AddressPort(), banned.IP, banned.Duration, banned.Reason)
+	// propagate ban to other servers, except for servers that already have a longer ban
+	ban := parser.BanListEntry{IP: banned.IP, Duration: banned.Duration, Reason: banned.Reason}
+	err := p.propagateBan(s.AddressPort(), p.banTargets(s.AddressPort(), ban, true), banned.IP, banned.Duration, banned.Reason)
 	if err != nil {
 		log.Printf("error propagating ban to other servers: %v", err)
 	}
 }
 
 func (p *Broker) handleUnbanned(s *econ.Server, unbanned parser.ClientUnbanned) {
+	p.mu.Lock()
+	delete(p.serverBans[s.AddressPort()], unbanned.IP)
+	if p.reconcileMode != "" {
+		p.tombstones[unbanned.IP] = struct{}{}
+	}
+	p.mu.Unlock()
+
 	if !p.propagate {
 		return
 	}
 
-	log.Printf("propagating client %s unbanned on server %s", unbanned.IP, s.AddressPort())
+	log.Printf("propagating client %s unbanned on server %s", unbanned.IP, s)
 
 	// propagate unban to other servers
 	err := p.UnbanOnOthers(s.AddressPort(), unbanned.IP)
@@ -360,25 +671,170 @@ func (p *Broker) handleUnbanned(s *econ.Server, unbanned parser.ClientUnbanned)
 	}
 }
 
+// handleBanList learns about bans that were made while the banserver was not connected to the server.
+// Bans that were not known before are propagated to all other servers.
+func (p *Broker) handleBanList(s *econ.Server, list econ.BanList) {
+	p.mu.Lock()
+	_, listed := p.serverBans[s.AddressPort()]
+	current, added, ranges := diffBanList(p.serverBans[s.AddressPort()], list.Bans)
+	p.serverBans[s.AddressPort()] = current
+	p.banListedAt[s.AddressPort()] = time.Now()
+	p.mu.Unlock()
+
+	select {
+	case p.banListed <- struct{}{}:
+	default:
+	}
+
+	log.Printf("server %s has %d bans, %d of them are new", s, len(list.Bans), len(added))
+	if ranges > 0 {
+		log.Printf("%d ip range bans of server %s are not propagated", ranges, s)
+	}
+
+	// enforced reconciliation converges the ban lists on its own, propagating them as well would ban twice
+	if !p.propagate || p.reconcileMode == ReconcileEnforce {
+		return
+	}
+
+	for _, ban := range added {
+		// servers whose bans are unknown get the ban as soon as they list their bans
+		targets := p.banTargets(s.AddressPort(), ban, false)
+		if len(targets) == 0 {
+			continue
+		}
+
+		log.Printf("propagating ban of client %s listed by server %s", ban.IP, s)
+		err := p.propagateBan(s.AddressPort(), targets, ban.IP, ban.Duration, ban.Reason)
+		if err != nil {
+			log.Printf("error propagating listed ban to other servers: %v", err)
+		}
+	}
+
+	if listed {
+		return
+	}
+
+	// the first list of a server lacks the bans of the servers that listed their bans before
+	for _, missing := range p.missingBans(s.AddressPort()) {
+		log.Printf("propagating ban of client %s listed by server %s to server %s", missing.ban.IP, p.serverName(missing.source), s)
+		err := p.propagateBan(missing.source, []string{s.AddressPort()}, missing.ban.IP, missing.ban.Duration, missing.ban.Reason)
+		if err != nil {
+			log.Printf("error propagating listed ban to server %s: %v", s, err)
+		}
+	}
+}
+
+// banTargets returns the other servers of the server that do not list the ban or only list a shorter ban of the
+// same ip, so that longer and permanent bans are never replaced by shorter ones. Servers whose bans have not been
+// listed yet are only returned if unlisted is true.
+func (p *Broker) banTargets(server string, ban parser.BanListEntry, unlisted bool) []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var targets []string
+	for _, other := range p.others[server] {
+		bans, ok := p.serverBans[other]
+		if !ok && !unlisted {
+			continue
+		}
+		if listed, ok := bans[ban.IP]; ok && !longerBan(ban, listed) {
+			continue
+		}
+		targets = append(targets, other)
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+type sourcedBan struct {
+	source string
+	ban    parser.BanListEntry
+}
+
+// missingBans returns the longest bans of all other servers that the server does not list or only lists shorter.
+func (p *Broker) missingBans(server string) []sourcedBan {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	others := slices.Clone(p.others[server])
+	sort.Strings(others)
+
+	missing := make(map[netip.Addr]sourcedBan)
+	for _, other := range others {
+		for ip, ban := range p.serverBans[other] {
+			if listed, ok := p.serverBans[server][ip]; ok && !longerBan(ban, listed) {
+				continue
+			}
+			if m, ok := missing[ip]; ok && !longerBan(ban, m.ban) {
+				continue
+			}
+			missing[ip] = sourcedBan{source: other, ban: ban}
+		}
+	}
+
+	result := slices.Collect(maps.Values(missing))
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ban.IP.Less(result[j].ban.IP)
+	})
+	return result
+}
+
+// diffBanList returns the ip bans of the ban list and those that are not part of the previous ban list.
+// Range bans are counted, but not returned, as they cannot be propagated.
+func diffBanList(previous map[netip.Addr]parser.BanListEntry, list []parser.BanListEntry) (
+	current map[netip.Addr]parser.BanListEntry,
+	added []parser.BanListEntry,
+	ranges int,
+) {
+	current = make(map[netip.Addr]parser.BanListEntry, len(list))
+	for _, ban := range list {
+		if ban.IsRange() {
+			ranges++
+			continue
+		}
+
+		current[ban.IP] = ban
+		if _, ok := previous[ban.IP]; !ok {
+			added = append(added, ban)
+		}
+	}
+	return current, added, ranges
+}
+
 func (p *Broker) handleChat(s *econ.Server, chat parser.ChatMessage) {
-	for _, re := range p.chatBlockRegex {
-		if !re.MatchString(chat.Message) {
+	serverRules := p.rulesOf(s.AddressPort())
+	if chat.IsWhisper() && p.whisperTargets != nil && serverRules.IsEnabled(RuleWhisper) {
+		p.handleWhisper(s, chat)
+	}
+
+	if !serverRules.IsEnabled(RuleChatBlacklist) {
+		return
+	}
+
+	// rules of remote blacklists are replaced when they are refreshed
+	p.mu.RLock()
+	rules := p.chatRules
+	p.mu.RUnlock()
+
+	for _, rule := range rules {
+		if !rule.Matches(chat) {
 			continue
 		}
 
-		log.Printf("banning client on all servers for chat message matching '%s': %s", re.String(), chat.Message)
+		log.Printf("banning client on all servers for chat message matching '%s': %s", rule, chat.Message)
 		ip, ok := s.ClientIP(chat.ClientID)
 		if !ok {
 			log.Printf("error getting client ip for chat message: %v", chat.ClientID)
 			return
 		}
 
-		if ip == "" {
-			log.Printf("client ip is empty for chat message: %v", chat.ClientID)
+		if !ip.IsValid() {
+			log.Printf("client ip is invalid for chat message: %v", chat.ClientID)
 			return
 		}
 
-		err := p.BanOnAll(s.AddressPort(), ip, p.chatBanDuration, p.chatBanReason)
+		duration, reason := serverRules.Ban(RuleChatBlacklist, p.chatBanDuration, p.chatBanReason)
+		err := p.BanOnAll(s.AddressPort(), ip, duration, reason)
 		if err != nil {
 			log.Printf("error banning client %s for chat message: %v", ip, err)
 			return
@@ -386,3 +842,149 @@ func (p *Broker) handleChat(s *econ.Server, chat parser.ChatMessage) {
 		return
 	}
 }
+
+func (p *Broker) handleWhisper(s *econ.Server, chat parser.ChatMessage) {
+	ip, ok := s.ClientIP(chat.ClientID)
+	if !ok || !ip.IsValid() {
+		log.Printf("error getting client ip for whisper message: %v", chat.ClientID)
+		return
+	}
+
+	// whisper targets are only distinct per server
+	key := s.AddressPort() + " " + ip.String()
+	targets := p.whisperTargets.Add(key, strconv.Itoa(chat.TargetID), time.Now())
+	if targets <= p.whisperMaxTargets {
+		return
+	}
+	p.whisperTargets.Reset(key)
+
+	duration, reason := p.rulesOf(s.AddressPort()).Ban(RuleWhisper, p.whisperBanDuration, p.whisperBanReason)
+	p.punish(s, chat.ClientID, ip, p.whisperAction, duration, reason, fmt.Sprintf("whisper spam to %d clients", targets))
+}
+
+func (p *Broker) handleRconAuth(s *econ.Server, auth parser.RconAuth) {
+	ip, ok := s.ClientIP(auth.ClientID)
+	if !ok || !ip.IsValid() {
+		log.Printf("error getting client ip for rcon login: %v", auth.ClientID)
+		return
+	}
+
+	if auth.Success {
+		if p.rconFailures != nil {
+			p.rconFailures.Reset(ip.String())
+		}
+
+		if p.rconAlert && !p.isFamiliarRconIP(ip) {
+			log.Printf("ALERT: client %s logged into the rcon of server %s as %s from an unfamiliar ip", ip, s, auth.Level)
+		} else {
+			log.Printf("client %s logged into the rcon of server %s as %s", ip, s, auth.Level)
+		}
+		return
+	}
+
+	rules := p.rulesOf(s.AddressPort())
+	if p.rconBanAttempts <= 0 || !rules.IsEnabled(RuleRcon) {
+		log.Printf("client %s failed to log into the rcon of server %s", ip, s)
+		return
+	}
+
+	attempts := p.rconFailures.Add(ip.String(), time.Now())
+	log.Printf("client %s failed to log into the rcon of server %s (%d/%d)", ip, s, attempts, p.rconBanAttempts)
+	if attempts < p.rconBanAttempts {
+		return
+	}
+	p.rconFailures.Reset(ip.String())
+
+	duration, reason := rules.Ban(RuleRcon, p.rconBanDuration, p.rconBanReason)
+	err := p.BanOnAll(s.AddressPort(), ip, duration, reason)
+	if err != nil {
+		log.Printf("error banning client %s for failed rcon logins: %v", ip, err)
+		return
+	}
+}
+
+// isFamiliarRconIP returns true in case the ip is trusted or has logged in before.
+// The ip is remembered as familiar for any subsequent calls.
+func (p *Broker) isFamiliarRconIP(ip netip.Addr) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.rconFamiliar[ip]; ok {
+		return true
+	}
+	p.rconFamiliar[ip] = struct{}{}
+
+	return containsIP(p.rconTrusted, ip)
+}
+
+func (p *Broker) handleVoteCalled(s *econ.Server, vote parser.VoteCalled) {
+	log.Printf("client %d called %s vote '%s' on server %s", vote.ClientID, vote.Type, vote.Value, s)
+
+	rules := p.rulesOf(s.AddressPort())
+	if p.votes == nil || !rules.IsEnabled(RuleVote) {
+		return
+	}
+
+	ip, ok := s.ClientIP(vote.ClientID)
+	if !ok || !ip.IsValid() {
+		log.Printf("error getting client ip for vote: %v", vote.ClientID)
+		return
+	}
+
+	duration, reason := rules.Ban(RuleVote, p.voteBanDuration, p.voteBanReason)
+	now := time.Now()
+	votes := p.votes.Add(ip.String(), now)
+	if p.voteMax > 0 && votes > p.voteMax {
+		p.votes.Reset(ip.String())
+		p.punish(s, vote.ClientID, ip, p.voteAction, duration, reason, fmt.Sprintf("vote abuse, called %d votes", votes))
+		return
+	}
+
+	target, ok := vote.KickTarget()
+	if !ok || p.voteKickMax <= 0 {
+		return
+	}
+
+	// kick votes may either target a client id or an ip
+	if id, err := strconv.Atoi(target); err == nil {
+		if targetIP, ok := s.ClientIP(id); ok && targetIP.IsValid() {
+			target = targetIP.String()
+		}
+	} else if targetIP, err := parser.ParseIP(target); err == nil {
+		target = targetIP.String()
+	}
+
+	key := ip.String() + " " + target
+	kickVotes := p.kickVotes.Add(key, now)
+	if kickVotes > p.voteKickMax {
+		p.kickVotes.Reset(key)
+		p.punish(s, vote.ClientID, ip, p.voteAction, duration, reason, fmt.Sprintf("vote abuse, called %d kick votes against %s", kickVotes, target))
+	}
+}
+
+// punish applies the action (log, kick or ban) to the client on the given server.
+func (p *Broker) punish(s *econ.Server, clientID int, ip netip.Addr, action string, banDuration time.Duration, reason, abuse string) {
+	var err error
+	switch action {
+	case ActionKick:
+		log.Printf("kicking client %s from server %s for %s", ip, s, abuse)
+		err = s.Kick(clientID, reason)
+	case ActionBan:
+		log.Printf("banning client %s on server %s for %s", ip, s, abuse)
+		err = p.ban(s, s.AddressPort(), ip, banDuration, reason)
+	default:
+		log.Printf("detected %s of client %s on server %s", abuse, ip, s)
+	}
+
+	if err != nil {
+		log.Printf("error punishing %s of client %s on server %s: %v", abuse, ip, s, err)
+	}
+}
+
+func (p *Broker) handleVoteResult(s *econ.Server, result parser.VoteResult) {
+	if result.Passed {
+		log.Printf("vote passed on server %s", s)
+	} else {
+		log.Printf("vote failed on server %s", s)
+	}
+}
@@ -0,0 +1,277 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jxsl13/banserver/econ"
+	"github.com/jxsl13/banserver/econ/econtest"
+	"github.com/jxsl13/banserver/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeoViolation(t *testing.T) {
+	p := NewBroker(false, time.Hour, "permaban", time.Hour, "chat ban",
+		WithGeoIP(&GeoIP{}, []uint{16276}, map[string][]string{
+			"eu":      {"de", "AT"},
+			AllGroups: {"US"},
+		}, ActionBan, time.Hour, "location"),
+	)
+
+	tests := []struct {
+		name     string
+		group    string
+		location GeoInfo
+		want     bool
+	}{
+		{"allowed country", "eu", GeoInfo{Country: "DE"}, false},
+		{"lowercase allowlist", "eu", GeoInfo{Country: "AT", ASN: 24940}, false},
+		{"country not allowed in group", "eu", GeoInfo{Country: "US"}, true},
+		{"fallback allowlist", "na", GeoInfo{Country: "US"}, false},
+		{"country not allowed in fallback", "", GeoInfo{Country: "DE"}, true},
+		{"denied asn", "eu", GeoInfo{Country: "DE", ASN: 16276, Organization: "OVH SAS"}, true},
+		{"unknown location", "eu", GeoInfo{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violation, denied := p.geoViolation(tt.group, tt.location)
+			assert.Equal(t, tt.want, denied)
+			assert.Equal(t, tt.want, violation != "")
+		})
+	}
+}
+
+func TestDiffBanList(t *testing.T) {
+	ban := func(ip string, minutes int) parser.BanListEntry {
+		return parser.BanListEntry{IP: netip.MustParseAddr(ip), Duration: time.Duration(minutes) * time.Minute, Reason: "test"}
+	}
+	rangeBan := parser.BanListEntry{IP: netip.MustParseAddr("1.2.3.0"), LastIP: netip.MustParseAddr("1.2.3.255")}
+
+	current, added, ranges := diffBanList(nil, []parser.BanListEntry{ban("1.1.1.1", 5), ban("2.2.2.2", 0), rangeBan})
+	assert.Len(t, current, 2)
+	assert.Equal(t, []parser.BanListEntry{ban("1.1.1.1", 5), ban("2.2.2.2", 0)}, added)
+	assert.Equal(t, 1, ranges)
+
+	// only bans that were not listed before are new
+	current, added, ranges = diffBanList(current, []parser.BanListEntry{ban("2.2.2.2", 0), ban("3.3.3.3", 10)})
+	assert.Equal(t, map[netip.Addr]parser.BanListEntry{
+		netip.MustParseAddr("2.2.2.2"): ban("2.2.2.2", 0),
+		netip.MustParseAddr("3.3.3.3"): ban("3.3.3.3", 10),
+	}, current)
+	assert.Equal(t, []parser.BanListEntry{ban("3.3.3.3", 10)}, added)
+	assert.Equal(t, 0, ranges)
+}
+
+func TestOriginReason(t *testing.T) {
+	tests := []struct {
+		origin string
+		reason string
+		want   string
+	}{
+		{"DDNet Novice #1", "spam", "banned on DDNet Novice #1: spam"},
+		{"127.0.0.1:8303", "", "banned on 127.0.0.1:8303"},
+		{"DDNet Novice #2", "banned on DDNet Novice #1: spam", "banned on DDNet Novice #1: spam"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, originReason(tt.origin, tt.reason))
+	}
+}
+
+func TestReloadBlacklists(t *testing.T) {
+	dir := t.TempDir()
+	ipFile := filepath.Join(dir, "ip.txt")
+	chatFile := filepath.Join(dir, "chat.txt")
+	require.NoError(t, os.WriteFile(ipFile, []byte("1.2.3.4\n"), 0o600))
+	require.NoError(t, os.WriteFile(chatFile, []byte("spam\n"), 0o600))
+
+	p := NewBroker(false, time.Hour, "perma", time.Hour, "chat")
+	require.NoError(t, p.AddBlacklistCIDRFile(context.Background(), ipFile))
+	require.NoError(t, p.AddBlacklistChatFile(context.Background(), chatFile))
+
+	require.NoError(t, os.WriteFile(ipFile, []byte("5.6.7.8\n"), 0o600))
+	require.NoError(t, os.WriteFile(chatFile, []byte("scam\n"), 0o600))
+	require.NoError(t, p.ReloadBlacklists(context.Background()))
+
+	for ip, want := range map[string]int{"1.2.3.4": 0, "5.6.7.8": 1} {
+		matches, err := p.MatchIP(netip.MustParseAddr(ip))
+		require.NoError(t, err)
+		assert.Len(t, matches, want, ip)
+	}
+	assert.Empty(t, p.MatchChat(parser.ChatMessage{TargetID: parser.ChatTargetPublic, Message: "spam"}))
+	assert.Len(t, p.MatchChat(parser.ChatMessage{TargetID: parser.ChatTargetPublic, Message: "scam"}), 1)
+
+	// invalid blacklists keep their previous entries
+	require.NoError(t, os.WriteFile(chatFile, []byte("(unclosed\n"), 0o600))
+	require.Error(t, p.ReloadBlacklists(context.Background()))
+	assert.Len(t, p.MatchChat(parser.ChatMessage{TargetID: parser.ChatTargetPublic, Message: "scam"}), 1)
+}
+
+func TestHandleBanList(t *testing.T) {
+	p := NewBroker(true, time.Hour, "permaban", time.Hour, "chat ban")
+	_, connA := addFakeServer(t, p, "127.0.0.1:8303")
+	_, connB := addFakeServer(t, p, "127.0.0.1:8304")
+
+	// the bans of the other server are unknown
+	connB.Log(
+		ddnetLine("net_ban", "#0 '1.1.1.1' banned for life (cheating)"),
+		ddnetLine("net_ban", "#1 '3.3.3.3' banned for 10 minutes (spam)"),
+		ddnetLine("net_ban", "2 bans, showing entries 0 - 1"),
+	)
+	requireNoCommand(t, connA)
+
+	connA.Log(
+		ddnetLine("net_ban", "#0 '1.1.1.1' banned for 30 minutes (spam)"),
+		ddnetLine("net_ban", "#1 '2.2.2.2' banned for 60 minutes (spam)"),
+		ddnetLine("net_ban", "#2 '3.3.3.3' banned for 10 minutes (spam)"),
+		ddnetLine("net_ban", "3 bans, showing entries 0 - 2"),
+	)
+
+	// the permanent ban is not shortened, equal bans are not sent again
+	assert.Equal(t, `ban 2.2.2.2 60 "spam"`, connB.Command(t))
+	requireNoCommand(t, connB)
+
+	// the first list of a server receives the longer bans of the other servers
+	assert.Equal(t, `ban 1.1.1.1 0 "cheating"`, connA.Command(t))
+	requireNoCommand(t, connA)
+
+	// the next list only propagates new bans
+	connA.Log(
+		ddnetLine("net_ban", "#0 '1.1.1.1' banned for life (cheating)"),
+		ddnetLine("net_ban", "#1 '4.4.4.4' banned for 5 minutes (spam)"),
+		ddnetLine("net_ban", "2 bans, showing entries 0 - 1"),
+	)
+	assert.Equal(t, `ban 4.4.4.4 5 "spam"`, connB.Command(t))
+	requireNoCommand(t, connB)
+	requireNoCommand(t, connA)
+}
+
+func TestUnbanOnAll(t *testing.T) {
+	p := NewBroker(true, time.Hour, "permaban", time.Hour, "chat ban")
+	a, connA := addFakeServer(t, p, "127.0.0.1:8303")
+	b, connB := addFakeServer(t, p, "127.0.0.1:8304")
+
+	ip := netip.MustParseAddr("1.1.1.1")
+	require.NoError(t, p.UnbanOnAll("", ip))
+	assert.Equal(t, "unban 1.1.1.1", connA.Command(t))
+	assert.Equal(t, "unban 1.1.1.1", connB.Command(t))
+
+	// the unbans of the other servers are not propagated back
+	assert.True(t, a.IsIgnoredUnbanPropagation(b.AddressPort(), ip))
+	assert.True(t, b.IsIgnoredUnbanPropagation(a.AddressPort(), ip))
+	assert.False(t, a.IsIgnoredBanPropagation(b.AddressPort(), ip))
+	assert.False(t, b.IsIgnoredBanPropagation(a.AddressPort(), ip))
+}
+
+func TestAddServerImmediateBanList(t *testing.T) {
+	p := NewBroker(true, time.Hour, "permaban", time.Hour, "chat ban")
+	_, connA := addFakeServer(t, p, "127.0.0.1:8303")
+	connA.Log(
+		ddnetLine("net_ban", "#0 '1.1.1.1' banned for life (cheating)"),
+		ddnetLine("net_ban", "1 bans, showing entries 0 - 0"),
+	)
+	requireNoCommand(t, connA)
+
+	// the server answers the bans command right away
+	connB := econtest.NewConn()
+	go func() {
+		if <-connB.Commands() == "bans" {
+			connB.Log(
+				ddnetLine("net_ban", "#0 '2.2.2.2' banned for 60 minutes (spam)"),
+				ddnetLine("net_ban", "1 bans, showing entries 0 - 0"),
+			)
+		}
+	}()
+	b := econ.NewServer(context.Background(), "127.0.0.1:8304", connB, p.dispatch, econ.WithFlavor(parser.FlavorDDNet))
+	t.Cleanup(func() {
+		require.NoError(t, b.Close())
+	})
+	p.addServer(b)
+
+	// the listed ban is propagated and the missing ban is pulled
+	assert.Equal(t, `ban 2.2.2.2 60 "spam"`, connA.Command(t))
+	assert.Equal(t, `ban 1.1.1.1 0 "cheating"`, connB.Command(t))
+}
+
+func TestUnbanOnOthers(t *testing.T) {
+	p := NewBroker(true, time.Hour, "permaban", time.Hour, "chat ban")
+	a, connA := addFakeServer(t, p, "127.0.0.1:8303")
+	b, connB := addFakeServer(t, p, "127.0.0.1:8304")
+
+	ip := netip.MustParseAddr("1.1.1.1")
+	require.NoError(t, p.UnbanOnOthers(a.AddressPort(), ip))
+	assert.Equal(t, "unban 1.1.1.1", connB.Command(t))
+	requireNoCommand(t, connA)
+
+	// the resulting unban is not propagated back
+	assert.True(t, a.IsIgnoredUnbanPropagation(b.AddressPort(), ip))
+	assert.Error(t, p.UnbanOnOthers("127.0.0.1:8305", ip))
+}
+
+func TestUnbanOnAllConcurrent(t *testing.T) {
+	p := NewBroker(true, time.Hour, "permaban", time.Hour, "chat ban")
+	ip := netip.MustParseAddr("1.1.1.1")
+
+	// servers reconnect, which replaces and closes the previous connection, while the ip is unbanned on all servers
+	stop := make(chan struct{})
+	connected := make(chan struct{})
+	go func() {
+		defer close(connected)
+		previous := make(map[string]*econ.Server)
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+
+			conn := econtest.NewConn()
+			go func() {
+				for {
+					select {
+					case <-conn.Commands():
+					case <-stop:
+						return
+					}
+				}
+			}()
+
+			addrPort := fmt.Sprintf("127.0.0.1:%d", 8303+i%8)
+			s := econ.NewServer(context.Background(), addrPort, conn, p.dispatch, econ.WithFlavor(parser.FlavorDDNet))
+			p.addServer(s)
+			if old, ok := previous[addrPort]; ok {
+				_ = old.Close()
+			}
+			previous[addrPort] = s
+		}
+	}()
+
+	require.Eventually(t, func() bool {
+		return len(p.Servers()) == 8
+	}, time.Second, time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 500; i++ {
+			// unbans on just closed servers fail
+			_ = p.UnbanOnAll("", ip)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("unbanning on all servers deadlocked")
+	}
+	close(stop)
+	<-connected
+	require.NoError(t, p.Close())
+}
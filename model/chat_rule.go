@@ -0,0 +1,130 @@
+package model
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/jxsl13/banserver/parser"
+)
+
+// chat rule scopes, a rule line may be prefixed with a scope, e.g. '@whisper https?://bot.xyz'
+const (
+	ChatScopeAll     = "all"
+	ChatScopePublic  = "public"
+	ChatScopeTeam    = "team"
+	ChatScopeWhisper = "whisper"
+)
+
+type chatRule struct {
+	scope  string
+	re     *regexp.Regexp
+	source string // blacklist the rule was loaded from
+	line   int
+}
+
+// ValidateChatBlacklist returns the number of valid rules of a chat blacklist.
+// Every invalid line is returned as joined *LineError.
+func ValidateChatBlacklist(r io.Reader) (rules int, err error) {
+	list, err := parseChatBlacklist(r)
+	return len(list), err
+}
+
+// parseChatBlacklist parses a chat blacklist with one regular expression per line, lines starting with # are comments.
+// Duplicate lines are skipped. All valid rules are returned, invalid lines are returned as joined *LineError.
+func parseChatBlacklist(r io.Reader) ([]chatRule, error) {
+	var (
+		errs         []error
+		lineNum      int
+		list         = make([]chatRule, 0)
+		deduplicated = make(map[string]struct{})
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if _, ok := deduplicated[line]; ok {
+			continue
+		}
+		deduplicated[line] = struct{}{}
+
+		rule, err := parseChatRule(line)
+		if err != nil {
+			errs = append(errs, &LineError{Line: lineNum, Text: line, Err: err})
+			continue
+		}
+		rule.line = lineNum
+		list = append(list, rule)
+	}
+
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to read chat blacklist: %w", err))
+	}
+	return list, errors.Join(errs...)
+}
+
+func parseChatRule(line string) (chatRule, error) {
+	scope := ChatScopeAll
+	if strings.HasPrefix(line, "@") {
+		prefix, expr, found := strings.Cut(line, " ")
+		if !found {
+			return chatRule{}, fmt.Errorf("missing regular expression after scope %s", prefix)
+		}
+
+		scope = strings.TrimPrefix(prefix, "@")
+		switch scope {
+		case ChatScopeAll, ChatScopePublic, ChatScopeTeam, ChatScopeWhisper:
+		default:
+			return chatRule{}, fmt.Errorf("invalid chat scope %q, must be one of @all, @public, @team or @whisper", prefix)
+		}
+		line = strings.TrimSpace(expr)
+	}
+
+	re, err := regexp.Compile(line)
+	if err != nil {
+		return chatRule{}, err
+	}
+
+	return chatRule{
+		scope: scope,
+		re:    re,
+	}, nil
+}
+
+func (r chatRule) String() string {
+	if r.scope == ChatScopeAll {
+		return r.re.String()
+	}
+	return "@" + r.scope + " " + r.re.String()
+}
+
+func (r chatRule) Matches(chat parser.ChatMessage) bool {
+	switch r.scope {
+	case ChatScopePublic:
+		if !chat.IsPublic() {
+			return false
+		}
+	case ChatScopeTeam:
+		if !chat.IsTeam() {
+			return false
+		}
+	case ChatScopeWhisper:
+		if !chat.IsWhisper() {
+			return false
+		}
+	}
+
+	return r.re.MatchString(chat.Message)
+}
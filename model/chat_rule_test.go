@@ -0,0 +1,71 @@
+package model_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jxsl13/banserver/model"
+	"github.com/jxsl13/banserver/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateChatBlacklist(t *testing.T) {
+	blacklist := strings.Join([]string{
+		"# comment",
+		`https?://bot\.xyz`,
+		"(unclosed",
+		"",
+		"@whisper discord\\.gg",
+		`https?://bot\.xyz`,
+		"@private spam",
+		"@team",
+	}, "\n")
+
+	rules, err := model.ValidateChatBlacklist(strings.NewReader(blacklist))
+	assert.Equal(t, 2, rules)
+	require.Error(t, err)
+
+	var lines []int
+	for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var lineErr *model.LineError
+		require.True(t, errors.As(err, &lineErr))
+		lines = append(lines, lineErr.Line)
+	}
+	assert.Equal(t, []int{3, 7, 8}, lines)
+
+	rules, err = model.ValidateChatBlacklist(strings.NewReader("spam\n@public eggs"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, rules)
+}
+
+func TestMatchChat(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "chat.txt")
+	require.NoError(t, os.WriteFile(file, []byte(strings.Join([]string{
+		"# comment",
+		`(?i)https?://bot\.xyz`,
+		`@whisper discord\.gg`,
+		`bot`,
+	}, "\n")), 0o600))
+
+	p := model.NewBroker(false, time.Hour, "perma", time.Hour, "chat")
+	require.NoError(t, p.AddBlacklistChatFile(context.Background(), file))
+
+	public := parser.ChatMessage{TargetID: parser.ChatTargetPublic, Message: "visit HTTPS://bot.xyz and discord.gg"}
+	assert.Equal(t, []model.ChatMatch{
+		{Rule: `(?i)https?://bot\.xyz`, Source: file, Line: 2},
+		{Rule: `bot`, Source: file, Line: 4},
+	}, p.MatchChat(public))
+
+	whisper := parser.ChatMessage{TargetID: 3, Message: "join discord.gg"}
+	matches := p.MatchChat(whisper)
+	require.Len(t, matches, 1)
+	assert.Equal(t, `@whisper discord\.gg (`+file+`:3)`, matches[0].String())
+
+	assert.Empty(t, p.MatchChat(parser.ChatMessage{TargetID: parser.ChatTargetTeam, Message: "hello"}))
+}
@@ -1,42 +1,126 @@
 package model
 
 import (
-	"net"
-	"regexp"
-)
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
 
-var (
-	cidrRegex = regexp.MustCompile(`^\s*([0-9.:a-fA-F\-\/]+)\s*.*$`)
+	"github.com/jxsl13/banserver/parser"
 )
 
+// parseCIDRs parses a single ip, a CIDR range (1.2.3.0/24), a start-end range (1.2.3.4-1.2.3.200)
+// or an ipv4 wildcard (1.2.3.*) and returns the minimal set of prefixes that covers it.
+// IPv4-mapped ipv6 addresses are converted to ipv4, so that they match the ips of the parser.
+func parseCIDRs(s string) ([]netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case strings.Contains(s, "*"):
+		prefix, err := parseWildcard(s)
+		if err != nil {
+			return nil, err
+		}
+		return []netip.Prefix{prefix}, nil
+	case strings.Contains(s, "-"):
+		first, last, _ := strings.Cut(s, "-")
+		return parseRange(strings.TrimSpace(first), strings.TrimSpace(last))
+	default:
+		prefix, err := parseCIDR(s)
+		if err != nil {
+			return nil, err
+		}
+		return []netip.Prefix{prefix}, nil
+	}
+}
+
 // allow single ip addresses and CIDR ranges
-func parseCIDR(line string) (_ *net.IPNet, ok bool) {
-	matches := cidrRegex.FindStringSubmatch(line)
-	if len(matches) == 0 {
-		return nil, false
+func parseCIDR(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(strings.NewReplacer("[", "", "]", "").Replace(s))
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		if prefix.Addr().Is4In6() && prefix.Bits() >= 96 {
+			return netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96).Masked(), nil
+		}
+		return prefix.Masked(), nil
 	}
 
-	_, cidr, err := net.ParseCIDR(matches[1])
-	if err == nil {
-		return cidr, true
+	ip, err := parser.ParseIP(s)
+	if err != nil {
+		return netip.Prefix{}, err
 	}
+	return netip.PrefixFrom(ip, ip.BitLen()), nil
+}
 
-	ip := net.ParseIP(matches[1])
-	if ip == nil {
-		return nil, false
+// parseWildcard parses ipv4 addresses with trailing wildcard octets, e.g. 1.2.*.*
+func parseWildcard(s string) (netip.Prefix, error) {
+	octets := strings.Split(s, ".")
+	if len(octets) != 4 {
+		return netip.Prefix{}, fmt.Errorf("invalid wildcard %q: expected four octets", s)
 	}
 
-	var mask net.IPMask
-	if ip.To4() != nil {
-		// IPv4 /32 single ip msk
-		mask = net.CIDRMask(32, 32)
-	} else {
-		// IPv6 - /128 single ip mask
-		mask = net.CIDRMask(128, 128)
+	var (
+		addr [4]byte
+		bits int
+	)
+	for i, octet := range octets {
+		if octet == "*" {
+			continue
+		}
+		if bits != i*8 {
+			return netip.Prefix{}, fmt.Errorf("invalid wildcard %q: only trailing octets may be wildcards", s)
+		}
+
+		v, err := strconv.ParseUint(octet, 10, 8)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid wildcard %q: invalid octet %q", s, octet)
+		}
+		addr[i] = byte(v)
+		bits += 8
 	}
+	return netip.PrefixFrom(netip.AddrFrom4(addr), bits), nil
+}
 
-	return &net.IPNet{
-		IP:   ip,
-		Mask: mask,
-	}, true
+// parseRange returns the minimal set of prefixes that covers all ips from first to last (inclusive).
+func parseRange(first, last string) ([]netip.Prefix, error) {
+	start, err := parser.ParseIP(first)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parser.ParseIP(last)
+	if err != nil {
+		return nil, err
+	}
+
+	if start.Is4() != end.Is4() {
+		return nil, fmt.Errorf("invalid range %s-%s: mixed ipv4 and ipv6", start, end)
+	}
+	if end.Less(start) {
+		return nil, fmt.Errorf("invalid range %s-%s: start is after end", start, end)
+	}
+	return rangeToPrefixes(start, end), nil
+}
+
+func rangeToPrefixes(start, end netip.Addr) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for {
+		// the biggest prefix that starts at start and does not exceed end
+		prefix := netip.PrefixFrom(start, start.BitLen())
+		for bits := 0; bits < start.BitLen(); bits++ {
+			p := netip.PrefixFrom(start, bits)
+			if p.Masked().Addr() == start && !end.Less(parser.LastAddr(p)) {
+				prefix = p
+				break
+			}
+		}
+		prefixes = append(prefixes, prefix)
+
+		last := parser.LastAddr(prefix)
+		if last == end {
+			return prefixes
+		}
+		start = last.Next()
+	}
 }
+
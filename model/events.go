@@ -0,0 +1,98 @@
+package model
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jxsl13/banserver/econ"
+	"github.com/jxsl13/banserver/parser"
+)
+
+// Event is a parsed log line of a game server or an event of the banserver itself, e.g. BanFailed.
+type Event struct {
+	Time   time.Time    `json:"time"`
+	Server string       `json:"server"` // name of the server
+	Type   string       `json:"type"`   // type of the event, e.g. ChatMessage
+	Data   parser.Event `json:"data"`
+}
+
+// dispatch passes the event to all handlers and to all event subscribers.
+func (p *Broker) dispatch(s *econ.Server, event parser.Event) {
+	p.dispatcher.Dispatch(s, event)
+	p.publish(s, event)
+}
+
+// publish passes the event to all event subscribers, e.g. events of the banserver itself like BanFailed.
+func (p *Broker) publish(s *econ.Server, event parser.Event) {
+	p.subMu.Lock()
+	defer p.subMu.Unlock()
+	if len(p.subscribers) == 0 {
+		return
+	}
+
+	e := Event{
+		Time:   time.Now(),
+		Server: s.Name(),
+		Type:   reflect.TypeOf(event).Name(),
+		Data:   event,
+	}
+	for ch := range p.subscribers {
+		// slow subscribers miss events instead of blocking the game servers
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// SubscribeEvents returns a channel that receives the events of all game servers until unsubscribe is called.
+// Events are dropped in case that the channel buffer is full.
+func (p *Broker) SubscribeEvents(buffer int) (events <-chan Event, unsubscribe func()) {
+	ch := make(chan Event, buffer)
+
+	p.subMu.Lock()
+	p.subscribers[ch] = struct{}{}
+	p.subMu.Unlock()
+
+	return ch, func() {
+		p.subMu.Lock()
+		defer p.subMu.Unlock()
+		if _, ok := p.subscribers[ch]; ok {
+			delete(p.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Servers returns all connected game servers ordered by their names.
+func (p *Broker) Servers() []*econ.Server {
+	p.mu.RLock()
+	servers := make([]*econ.Server, 0, len(p.serverMap))
+	for _, s := range p.serverMap {
+		servers = append(servers, s)
+	}
+	p.mu.RUnlock()
+
+	sort.Slice(servers, func(i, j int) bool {
+		return servers[i].Name() < servers[j].Name()
+	})
+	return servers
+}
+
+// Server returns the game server with the given name or address, names are case insensitive.
+func (p *Broker) Server(nameOrAddr string) (*econ.Server, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if s, ok := p.serverMap[nameOrAddr]; ok {
+		return s, true
+	}
+	for _, s := range p.serverMap {
+		if strings.EqualFold(s.Name(), nameOrAddr) {
+			return s, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jxsl13/banserver/econ"
+	"github.com/jxsl13/banserver/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscribeEvents(t *testing.T) {
+	p := NewBroker(false, time.Hour, "perma", time.Hour, "chat")
+	s := &econ.Server{}
+
+	// no subscribers
+	p.dispatch(s, parser.ClientUnbanned{})
+
+	events, unsubscribe := p.SubscribeEvents(1)
+	chat := parser.ChatMessage{ClientID: 1, TargetID: parser.ChatTargetPublic, Nickname: "nameless tee", Message: "hi"}
+	p.dispatch(s, chat)
+	// the buffer is full, the event is dropped
+	p.dispatch(s, parser.ClientUnbanned{})
+
+	event := <-events
+	assert.Equal(t, "ChatMessage", event.Type)
+	assert.Equal(t, chat, event.Data)
+	assert.False(t, event.Time.IsZero())
+
+	unsubscribe()
+	_, ok := <-events
+	require.False(t, ok)
+	unsubscribe()
+
+	p.dispatch(s, chat)
+}
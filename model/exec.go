@@ -0,0 +1,79 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jxsl13/banserver/econ"
+)
+
+// ExecResult is the output of a console command on a single game server.
+type ExecResult struct {
+	Server string   `json:"server"`
+	Lines  []string `json:"lines"`
+	Error  string   `json:"error,omitempty"`
+}
+
+// SelectServers returns the game servers that match any of the selectors, which are either
+// server names, addresses or groups. No selectors select all servers.
+func (p *Broker) SelectServers(selectors ...string) ([]*econ.Server, error) {
+	servers := p.Servers()
+	if len(selectors) == 0 {
+		return servers, nil
+	}
+
+	selected := make(map[*econ.Server]struct{}, len(servers))
+	for _, selector := range selectors {
+		if s, ok := p.Server(selector); ok {
+			selected[s] = struct{}{}
+			continue
+		}
+
+		found := false
+		for _, s := range servers {
+			if s.Group() != "" && strings.EqualFold(s.Group(), selector) {
+				selected[s] = struct{}{}
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown server or group %q", selector)
+		}
+	}
+
+	result := make([]*econ.Server, 0, len(selected))
+	for _, s := range servers {
+		if _, ok := selected[s]; ok {
+			result = append(result, s)
+		}
+	}
+	return result, nil
+}
+
+// Exec sends a console command to the given servers at once and collects the output of every
+// server until the timeout expired. The results are in the order of the servers.
+func (p *Broker) Exec(ctx context.Context, servers []*econ.Server, command string, timeout time.Duration) []ExecResult {
+	results := make([]ExecResult, len(servers))
+
+	var wg sync.WaitGroup
+	wg.Add(len(servers))
+	for i, s := range servers {
+		go func(i int, s *econ.Server) {
+			defer wg.Done()
+
+			lines, err := s.Exec(ctx, command, timeout)
+			results[i] = ExecResult{Server: s.Name(), Lines: lines}
+			if err != nil {
+				results[i].Error = err.Error()
+			}
+		}(i, s)
+	}
+	wg.Wait()
+
+	log.Printf("executed command %q on %d servers", command, len(servers))
+	return results
+}
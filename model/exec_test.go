@@ -0,0 +1,70 @@
+package model
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jxsl13/banserver/econ"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectServers(t *testing.T) {
+	p := NewBroker(false, time.Hour, "perma", time.Hour, "chat")
+
+	servers, err := p.SelectServers()
+	require.NoError(t, err)
+	assert.Empty(t, servers)
+
+	eu1, _ := addFakeServer(t, p, "127.0.0.1:8303", econ.WithName("EU #1"), econ.WithGroup("eu"))
+	eu2, _ := addFakeServer(t, p, "127.0.0.1:8304", econ.WithName("EU #2"), econ.WithGroup("eu"))
+	us1, _ := addFakeServer(t, p, "127.0.0.1:8305", econ.WithName("US #1"), econ.WithGroup("us"))
+
+	tests := []struct {
+		name      string
+		selectors []string
+		want      []*econ.Server
+		wantErr   string
+	}{
+		{name: "all", want: []*econ.Server{eu1, eu2, us1}},
+		{name: "name", selectors: []string{"eu #2"}, want: []*econ.Server{eu2}},
+		{name: "address", selectors: []string{"127.0.0.1:8305"}, want: []*econ.Server{us1}},
+		{name: "group", selectors: []string{"EU"}, want: []*econ.Server{eu1, eu2}},
+		{name: "overlapping", selectors: []string{"us", "eu #1", "EU #1"}, want: []*econ.Server{eu1, us1}},
+		{name: "unknown", selectors: []string{"eu", "asia"}, wantErr: `unknown server or group "asia"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := p.SelectServers(tt.selectors...)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestExec(t *testing.T) {
+	p := NewBroker(false, time.Hour, "perma", time.Hour, "chat")
+	assert.Empty(t, p.Exec(context.Background(), nil, "status", time.Millisecond))
+
+	a, connA := addFakeServer(t, p, "127.0.0.1:8303", econ.WithName("a"))
+	b, _ := addFakeServer(t, p, "127.0.0.1:8304", econ.WithName("b"))
+	require.NoError(t, b.Close())
+
+	go func() {
+		assert.Equal(t, "sv_motd", <-connA.Commands())
+		connA.Log(ddnetLine("console", "Value: welcome"))
+	}()
+
+	results := p.Exec(context.Background(), []*econ.Server{a, b}, "sv_motd", 200*time.Millisecond)
+	require.Len(t, results, 2)
+	assert.Equal(t, ExecResult{Server: "a", Lines: []string{ddnetLine("console", "Value: welcome")}}, results[0])
+	assert.Equal(t, "b", results[1].Server)
+	assert.Empty(t, results[1].Lines)
+	assert.Contains(t, results[1].Error, econ.ErrClosed.Error())
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jxsl13/banserver/econ"
+	"github.com/jxsl13/banserver/econ/econtest"
+	"github.com/jxsl13/banserver/parser"
+	"github.com/stretchr/testify/require"
+)
+
+// addFakeServer connects a fake ddnet game server to the broker.
+func addFakeServer(t *testing.T, p *Broker, addrPort string, opts ...econ.Option) (*econ.Server, *econtest.Conn) {
+	t.Helper()
+	conn := econtest.NewConn()
+	opts = append([]econ.Option{econ.WithFlavor(parser.FlavorDDNet)}, opts...)
+	s := econ.NewServer(context.Background(), addrPort, conn, p.dispatch, opts...)
+	t.Cleanup(func() {
+		require.NoError(t, s.Close())
+	})
+
+	// the ban list is requested as soon as the server is registered
+	p.addServer(s)
+	require.Equal(t, "bans", conn.Command(t))
+	return s, conn
+}
+
+func ddnetLine(system, message string) string {
+	return fmt.Sprintf("2024-11-25 01:12:00 I %s: %s", system, message)
+}
+
+// requireNoCommand fails in case that any command is sent to the fake game server within a short time.
+func requireNoCommand(t *testing.T, conn *econtest.Conn) {
+	t.Helper()
+	select {
+	case command := <-conn.Commands():
+		t.Fatalf("unexpected command %q", command)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
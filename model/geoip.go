@@ -0,0 +1,136 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// GeoIP resolves the country and the autonomous system of ips with local MaxMind-format (.mmdb) databases,
+// e.g. GeoLite2-Country and GeoLite2-ASN. City databases can be used instead of country databases.
+type GeoIP struct {
+	country *maxminddb.Reader
+	asn     *maxminddb.Reader
+}
+
+// OpenGeoIP opens the given databases, an empty path disables the corresponding lookup.
+func OpenGeoIP(countryDB, asnDB string) (_ *GeoIP, err error) {
+	g := &GeoIP{}
+	defer func() {
+		if err != nil {
+			err = errors.Join(err, g.Close())
+		}
+	}()
+
+	if countryDB != "" {
+		g.country, err = maxminddb.Open(countryDB)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open country database %s: %w", countryDB, err)
+		}
+	}
+
+	if asnDB != "" {
+		g.asn, err = maxminddb.Open(asnDB)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open asn database %s: %w", asnDB, err)
+		}
+	}
+	return g, nil
+}
+
+func (g *GeoIP) Close() error {
+	var err error
+	if g.country != nil {
+		err = errors.Join(err, g.country.Close())
+	}
+	if g.asn != nil {
+		err = errors.Join(err, g.asn.Close())
+	}
+	return err
+}
+
+// GeoInfo is the result of a lookup, fields are empty in case they are unknown.
+type GeoInfo struct {
+	Country      string `json:"country,omitempty"` // ISO 3166-1 alpha-2 code, e.g. DE
+	ASN          uint   `json:"asn,omitempty"`
+	Organization string `json:"organization,omitempty"`
+}
+
+func (i GeoInfo) String() string {
+	parts := make([]string, 0, 3)
+	if i.Country != "" {
+		parts = append(parts, "country="+i.Country)
+	}
+	if i.ASN != 0 {
+		parts = append(parts, fmt.Sprintf("asn=AS%d", i.ASN))
+	}
+	if i.Organization != "" {
+		parts = append(parts, strconv.Quote(i.Organization))
+	}
+	if len(parts) == 0 {
+		return "unknown location"
+	}
+	return strings.Join(parts, " ")
+}
+
+type countryRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	// used in case that the location of the ip is unknown
+	RegisteredCountry struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"registered_country"`
+}
+
+type asnRecord struct {
+	Number       uint   `maxminddb:"autonomous_system_number"`
+	Organization string `maxminddb:"autonomous_system_organization"`
+}
+
+// Lookup returns everything that is known about the ip.
+func (g *GeoIP) Lookup(ip netip.Addr) (info GeoInfo, err error) {
+	netIP := net.IP(ip.Unmap().AsSlice())
+
+	if g.country != nil {
+		var record countryRecord
+		err = g.country.Lookup(netIP, &record)
+		if err != nil {
+			return info, fmt.Errorf("failed to look up country of %s: %w", ip, err)
+		}
+		info.Country = record.Country.ISOCode
+		if info.Country == "" {
+			info.Country = record.RegisteredCountry.ISOCode
+		}
+	}
+
+	if g.asn != nil {
+		var record asnRecord
+		err = g.asn.Lookup(netIP, &record)
+		if err != nil {
+			return info, fmt.Errorf("failed to look up asn of %s: %w", ip, err)
+		}
+		info.ASN = record.Number
+		info.Organization = record.Organization
+	}
+	return info, nil
+}
+
+// ParseASN parses autonomous system numbers with or without AS prefix, e.g. AS24940 or 24940.
+func ParseASN(s string) (uint, error) {
+	number := strings.TrimSpace(s)
+	if len(number) > 2 && strings.EqualFold(number[:2], "as") {
+		number = number[2:]
+	}
+
+	asn, err := strconv.ParseUint(number, 10, 32)
+	if err != nil || asn == 0 {
+		return 0, fmt.Errorf("invalid asn: %q", s)
+	}
+	return uint(asn), nil
+}
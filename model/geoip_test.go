@@ -0,0 +1,225 @@
+package model_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/jxsl13/banserver/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mmdbNode is a node of the binary search tree of a MaxMind DB file.
+type mmdbNode struct {
+	children [2]*mmdbNode
+	data     int // offset in the data section, -1 if the node has no data
+	id       int
+}
+
+// writeMMDB writes a minimal ipv6 MaxMind DB (format version 2.0) with 24 bit records.
+// ipv4 networks are stored in the ::/96 subtree, like in the official databases.
+// Only strings, uint32 and maps are supported as values.
+func writeMMDB(t *testing.T, dbType string, networks map[string]map[string]any) string {
+	t.Helper()
+
+	var data bytes.Buffer
+	root := &mmdbNode{data: -1}
+
+	// sorted for reproducible files
+	keys := make([]string, 0, len(networks))
+	for k := range networks {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		prefix := netip.MustParsePrefix(k)
+		addr := prefix.Addr().As16()
+		bits := prefix.Bits()
+		if prefix.Addr().Is4() {
+			// ::a.b.c.d/96+bits
+			var v4 [16]byte
+			copy(v4[12:], addr[12:])
+			addr = v4
+			bits += 96
+		}
+
+		node := root
+		for i := 0; i < bits; i++ {
+			bit := (addr[i/8] >> (7 - i%8)) & 1
+			if node.children[bit] == nil {
+				node.children[bit] = &mmdbNode{data: -1}
+			}
+			node = node.children[bit]
+		}
+		node.data = data.Len()
+		encodeMMDB(t, &data, networks[k])
+	}
+
+	// number all inner nodes, leaves with data become data pointers
+	var nodes []*mmdbNode
+	var number func(n *mmdbNode)
+	number = func(n *mmdbNode) {
+		n.id = len(nodes)
+		nodes = append(nodes, n)
+		for _, c := range n.children {
+			if c != nil && c.data < 0 {
+				number(c)
+			}
+		}
+	}
+	number(root)
+	nodeCount := len(nodes)
+
+	var file bytes.Buffer
+	record := func(c *mmdbNode) {
+		var v int
+		switch {
+		case c == nil:
+			v = nodeCount
+		case c.data >= 0:
+			v = nodeCount + 16 + c.data
+		default:
+			v = c.id
+		}
+		file.Write([]byte{byte(v >> 16), byte(v >> 8), byte(v)})
+	}
+	for _, n := range nodes {
+		record(n.children[0])
+		record(n.children[1])
+	}
+	file.Write(make([]byte, 16))
+	file.Write(data.Bytes())
+
+	file.WriteString("\xab\xcd\xefMaxMind.com")
+	encodeMMDB(t, &file, map[string]any{
+		"node_count":                  uint32(nodeCount),
+		"record_size":                 uint32(24),
+		"ip_version":                  uint32(6),
+		"database_type":               dbType,
+		"binary_format_major_version": uint32(2),
+		"binary_format_minor_version": uint32(0),
+		"build_epoch":                 uint32(0),
+	})
+
+	path := filepath.Join(t.TempDir(), dbType+".mmdb")
+	require.NoError(t, os.WriteFile(path, file.Bytes(), 0o600))
+	return path
+}
+
+func encodeMMDB(t *testing.T, buf *bytes.Buffer, v any) {
+	control := func(typ byte, size int) {
+		require.Less(t, size, 29+256)
+		if size < 29 {
+			buf.WriteByte(typ<<5 | byte(size))
+			return
+		}
+		buf.WriteByte(typ<<5 | 29)
+		buf.WriteByte(byte(size - 29))
+	}
+
+	switch v := v.(type) {
+	case string:
+		control(2, len(v))
+		buf.WriteString(v)
+	case uint32:
+		b := binary.BigEndian.AppendUint32(nil, v)
+		b = bytes.TrimLeft(b, "\x00")
+		control(6, len(b))
+		buf.Write(b)
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		control(7, len(v))
+		for _, k := range keys {
+			encodeMMDB(t, buf, k)
+			encodeMMDB(t, buf, v[k])
+		}
+	default:
+		t.Fatalf("unsupported mmdb type %T", v)
+	}
+}
+
+func country(code string) map[string]any {
+	return map[string]any{"country": map[string]any{"iso_code": code}}
+}
+
+func asn(number uint32, org string) map[string]any {
+	return map[string]any{"autonomous_system_number": number, "autonomous_system_organization": org}
+}
+
+func TestGeoIP(t *testing.T) {
+	countryDB := writeMMDB(t, "GeoLite2-Country", map[string]map[string]any{
+		"1.2.3.0/24":    country("DE"),
+		"5.6.0.0/16":    country("US"),
+		"2001:db8::/32": country("AT"),
+		"9.9.9.0/24":    {"registered_country": map[string]any{"iso_code": "CH"}},
+	})
+	asnDB := writeMMDB(t, "GeoLite2-ASN", map[string]map[string]any{
+		"1.2.0.0/16":    asn(24940, "Hetzner Online GmbH"),
+		"2001:db8::/48": asn(16276, "OVH SAS"),
+	})
+
+	geo, err := model.OpenGeoIP(countryDB, asnDB)
+	require.NoError(t, err)
+	defer geo.Close()
+
+	tests := []struct {
+		ip   string
+		want model.GeoInfo
+	}{
+		{"1.2.3.4", model.GeoInfo{Country: "DE", ASN: 24940, Organization: "Hetzner Online GmbH"}},
+		{"::ffff:1.2.3.4", model.GeoInfo{Country: "DE", ASN: 24940, Organization: "Hetzner Online GmbH"}},
+		{"1.2.4.4", model.GeoInfo{ASN: 24940, Organization: "Hetzner Online GmbH"}},
+		{"5.6.7.8", model.GeoInfo{Country: "US"}},
+		{"9.9.9.9", model.GeoInfo{Country: "CH"}},
+		{"2001:db8::1", model.GeoInfo{Country: "AT", ASN: 16276, Organization: "OVH SAS"}},
+		{"2001:db8:1::1", model.GeoInfo{Country: "AT"}},
+		{"127.0.0.1", model.GeoInfo{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			info, err := geo.Lookup(netip.MustParseAddr(tt.ip))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, info)
+		})
+	}
+
+	assert.Equal(t, `country=DE asn=AS24940 "Hetzner Online GmbH"`, tests[0].want.String())
+	assert.Equal(t, "unknown location", model.GeoInfo{}.String())
+}
+
+func TestOpenGeoIP(t *testing.T) {
+	geo, err := model.OpenGeoIP("", "")
+	require.NoError(t, err)
+	info, err := geo.Lookup(netip.MustParseAddr("1.2.3.4"))
+	require.NoError(t, err)
+	assert.Equal(t, model.GeoInfo{}, info)
+	require.NoError(t, geo.Close())
+
+	_, err = model.OpenGeoIP(filepath.Join(t.TempDir(), "missing.mmdb"), "")
+	require.Error(t, err)
+}
+
+func TestParseASN(t *testing.T) {
+	for s, want := range map[string]uint{"AS24940": 24940, "as16276": 16276, " 13335 ": 13335} {
+		got, err := model.ParseASN(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, want, got, s)
+	}
+
+	for _, s := range []string{"", "AS", "ASN1", "0", "-1", "4294967296"} {
+		_, err := model.ParseASN(s)
+		require.Error(t, err, s)
+	}
+}
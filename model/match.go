@@ -0,0 +1,39 @@
+package model
+
+import (
+	"fmt"
+	"net/netip"
+
+	"github.com/jxsl13/banserver/parser"
+)
+
+// ChatMatch is a chat blacklist rule that matches a message.
+type ChatMatch struct {
+	Rule   string `json:"rule"`
+	Source string `json:"source"` // blacklist the rule was loaded from
+	Line   int    `json:"line"`
+}
+
+func (m ChatMatch) String() string {
+	return fmt.Sprintf("%s (%s:%d)", m.Rule, m.Source, m.Line)
+}
+
+// MatchIP returns every ip blacklist entry that contains the ip.
+func (p *Broker) MatchIP(ip netip.Addr) ([]SourcedPrefix, error) {
+	return p.banserver.Lookup(ip)
+}
+
+// MatchChat returns every chat blacklist rule that matches the message, the nickname is not checked.
+func (p *Broker) MatchChat(chat parser.ChatMessage) []ChatMatch {
+	p.mu.RLock()
+	rules := p.chatRules
+	p.mu.RUnlock()
+
+	var matches []ChatMatch
+	for _, rule := range rules {
+		if rule.Matches(chat) {
+			matches = append(matches, ChatMatch{Rule: rule.String(), Source: rule.source, Line: rule.line})
+		}
+	}
+	return matches
+}
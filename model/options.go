@@ -0,0 +1,156 @@
+package model
+
+import (
+	"log"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+type Option func(*Broker)
+
+// WithRconBruteforceProtection bans an ip on all servers after it failed to log into the rcon
+// of any server attempts times within the given window.
+func WithRconBruteforceProtection(attempts int, window, banDuration time.Duration, banReason string) Option {
+	return func(p *Broker) {
+		p.rconBanAttempts = attempts
+		p.rconFailures = newEventWindow(window)
+		p.rconBanDuration = banDuration
+		p.rconBanReason = banReason
+	}
+}
+
+// WithRconLoginAlert logs an alert whenever a client successfully logs into the rcon
+// from an ip that is neither trusted nor has logged in successfully before.
+func WithRconLoginAlert(trustedCIDRs ...string) Option {
+	return func(p *Broker) {
+		p.rconAlert = true
+		for _, cidr := range trustedCIDRs {
+			prefixes, err := parseCIDRs(cidr)
+			if err != nil {
+				log.Printf("ignoring invalid trusted rcon ip: %s: %v", cidr, err)
+				continue
+			}
+			p.rconTrusted = append(p.rconTrusted, prefixes...)
+		}
+	}
+}
+
+// actions that can be applied to clients abusing votes or whispers
+const (
+	ActionLog  = "log"
+	ActionKick = "kick"
+	ActionBan  = "ban"
+)
+
+// WithVoteAbuseProtection applies the given action (log, kick or ban) to clients that either call more than
+// maxVotes votes or more than maxKickVotes kick votes against the same player within the given window.
+// A limit <= 0 disables the corresponding check.
+func WithVoteAbuseProtection(maxVotes, maxKickVotes int, window time.Duration, action string, banDuration time.Duration, banReason string) Option {
+	return func(p *Broker) {
+		p.voteMax = maxVotes
+		p.voteKickMax = maxKickVotes
+		p.votes = newEventWindow(window)
+		p.kickVotes = newEventWindow(window)
+		p.voteAction = action
+		p.voteBanDuration = banDuration
+		p.voteBanReason = banReason
+	}
+}
+
+// WithWhisperSpamProtection applies the given action (log, kick or ban) to clients that whisper
+// to more than maxTargets distinct clients within the given window.
+func WithWhisperSpamProtection(maxTargets int, window time.Duration, action string, banDuration time.Duration, banReason string) Option {
+	return func(p *Broker) {
+		p.whisperMaxTargets = maxTargets
+		p.whisperTargets = newDistinctWindow(window)
+		p.whisperAction = action
+		p.whisperBanDuration = banDuration
+		p.whisperBanReason = banReason
+	}
+}
+
+// AllGroups is the group of country allowlists that apply to all servers without a group specific allowlist.
+const AllGroups = "*"
+
+// WithGeoIP logs the location of joining clients and applies the given action (log, kick or ban) to clients that
+// join from one of the denied autonomous systems or from a country that is not allowed in the group of the server.
+// allowedCountries maps server groups (or AllGroups) to ISO country codes, groups without allowlist allow all countries.
+func WithGeoIP(geo *GeoIP, deniedASNs []uint, allowedCountries map[string][]string, action string, banDuration time.Duration, banReason string) Option {
+	return func(p *Broker) {
+		p.geo = geo
+		p.geoDeniedASNs = make(map[uint]struct{}, len(deniedASNs))
+		for _, asn := range deniedASNs {
+			p.geoDeniedASNs[asn] = struct{}{}
+		}
+
+		p.geoAllowedCountries = make(map[string]map[string]struct{}, len(allowedCountries))
+		for group, countries := range allowedCountries {
+			allowed := make(map[string]struct{}, len(countries))
+			for _, country := range countries {
+				allowed[strings.ToUpper(country)] = struct{}{}
+			}
+			p.geoAllowedCountries[group] = allowed
+		}
+
+		p.geoAction = action
+		p.geoBanDuration = banDuration
+		p.geoBanReason = banReason
+	}
+}
+
+// WithReconcileMode enables the tracking of unbans that is required to reconcile the ban lists of all servers
+// with Broker.Reconcile. The mode is either ReconcileReport or ReconcileEnforce.
+func WithReconcileMode(mode string) Option {
+	return func(p *Broker) {
+		p.reconcileMode = mode
+	}
+}
+
+// WithBanOrigin prefixes the reasons of propagated bans with the name of the server the ban originated from,
+// e.g. "banned on DDNet Novice #1: <reason>".
+func WithBanOrigin() Option {
+	return func(p *Broker) {
+		p.banOrigin = true
+	}
+}
+
+// WithBanConfirmation waits up to timeout for every game server to confirm a ban and retries bans that
+// were not confirmed up to retries times. Bans that failed nevertheless are logged and published as BanFailed events.
+func WithBanConfirmation(timeout time.Duration, retries int) Option {
+	return func(p *Broker) {
+		p.banConfirmTimeout = timeout
+		p.banRetries = retries
+	}
+}
+
+// WithBlacklistCache sets the directory in which the last valid copies of http(s) blacklists are cached.
+func WithBlacklistCache(dir string) Option {
+	return func(p *Broker) {
+		p.blacklistCacheDir = dir
+	}
+}
+
+// WithBlacklistMaxSize limits the size in bytes of http(s) blacklists.
+func WithBlacklistMaxSize(maxSize int) Option {
+	return func(p *Broker) {
+		p.blacklistMaxSize = maxSize
+	}
+}
+
+// WithHTTPClient sets the client that is used to fetch http(s) blacklists.
+func WithHTTPClient(client *http.Client) Option {
+	return func(p *Broker) {
+		p.blacklistClient = client
+	}
+}
+
+func containsIP(prefixes []netip.Prefix, ip netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
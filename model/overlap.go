@@ -0,0 +1,71 @@
+package model
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+)
+
+// SourcedPrefix is a CIDR of a blacklist entry.
+type SourcedPrefix struct {
+	Prefix netip.Prefix `json:"prefix"`
+	Source string       `json:"source,omitempty"` // blacklist the entry was loaded from, empty for manual bans
+	Line   int          `json:"line,omitempty"`
+}
+
+func (p SourcedPrefix) String() string {
+	if p.Source == manualSource {
+		return fmt.Sprintf("%s (manual)", p.Prefix)
+	}
+	return fmt.Sprintf("%s (%s:%d)", p.Prefix, p.Source, p.Line)
+}
+
+// SourcedPrefixes returns the CIDRs of all entries of a blacklist.
+func SourcedPrefixes(source string, entries []BlacklistEntry) []SourcedPrefix {
+	prefixes := make([]SourcedPrefix, 0, len(entries))
+	for _, entry := range entries {
+		for _, prefix := range entry.Prefixes {
+			prefixes = append(prefixes, SourcedPrefix{Prefix: prefix.Masked(), Source: source, Line: entry.Line})
+		}
+	}
+	return prefixes
+}
+
+// Overlap is a CIDR that is already contained in another CIDR, which makes its blacklist entry redundant.
+type Overlap struct {
+	Redundant SourcedPrefix
+	Container SourcedPrefix
+}
+
+// FindOverlaps returns every CIDR that is contained in a CIDR of another blacklist entry.
+// CIDRs either contain each other or are disjoint, which is why the containing CIDR is the
+// widest CIDR that starts at or before the redundant one.
+func FindOverlaps(prefixes []SourcedPrefix) []Overlap {
+	sorted := make([]SourcedPrefix, len(prefixes))
+	copy(sorted, prefixes)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].Prefix, sorted[j].Prefix
+		if c := a.Addr().Compare(b.Addr()); c != 0 {
+			return c < 0
+		}
+		return a.Bits() < b.Bits()
+	})
+
+	var (
+		overlaps  []Overlap
+		container SourcedPrefix
+	)
+	for _, p := range sorted {
+		if !container.Prefix.IsValid() || !container.Prefix.Contains(p.Prefix.Addr()) {
+			container = p
+			continue
+		}
+
+		// ranges are split into multiple CIDRs, which never overlap
+		if container.Source == p.Source && container.Line == p.Line {
+			continue
+		}
+		overlaps = append(overlaps, Overlap{Redundant: p, Container: container})
+	}
+	return overlaps
+}
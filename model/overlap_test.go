@@ -0,0 +1,73 @@
+package model_test
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/jxsl13/banserver/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindOverlaps(t *testing.T) {
+	prefix := func(source string, line int, cidr string) model.SourcedPrefix {
+		return model.SourcedPrefix{Prefix: netip.MustParsePrefix(cidr), Source: source, Line: line}
+	}
+
+	tests := []struct {
+		name     string
+		prefixes []model.SourcedPrefix
+		want     []model.Overlap
+	}{
+		{
+			name: "disjoint",
+			prefixes: []model.SourcedPrefix{
+				prefix("a", 1, "1.2.3.0/24"),
+				prefix("a", 2, "1.2.4.0/24"),
+				prefix("a", 3, "2001:db8::/32"),
+			},
+		},
+		{
+			name: "contained",
+			prefixes: []model.SourcedPrefix{
+				prefix("a", 1, "1.2.3.4/32"),
+				prefix("b", 1, "1.2.0.0/16"),
+				prefix("a", 2, "1.2.200.0/24"),
+				prefix("a", 3, "1.3.0.0/24"),
+			},
+			want: []model.Overlap{
+				{Redundant: prefix("a", 1, "1.2.3.4/32"), Container: prefix("b", 1, "1.2.0.0/16")},
+				{Redundant: prefix("a", 2, "1.2.200.0/24"), Container: prefix("b", 1, "1.2.0.0/16")},
+			},
+		},
+		{
+			name: "duplicate",
+			prefixes: []model.SourcedPrefix{
+				prefix("a", 1, "1.2.3.0/24"),
+				prefix("b", 7, "1.2.3.0/24"),
+			},
+			want: []model.Overlap{
+				{Redundant: prefix("b", 7, "1.2.3.0/24"), Container: prefix("a", 1, "1.2.3.0/24")},
+			},
+		},
+		{
+			name: "range of a single entry",
+			prefixes: []model.SourcedPrefix{
+				prefix("a", 1, "1.2.3.0/25"),
+				prefix("a", 1, "1.2.3.128/26"),
+			},
+		},
+		{
+			name: "different address families",
+			prefixes: []model.SourcedPrefix{
+				prefix("a", 1, "::/0"),
+				prefix("a", 2, "1.2.3.0/24"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, model.FindOverlaps(tt.prefixes))
+		})
+	}
+}
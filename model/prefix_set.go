@@ -0,0 +1,179 @@
+package model
+
+import (
+	"encoding/binary"
+	"net/netip"
+	"slices"
+	"sort"
+)
+
+// prefixSet is an immutable set of ip ranges that allows to check whether an ip is contained in any
+// of the prefixes it was built from. Overlapping and adjacent prefixes are merged into a single range,
+// which keeps the set compact for large lists of e.g. vpn or datacenter networks.
+type prefixSet struct {
+	v4 []v4Range
+	v6 []v6Range
+}
+
+type v4Range struct {
+	first, last uint32
+}
+
+type v6Range struct {
+	first, last uint128
+}
+
+type uint128 struct {
+	hi, lo uint64
+}
+
+func (u uint128) cmp(o uint128) int {
+	switch {
+	case u.hi < o.hi:
+		return -1
+	case u.hi > o.hi:
+		return 1
+	case u.lo < o.lo:
+		return -1
+	case u.lo > o.lo:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// next returns u+1 and false in case of an overflow.
+func (u uint128) next() (uint128, bool) {
+	lo := u.lo + 1
+	hi := u.hi
+	if lo == 0 {
+		hi++
+		if hi == 0 {
+			return uint128{}, false
+		}
+	}
+	return uint128{hi: hi, lo: lo}, true
+}
+
+func v4Of(addr netip.Addr) uint32 {
+	b := addr.As4()
+	return binary.BigEndian.Uint32(b[:])
+}
+
+func v6Of(addr netip.Addr) uint128 {
+	b := addr.As16()
+	return uint128{
+		hi: binary.BigEndian.Uint64(b[:8]),
+		lo: binary.BigEndian.Uint64(b[8:]),
+	}
+}
+
+// newPrefixSet builds a set from the given prefixes, which must be masked and must not contain ipv4-mapped ipv6 prefixes.
+func newPrefixSet(prefixes ...[]netip.Prefix) *prefixSet {
+	var (
+		v4 []v4Range
+		v6 []v6Range
+	)
+
+	for _, ps := range prefixes {
+		for _, p := range ps {
+			if p.Addr().Is4() {
+				first := v4Of(p.Addr())
+				hostBits := 32 - p.Bits()
+				last := first | uint32((uint64(1)<<hostBits)-1)
+				v4 = append(v4, v4Range{first, last})
+				continue
+			}
+
+			first := v6Of(p.Addr())
+			last := first
+			hostBits := 128 - p.Bits()
+			switch {
+			case hostBits >= 64:
+				last.lo = ^uint64(0)
+				last.hi |= (uint64(1) << (hostBits - 64)) - 1
+			case hostBits > 0:
+				last.lo |= (uint64(1) << hostBits) - 1
+			}
+			v6 = append(v6, v6Range{first, last})
+		}
+	}
+
+	return &prefixSet{
+		v4: mergeV4(v4),
+		v6: mergeV6(v6),
+	}
+}
+
+func mergeV4(ranges []v4Range) []v4Range {
+	if len(ranges) == 0 {
+		return nil
+	}
+
+	slices.SortFunc(ranges, func(a, b v4Range) int {
+		switch {
+		case a.first < b.first:
+			return -1
+		case a.first > b.first:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	merged := ranges[:1]
+	for _, r := range ranges[1:] {
+		cur := &merged[len(merged)-1]
+		if cur.last == ^uint32(0) || r.first <= cur.last+1 {
+			// overlapping or adjacent
+			cur.last = max(cur.last, r.last)
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return slices.Clip(merged)
+}
+
+func mergeV6(ranges []v6Range) []v6Range {
+	if len(ranges) == 0 {
+		return nil
+	}
+
+	slices.SortFunc(ranges, func(a, b v6Range) int {
+		return a.first.cmp(b.first)
+	})
+
+	merged := ranges[:1]
+	for _, r := range ranges[1:] {
+		cur := &merged[len(merged)-1]
+		next, ok := cur.last.next()
+		if !ok || r.first.cmp(next) <= 0 {
+			// overlapping or adjacent
+			if r.last.cmp(cur.last) > 0 {
+				cur.last = r.last
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return slices.Clip(merged)
+}
+
+// Contains expects an unmapped ip.
+func (s *prefixSet) Contains(addr netip.Addr) bool {
+	if addr.Is4() {
+		ip := v4Of(addr)
+		// first range that starts after the ip, the previous one may contain it
+		i := sort.Search(len(s.v4), func(i int) bool { return s.v4[i].first > ip })
+		return i > 0 && ip <= s.v4[i-1].last
+	}
+
+	ip := v6Of(addr)
+	i := sort.Search(len(s.v6), func(i int) bool { return s.v6[i].first.cmp(ip) > 0 })
+	return i > 0 && ip.cmp(s.v6[i-1].last) <= 0
+}
+
+// Len returns the number of merged ranges.
+func (s *prefixSet) Len() int {
+	return len(s.v4) + len(s.v6)
+}
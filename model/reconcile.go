@@ -0,0 +1,248 @@
+package model
+
+import (
+	"context"
+	"log"
+	"maps"
+	"net/netip"
+	"sort"
+	"time"
+
+	"github.com/jxsl13/banserver/econ"
+	"github.com/jxsl13/banserver/parser"
+)
+
+// reconcile modes
+const (
+	// ReconcileReport only logs the commands that are required to converge the ban lists.
+	ReconcileReport = "report"
+	// ReconcileEnforce executes the commands that are required to converge the ban lists.
+	ReconcileEnforce = "enforce"
+)
+
+// reconcileListTimeout is the maximum time to wait for the ban lists of all servers.
+const reconcileListTimeout = 30 * time.Second
+
+// reconcileAction is a ban or unban command that converges the ban list of a server.
+type reconcileAction struct {
+	server string
+	unban  bool
+	ban    parser.BanListEntry
+	source string // server that has the ban
+}
+
+// Reconcile periodically reads the ban lists of all servers and bans ips on every server that are banned
+// on any server. IPs that have been unbanned on any server since they were banned are unbanned on all servers.
+// Servers whose ban list could not be read are skipped.
+func (p *Broker) Reconcile(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.reconcile(ctx)
+		}
+	}
+}
+
+func (p *Broker) reconcile(ctx context.Context) {
+	start := time.Now()
+
+	p.mu.RLock()
+	servers := make([]*econ.Server, 0, len(p.serverMap))
+	for _, s := range p.serverMap {
+		servers = append(servers, s)
+	}
+	p.mu.RUnlock()
+
+	for _, s := range servers {
+		err := s.ListBans()
+		if err != nil {
+			log.Printf("failed to request ban list of %s for reconciliation: %v", s, err)
+		}
+	}
+
+	lists, complete := p.awaitBanLists(ctx, servers, start)
+	if ctx.Err() != nil {
+		return
+	}
+
+	p.mu.Lock()
+	actions := reconcileBans(lists, p.tombstones)
+	if complete {
+		// ips that are not banned anywhere anymore do not need to be unbanned again
+		for ip := range p.tombstones {
+			if !isListed(lists, ip) {
+				delete(p.tombstones, ip)
+			}
+		}
+	}
+	mode := p.reconcileMode
+	p.mu.Unlock()
+
+	if len(actions) == 0 {
+		log.Printf("ban lists of %d servers are in sync", len(lists))
+		return
+	}
+
+	for _, a := range actions {
+		if mode != ReconcileEnforce {
+			if a.unban {
+				log.Printf("reconcile: client %s is still banned on server %s, but was unbanned on another server", a.ban.IP, p.serverName(a.server))
+			} else {
+				log.Printf("reconcile: client %s banned on server %s is not banned on server %s", a.ban.IP, p.serverName(a.source), p.serverName(a.server))
+			}
+			continue
+		}
+
+		err := p.applyReconcileAction(a)
+		if err != nil {
+			log.Printf("failed to reconcile ban list of %s: %v", p.serverName(a.server), err)
+		}
+	}
+}
+
+// awaitBanLists waits until the ban lists of all servers have been received after the given time.
+// The ban lists of all servers that responded in time are returned.
+func (p *Broker) awaitBanLists(ctx context.Context, servers []*econ.Server, since time.Time) (lists map[string]map[netip.Addr]parser.BanListEntry, complete bool) {
+	timeout := time.NewTimer(reconcileListTimeout)
+	defer timeout.Stop()
+
+	for {
+		p.mu.RLock()
+		lists = make(map[string]map[netip.Addr]parser.BanListEntry, len(servers))
+		for _, s := range servers {
+			if p.banListedAt[s.AddressPort()].After(since) {
+				lists[s.AddressPort()] = maps.Clone(p.serverBans[s.AddressPort()])
+			}
+		}
+		p.mu.RUnlock()
+
+		if len(lists) == len(servers) {
+			return lists, true
+		}
+
+		select {
+		case <-ctx.Done():
+			return lists, false
+		case <-timeout.C:
+			for _, s := range servers {
+				if _, ok := lists[s.AddressPort()]; !ok {
+					log.Printf("skipping reconciliation of %s, ban list was not received in time", s)
+				}
+			}
+			return lists, false
+		case <-p.banListed:
+		}
+	}
+}
+
+func (p *Broker) applyReconcileAction(a reconcileAction) error {
+	// commands are sent without holding the lock, as writing them may take a while
+	p.mu.RLock()
+	s, ok := p.serverMap[a.server]
+	others := make([]*econ.Server, 0, len(p.others[a.server]))
+	for _, other := range p.others[a.server] {
+		others = append(others, p.serverMap[other])
+	}
+	ss, sourceOK := p.serverMap[a.source]
+	p.mu.RUnlock()
+
+	if !ok {
+		return nil
+	}
+
+	// the resulting ban or unban must not be propagated again, as all other servers are reconciled as well
+	if a.unban {
+		for _, other := range others {
+			other.IgnoreUnbanPrapagation(a.ban.IP, a.server)
+		}
+		log.Printf("reconcile: unbanning client %s on server %s", a.ban.IP, s)
+		return s.UnbanIP(a.server, a.ban.IP)
+	}
+
+	source, reason := a.source, a.ban.Reason
+	if sourceOK {
+		source = ss.String()
+		if p.banOrigin {
+			reason = originReason(ss.Name(), reason)
+		}
+	}
+	log.Printf("reconcile: banning client %s on server %s as it is banned on server %s", a.ban.IP, s, source)
+	return p.ban(s, a.source, a.ban.IP, a.ban.Duration, reason, others...)
+}
+
+// reconcileBans computes the commands that converge the ban lists of all servers.
+// Every ip that is banned on any server must be banned on all servers, unless it has been unbanned on any server,
+// in which case it must not be banned on any server. Permanent bans and longer durations take precedence.
+func reconcileBans(lists map[string]map[netip.Addr]parser.BanListEntry, tombstones map[netip.Addr]struct{}) []reconcileAction {
+	servers := make([]string, 0, len(lists))
+	for server := range lists {
+		servers = append(servers, server)
+	}
+	sort.Strings(servers)
+
+	type desiredBan struct {
+		ban    parser.BanListEntry
+		source string
+	}
+	desired := make(map[netip.Addr]desiredBan)
+	for _, server := range servers {
+		for ip, ban := range lists[server] {
+			if _, unbanned := tombstones[ip]; unbanned {
+				continue
+			}
+
+			d, ok := desired[ip]
+			if !ok || longerBan(ban, d.ban) {
+				desired[ip] = desiredBan{ban: ban, source: server}
+			}
+		}
+	}
+
+	var actions []reconcileAction
+	for _, server := range servers {
+		for ip, d := range desired {
+			if _, ok := lists[server][ip]; !ok {
+				actions = append(actions, reconcileAction{server: server, ban: d.ban, source: d.source})
+			}
+		}
+		for ip, ban := range lists[server] {
+			if _, unbanned := tombstones[ip]; unbanned {
+				actions = append(actions, reconcileAction{server: server, unban: true, ban: ban, source: server})
+			}
+		}
+	}
+
+	sort.Slice(actions, func(i, j int) bool {
+		if actions[i].server != actions[j].server {
+			return actions[i].server < actions[j].server
+		}
+		return actions[i].ban.IP.Less(actions[j].ban.IP)
+	})
+	return actions
+}
+
+// longerBan returns true in case that a lasts longer than b.
+func longerBan(a, b parser.BanListEntry) bool {
+	if b.Duration == 0 {
+		return false
+	}
+	return a.Duration == 0 || a.Duration > b.Duration
+}
+
+func isListed(lists map[string]map[netip.Addr]parser.BanListEntry, ip netip.Addr) bool {
+	for _, bans := range lists {
+		if _, ok := bans[ip]; ok {
+			return true
+		}
+	}
+	return false
+}
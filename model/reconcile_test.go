@@ -0,0 +1,126 @@
+package model
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/jxsl13/banserver/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func listed(minutes int, ips ...string) map[netip.Addr]parser.BanListEntry {
+	bans := make(map[netip.Addr]parser.BanListEntry, len(ips))
+	for _, ip := range ips {
+		addr := netip.MustParseAddr(ip)
+		bans[addr] = parser.BanListEntry{IP: addr, Duration: time.Duration(minutes) * time.Minute, Reason: "test"}
+	}
+	return bans
+}
+
+func TestReconcileBans(t *testing.T) {
+	ban := func(server, source, ip string, minutes int) reconcileAction {
+		return reconcileAction{server: server, ban: listed(minutes, ip)[netip.MustParseAddr(ip)], source: source}
+	}
+	unban := func(server, ip string, minutes int) reconcileAction {
+		return reconcileAction{server: server, unban: true, ban: listed(minutes, ip)[netip.MustParseAddr(ip)], source: server}
+	}
+
+	tests := []struct {
+		name       string
+		lists      map[string]map[netip.Addr]parser.BanListEntry
+		tombstones []string
+		want       []reconcileAction
+	}{
+		{
+			name: "in sync",
+			lists: map[string]map[netip.Addr]parser.BanListEntry{
+				"a": listed(0, "1.1.1.1"),
+				"b": listed(0, "1.1.1.1"),
+			},
+		},
+		{
+			name: "missing bans",
+			lists: map[string]map[netip.Addr]parser.BanListEntry{
+				"a": listed(0, "1.1.1.1", "2.2.2.2"),
+				"b": listed(0),
+				"c": listed(0, "2.2.2.2"),
+			},
+			want: []reconcileAction{
+				ban("b", "a", "1.1.1.1", 0),
+				ban("b", "a", "2.2.2.2", 0),
+				ban("c", "a", "1.1.1.1", 0),
+			},
+		},
+		{
+			name: "longest ban wins",
+			lists: map[string]map[netip.Addr]parser.BanListEntry{
+				"a": listed(5, "1.1.1.1"),
+				"b": listed(60, "1.1.1.1", "2.2.2.2"),
+				"c": listed(0, "2.2.2.2"),
+				"d": listed(0),
+			},
+			want: []reconcileAction{
+				ban("a", "c", "2.2.2.2", 0),
+				ban("c", "b", "1.1.1.1", 60),
+				ban("d", "b", "1.1.1.1", 60),
+				ban("d", "c", "2.2.2.2", 0),
+			},
+		},
+		{
+			name: "unbanned on one server",
+			lists: map[string]map[netip.Addr]parser.BanListEntry{
+				"a": listed(0, "1.1.1.1", "2.2.2.2"),
+				"b": listed(0, "2.2.2.2"),
+				"c": listed(0, "1.1.1.1", "2.2.2.2"),
+			},
+			tombstones: []string{"1.1.1.1"},
+			want: []reconcileAction{
+				unban("a", "1.1.1.1", 0),
+				unban("c", "1.1.1.1", 0),
+			},
+		},
+		{
+			name:       "no servers",
+			tombstones: []string{"1.1.1.1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tombstones := make(map[netip.Addr]struct{})
+			for _, ip := range tt.tombstones {
+				tombstones[netip.MustParseAddr(ip)] = struct{}{}
+			}
+
+			got := reconcileBans(tt.lists, tombstones)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestReconcileEnforce(t *testing.T) {
+	p := NewBroker(true, time.Hour, "permaban", time.Hour, "chat ban", WithReconcileMode(ReconcileEnforce))
+	a, connA := addFakeServer(t, p, "127.0.0.1:8303")
+	_, connB := addFakeServer(t, p, "127.0.0.1:8304")
+
+	connB.Log(ddnetLine("net_ban", "0 bans, showing entries 0 - -1"))
+	connA.Log(
+		ddnetLine("net_ban", "#0 '1.1.1.1' banned for 30 minutes (spam)"),
+		ddnetLine("net_ban", "1 bans, showing entries 0 - 0"),
+	)
+	// the listed ban is only banned by the reconciliation
+	requireNoCommand(t, connB)
+	requireNoCommand(t, connA)
+
+	ip := netip.MustParseAddr("1.1.1.1")
+	require.NoError(t, p.applyReconcileAction(reconcileAction{
+		server: "127.0.0.1:8304",
+		ban:    parser.BanListEntry{IP: ip, Duration: 30 * time.Minute, Reason: "spam"},
+		source: "127.0.0.1:8303",
+	}))
+	assert.Equal(t, `ban 1.1.1.1 30 "spam"`, connB.Command(t))
+	// the resulting ban is not propagated back
+	assert.True(t, a.IsIgnoredBanPropagation("127.0.0.1:8304", ip))
+}
@@ -0,0 +1,286 @@
+package model
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultBlacklistMaxSize = 64 << 20 // 64 MiB
+	defaultBlacklistTimeout = 30 * time.Second
+)
+
+// IsRemoteBlacklist returns true in case the blacklist is an http(s) url instead of a file path.
+func IsRemoteBlacklist(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// ReadBlacklist reads a blacklist, which is either a file path or an http(s) url, without caching it.
+func ReadBlacklist(ctx context.Context, client *http.Client, source string, maxSize int) ([]byte, error) {
+	if !IsRemoteBlacklist(source) {
+		return os.ReadFile(source)
+	}
+
+	l := &remoteList{url: source, client: client, maxSize: maxSize}
+	data, _, err := l.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.IndexByte(data, 0) >= 0 {
+		return nil, fmt.Errorf("blacklist %s is not a text file", source)
+	}
+	return data, nil
+}
+
+// remoteList is a blacklist that is published as http(s) url.
+// Conditional requests avoid downloading unchanged lists and the last valid copy is cached on disk,
+// so that the blacklist is still enforced in case the url is not reachable after a restart.
+type remoteList struct {
+	mu       sync.Mutex
+	url      string
+	client   *http.Client
+	cacheDir string // empty disables the cache
+	maxSize  int
+
+	// apply replaces the previous entries of the list, it must not modify anything in case of an error.
+	apply func(source string, r io.Reader) error
+
+	meta    remoteMeta
+	data    []byte
+	applied bool
+}
+
+type remoteMeta struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// Load fetches the list and applies it. In case the list cannot be fetched, the cached copy is applied instead.
+func (l *remoteList) Load(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.readCache()
+
+	err := l.refresh(ctx)
+	if err == nil {
+		return nil
+	}
+
+	if l.data == nil {
+		return err
+	}
+
+	log.Printf("failed to fetch blacklist %s, using cached copy: %v", l.url, err)
+	err = l.apply(l.url, bytes.NewReader(l.data))
+	if err != nil {
+		return err
+	}
+	l.applied = true
+	return nil
+}
+
+// Refresh applies the list in case it changed since the last successful fetch.
+// The previously applied entries are kept in case of an error.
+func (l *remoteList) Refresh(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.refresh(ctx)
+}
+
+func (l *remoteList) refresh(ctx context.Context) error {
+	data, meta, err := l.fetch(ctx)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		// not modified
+		if l.applied {
+			return nil
+		}
+		if l.data == nil {
+			return errors.New("server responded not modified without a cached copy")
+		}
+		err = l.apply(l.url, bytes.NewReader(l.data))
+		if err != nil {
+			return err
+		}
+		l.applied = true
+		return nil
+	}
+
+	if bytes.IndexByte(data, 0) >= 0 {
+		return fmt.Errorf("blacklist %s is not a text file", l.url)
+	}
+
+	err = l.apply(l.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	l.data = data
+	l.meta = meta
+	l.applied = true
+	l.writeCache()
+	return nil
+}
+
+// fetch returns nil data in case the list was not modified since the last fetch.
+func (l *remoteList) fetch(ctx context.Context) ([]byte, remoteMeta, error) {
+	meta := remoteMeta{URL: l.url}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, l.url, nil)
+	if err != nil {
+		return nil, meta, err
+	}
+
+	if l.data != nil {
+		if l.meta.ETag != "" {
+			req.Header.Set("If-None-Match", l.meta.ETag)
+		}
+		if l.meta.LastModified != "" {
+			req.Header.Set("If-Modified-Since", l.meta.LastModified)
+		}
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, meta, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return nil, l.meta, nil
+	default:
+		return nil, meta, fmt.Errorf("failed to fetch blacklist %s: unexpected status %s", l.url, resp.Status)
+	}
+
+	if resp.ContentLength > int64(l.maxSize) {
+		return nil, meta, fmt.Errorf("blacklist %s exceeds the maximum size of %d bytes", l.url, l.maxSize)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, int64(l.maxSize)+1))
+	if err != nil {
+		return nil, meta, fmt.Errorf("failed to read blacklist %s: %w", l.url, err)
+	}
+	if len(data) > l.maxSize {
+		return nil, meta, fmt.Errorf("blacklist %s exceeds the maximum size of %d bytes", l.url, l.maxSize)
+	}
+
+	meta.ETag = resp.Header.Get("ETag")
+	meta.LastModified = resp.Header.Get("Last-Modified")
+	return data, meta, nil
+}
+
+func (l *remoteList) cachePath() string {
+	sum := sha256.Sum256([]byte(l.url))
+	return filepath.Join(l.cacheDir, hex.EncodeToString(sum[:]))
+}
+
+// readCache loads the cached copy in case nothing has been fetched yet.
+func (l *remoteList) readCache() {
+	if l.cacheDir == "" || l.data != nil {
+		return
+	}
+
+	path := l.cachePath()
+	metaData, err := os.ReadFile(path + ".json")
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("failed to read cache of blacklist %s: %v", l.url, err)
+		}
+		return
+	}
+
+	var meta remoteMeta
+	err = json.Unmarshal(metaData, &meta)
+	if err != nil || meta.URL != l.url {
+		log.Printf("ignoring invalid cache of blacklist %s: %v", l.url, err)
+		return
+	}
+
+	data, err := os.ReadFile(path + ".txt")
+	if err != nil {
+		log.Printf("failed to read cache of blacklist %s: %v", l.url, err)
+		return
+	}
+
+	l.meta = meta
+	l.data = data
+}
+
+func (l *remoteList) writeCache() {
+	if l.cacheDir == "" {
+		return
+	}
+
+	err := os.MkdirAll(l.cacheDir, 0o700)
+	if err != nil {
+		log.Printf("failed to create blacklist cache directory %s: %v", l.cacheDir, err)
+		return
+	}
+
+	metaData, err := json.Marshal(l.meta)
+	if err != nil {
+		log.Printf("failed to cache blacklist %s: %v", l.url, err)
+		return
+	}
+
+	path := l.cachePath()
+	// the meta data is written last, as it is required to read the cached copy
+	err = errors.Join(
+		writeFileAtomic(path+".txt", l.data),
+		writeFileAtomic(path+".json", metaData),
+	)
+	if err != nil {
+		log.Printf("failed to cache blacklist %s: %v", l.url, err)
+	}
+}
+
+// writeFileAtomic makes sure that there are no partially written files in case of a crash.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// defaultBlacklistCacheDir returns the directory for cached remote blacklists or an empty string
+// in case that there is no cache directory for the current user.
+func defaultBlacklistCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		log.Printf("remote blacklists are not cached: %v", err)
+		return ""
+	}
+	return filepath.Join(dir, "banserver", "blacklists")
+}
@@ -0,0 +1,234 @@
+package model
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blacklistServer is a local stand-in for a remote blacklist that supports conditional requests.
+type blacklistServer struct {
+	mu           sync.Mutex
+	content      string
+	etag         string
+	lastModified time.Time
+	status       int
+	requests     int
+	notModified  int
+}
+
+func (s *blacklistServer) set(content, etag string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.content = content
+	s.etag = etag
+	s.lastModified = s.lastModified.Add(time.Hour)
+}
+
+func (s *blacklistServer) setStatus(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+}
+
+func (s *blacklistServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+
+	if s.status != 0 {
+		w.WriteHeader(s.status)
+		return
+	}
+
+	if s.etag != "" && r.Header.Get("If-None-Match") == s.etag {
+		s.notModified++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if s.etag == "" && r.Header.Get("If-Modified-Since") == s.lastModified.Format(http.TimeFormat) {
+		s.notModified++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if s.etag != "" {
+		w.Header().Set("ETag", s.etag)
+	}
+	w.Header().Set("Last-Modified", s.lastModified.Format(http.TimeFormat))
+	_, _ = w.Write([]byte(s.content))
+}
+
+func newTestBlacklist(t *testing.T, content, etag string) (*blacklistServer, *httptest.Server) {
+	bs := &blacklistServer{
+		content:      content,
+		etag:         etag,
+		lastModified: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	srv := httptest.NewServer(bs)
+	t.Cleanup(srv.Close)
+	return bs, srv
+}
+
+func newTestRemoteList(srv *httptest.Server, cacheDir string, b *BanServer) *remoteList {
+	return &remoteList{
+		url:      srv.URL + "/blacklist.txt",
+		client:   srv.Client(),
+		cacheDir: cacheDir,
+		maxSize:  1024,
+		apply:    b.AddBlacklist,
+	}
+}
+
+func assertBanned(t *testing.T, b *BanServer, ip string, want bool) {
+	t.Helper()
+	banned, err := b.IsBanned(netip.MustParseAddr(ip))
+	require.NoError(t, err)
+	assert.Equal(t, want, banned, ip)
+}
+
+func TestRemoteListConditionalRequests(t *testing.T) {
+	for _, etag := range []string{`"v1"`, ""} {
+		t.Run("etag="+etag, func(t *testing.T) {
+			ctx := context.Background()
+			bs, srv := newTestBlacklist(t, "1.2.3.4\n", etag)
+
+			b := NewBanServer()
+			l := newTestRemoteList(srv, "", b)
+			require.NoError(t, l.Load(ctx))
+			assertBanned(t, b, "1.2.3.4", true)
+
+			require.NoError(t, l.Refresh(ctx))
+			assert.Equal(t, 2, bs.requests)
+			assert.Equal(t, 1, bs.notModified)
+			assertBanned(t, b, "1.2.3.4", true)
+
+			bs.set("5.6.7.8\n", strings.ReplaceAll(etag, "v1", "v2"))
+			require.NoError(t, l.Refresh(ctx))
+			assert.Equal(t, 1, bs.notModified)
+			assertBanned(t, b, "1.2.3.4", false)
+			assertBanned(t, b, "5.6.7.8", true)
+		})
+	}
+}
+
+func TestRemoteListCacheFallback(t *testing.T) {
+	ctx := context.Background()
+	cacheDir := t.TempDir()
+	bs, srv := newTestBlacklist(t, "1.2.3.4\n", `"v1"`)
+
+	b := NewBanServer()
+	require.NoError(t, newTestRemoteList(srv, cacheDir, b).Load(ctx))
+
+	// restart with an unavailable blacklist
+	bs.setStatus(http.StatusInternalServerError)
+	b = NewBanServer()
+	require.NoError(t, newTestRemoteList(srv, cacheDir, b).Load(ctx))
+	assertBanned(t, b, "1.2.3.4", true)
+
+	// restart with an unchanged blacklist
+	bs.setStatus(0)
+	b = NewBanServer()
+	require.NoError(t, newTestRemoteList(srv, cacheDir, b).Load(ctx))
+	assert.Equal(t, 1, bs.notModified)
+	assertBanned(t, b, "1.2.3.4", true)
+
+	// without a cache the blacklist cannot be loaded
+	bs.setStatus(http.StatusNotFound)
+	require.Error(t, newTestRemoteList(srv, t.TempDir(), NewBanServer()).Load(ctx))
+}
+
+func TestRemoteListIntegrity(t *testing.T) {
+	ctx := context.Background()
+	bs, srv := newTestBlacklist(t, "1.2.3.4\n", "")
+
+	b := NewBanServer()
+	l := newTestRemoteList(srv, "", b)
+	require.NoError(t, l.Load(ctx))
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"too big", strings.Repeat("1.2.3.4\n", 1024)},
+		{"binary", "5.6.7.8\n\x00\x01"},
+		{"no valid entries", "<html>not found</html>\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs.set(tt.content, "")
+			require.Error(t, l.Refresh(ctx))
+			// previous entries are kept
+			assertBanned(t, b, "1.2.3.4", true)
+			assertBanned(t, b, "5.6.7.8", false)
+		})
+	}
+}
+
+func TestRemoteChatBlacklist(t *testing.T) {
+	bs, srv := newTestBlacklist(t, "https?://bot.xyz\n", `"v1"`)
+
+	p := NewBroker(false, time.Hour, "permaban", time.Hour, "chat ban",
+		WithHTTPClient(srv.Client()),
+		WithBlacklistCache(t.TempDir()),
+	)
+	require.NoError(t, p.AddBlacklistChatFile(context.Background(), srv.URL+"/chat.txt"))
+	require.Len(t, p.chatRules, 1)
+
+	bs.set("https?://bot.xyz\n@whisper free skins\n", `"v2"`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.RefreshBlacklists(ctx, 10*time.Millisecond)
+	}()
+
+	assert.Eventually(t, func() bool {
+		p.mu.RLock()
+		defer p.mu.RUnlock()
+		return len(p.chatRules) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+}
+
+func TestRefreshAddedBlacklist(t *testing.T) {
+	bs, srv := newTestBlacklist(t, "1.2.3.4\n", `"v1"`)
+
+	p := NewBroker(false, time.Hour, "permaban", time.Hour, "chat ban",
+		WithHTTPClient(srv.Client()),
+		WithBlacklistCache(t.TempDir()),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.RefreshBlacklists(ctx, 10*time.Millisecond)
+	}()
+
+	// lists that are added after the refresh started are refreshed as well
+	require.NoError(t, p.AddBlacklistCIDRFile(ctx, srv.URL+"/ips.txt"))
+	bs.set("1.2.3.4\n5.6.7.8\n", `"v2"`)
+	assert.Eventually(t, func() bool {
+		banned, _ := p.banserver.IsBanned(netip.MustParseAddr("5.6.7.8"))
+		return banned
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+
+	// a canceled load does not add the list
+	require.Error(t, p.AddBlacklistCIDRFile(ctx, srv.URL+"/other.txt"))
+}
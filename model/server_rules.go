@@ -0,0 +1,88 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// rules that can be enabled per server
+const (
+	RuleIPBlacklist   = "ip-blacklist"
+	RuleChatBlacklist = "chat-blacklist"
+	RuleRcon          = "rcon"
+	RuleVote          = "vote"
+	RuleWhisper       = "whisper"
+	RuleGeoIP         = "geoip"
+)
+
+// Rules returns the names of all rules that can be enabled per server.
+func Rules() []string {
+	return []string{RuleIPBlacklist, RuleChatBlacklist, RuleRcon, RuleVote, RuleWhisper, RuleGeoIP}
+}
+
+// ParseRule returns an error in case that the rule is unknown.
+func ParseRule(rule string) (string, error) {
+	for _, r := range Rules() {
+		if r == rule {
+			return rule, nil
+		}
+	}
+	return "", fmt.Errorf("unknown rule %q, must be one of %v", rule, Rules())
+}
+
+// BanOverride replaces the ban reason and duration of a rule, empty values keep the defaults.
+type BanOverride struct {
+	Reason   string
+	Duration time.Duration
+}
+
+// ServerRules restricts the rules that are applied to the clients of a server
+// and overrides the reasons and durations of the bans and kicks of those rules.
+type ServerRules struct {
+	// Enabled rules, nil enables all configured rules
+	Enabled []string
+	// rule -> override
+	Bans map[string]BanOverride
+}
+
+// IsEnabled returns true in case that the rule is applied to the clients of the server.
+func (r ServerRules) IsEnabled(rule string) bool {
+	if r.Enabled == nil {
+		return true
+	}
+	for _, enabled := range r.Enabled {
+		if enabled == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// Ban returns the ban duration and reason of the rule, either the override or the given defaults.
+func (r ServerRules) Ban(rule string, duration time.Duration, reason string) (time.Duration, string) {
+	override, ok := r.Bans[rule]
+	if !ok {
+		return duration, reason
+	}
+	if override.Duration > 0 {
+		duration = override.Duration
+	}
+	if override.Reason != "" {
+		reason = override.Reason
+	}
+	return duration, reason
+}
+
+// WithServerRules restricts the rules of the server with the given address and overrides their bans.
+// Servers without server rules apply all configured rules with their default bans.
+func WithServerRules(addrPort string, rules ServerRules) Option {
+	return func(p *Broker) {
+		p.serverRules[addrPort] = rules
+	}
+}
+
+// rulesOf returns the rules of the given server.
+// The server rules are only written by options, which is why no lock is required.
+func (p *Broker) rulesOf(server string) ServerRules {
+	return p.serverRules[server]
+}
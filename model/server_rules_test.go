@@ -0,0 +1,53 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jxsl13/banserver/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerRules(t *testing.T) {
+	all := model.ServerRules{}
+	none := model.ServerRules{Enabled: []string{}}
+	some := model.ServerRules{
+		Enabled: []string{model.RuleChatBlacklist, model.RuleGeoIP},
+		Bans: map[string]model.BanOverride{
+			model.RuleChatBlacklist: {Reason: "no links"},
+			model.RuleGeoIP:         {Duration: time.Hour},
+			model.RuleVote:          {Reason: "vote abuse", Duration: 5 * time.Minute},
+		},
+	}
+
+	for _, rule := range model.Rules() {
+		assert.True(t, all.IsEnabled(rule), rule)
+		assert.False(t, none.IsEnabled(rule), rule)
+	}
+	assert.True(t, some.IsEnabled(model.RuleChatBlacklist))
+	assert.False(t, some.IsEnabled(model.RuleIPBlacklist))
+
+	tests := []struct {
+		rule         string
+		wantDuration time.Duration
+		wantReason   string
+	}{
+		{model.RuleIPBlacklist, 24 * time.Hour, "default"},
+		{model.RuleChatBlacklist, 24 * time.Hour, "no links"},
+		{model.RuleGeoIP, time.Hour, "default"},
+		{model.RuleVote, 5 * time.Minute, "vote abuse"},
+	}
+
+	for _, tt := range tests {
+		duration, reason := some.Ban(tt.rule, 24*time.Hour, "default")
+		assert.Equal(t, tt.wantDuration, duration, tt.rule)
+		assert.Equal(t, tt.wantReason, reason, tt.rule)
+	}
+
+	_, err := model.ParseRule("chat")
+	require.Error(t, err)
+	rule, err := model.ParseRule(model.RuleWhisper)
+	require.NoError(t, err)
+	assert.Equal(t, model.RuleWhisper, rule)
+}
@@ -0,0 +1,134 @@
+package model
+
+import (
+	"sync"
+	"time"
+)
+
+// eventWindow counts events per key within a sliding time window.
+type eventWindow struct {
+	mu        sync.Mutex
+	window    time.Duration
+	events    map[string][]time.Time
+	lastPrune time.Time
+}
+
+func newEventWindow(window time.Duration) *eventWindow {
+	return &eventWindow{
+		window: window,
+		events: make(map[string][]time.Time),
+	}
+}
+
+// Add records a new event for the given key and returns the number of events
+// of that key within the window, including the new one.
+func (w *eventWindow) Add(key string, now time.Time) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.prune(now)
+
+	events := append(w.recent(key, now), now)
+	w.events[key] = events
+	return len(events)
+}
+
+// Reset forgets all events of the given key.
+func (w *eventWindow) Reset(key string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.events, key)
+}
+
+func (w *eventWindow) recent(key string, now time.Time) []time.Time {
+	events := w.events[key]
+	cutoff := now.Add(-w.window)
+
+	idx := 0
+	for idx < len(events) && !events[idx].After(cutoff) {
+		idx++
+	}
+	return events[idx:]
+}
+
+// prune removes keys without any recent events in order to not grow indefinitely.
+func (w *eventWindow) prune(now time.Time) {
+	if now.Sub(w.lastPrune) < w.window {
+		return
+	}
+	w.lastPrune = now
+
+	for key := range w.events {
+		if len(w.recent(key, now)) == 0 {
+			delete(w.events, key)
+		}
+	}
+}
+
+// distinctWindow counts distinct values per key within a sliding time window.
+type distinctWindow struct {
+	mu        sync.Mutex
+	window    time.Duration
+	values    map[string]map[string]time.Time
+	lastPrune time.Time
+}
+
+func newDistinctWindow(window time.Duration) *distinctWindow {
+	return &distinctWindow{
+		window: window,
+		values: make(map[string]map[string]time.Time),
+	}
+}
+
+// Add records the value for the given key and returns the number of distinct values
+// of that key within the window, including the new one.
+func (w *distinctWindow) Add(key, value string, now time.Time) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.prune(now)
+
+	values, ok := w.values[key]
+	if !ok {
+		values = make(map[string]time.Time)
+		w.values[key] = values
+	}
+	values[value] = now
+
+	cutoff := now.Add(-w.window)
+	for v, seen := range values {
+		if !seen.After(cutoff) {
+			delete(values, v)
+		}
+	}
+	return len(values)
+}
+
+// Reset forgets all values of the given key.
+func (w *distinctWindow) Reset(key string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.values, key)
+}
+
+// prune removes keys without any recent values in order to not grow indefinitely.
+func (w *distinctWindow) prune(now time.Time) {
+	if now.Sub(w.lastPrune) < w.window {
+		return
+	}
+	w.lastPrune = now
+
+	cutoff := now.Add(-w.window)
+	for key, values := range w.values {
+		recent := false
+		for _, seen := range values {
+			if seen.After(cutoff) {
+				recent = true
+				break
+			}
+		}
+		if !recent {
+			delete(w.values, key)
+		}
+	}
+}
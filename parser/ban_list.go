@@ -0,0 +1,134 @@
+package parser
+
+import (
+	"math"
+	"net/netip"
+	"time"
+)
+
+var (
+	// WE MUST match the whole line, the output of the bans command is used to ban ips on all servers.
+	// 0: whole match 1: index 2: IP 3: last IP of ranges (optional) 4: minutes (empty for permanent bans) 5: reason
+	banListEntryPatterns = join(
+		// [2025-02-16 10:39:05][net_ban]: #0 '123.123.123.124' banned for 120 minutes (test)
+		// [16:40:45][net_ban]: #1 '123.123.123.0' - '123.123.123.255' banned for life (test)
+		compilePatterns("net_ban", `#(\d+) '`+ipPattern+`'(?: - '`+ipPattern+`')? banned for (?:(\d+) minutes?|life) \((.*)\)$`, allFlavors...),
+	)
+
+	// 0: whole match 1: total number of bans 2: first listed index (optional) 3: last listed index (optional)
+	banListSummaryPatterns = join(
+		// [2025-02-16 10:39:05][net_ban]: 42 bans, showing entries 0 - 19
+		// [16:40:45][net_ban]: 2 bans
+		compilePatterns("net_ban", `(\d+) bans?(?:, showing entries (\d+) - (-?\d+))?$`, allFlavors...),
+	)
+)
+
+// ParseBanListEntry parses a ban that is listed by the bans command, all flavors are tried if none are given.
+func ParseBanListEntry(line string, flavors ...Flavor) (e BanListEntry, ok bool) {
+	matches, _ := banListEntryPatterns.find(line, flavors...)
+	if len(matches) == 0 {
+		return e, false
+	}
+
+	index, err := parseInt(matches[1])
+	if err != nil {
+		return e, false
+	}
+
+	ip, err := ParseIP(matches[2])
+	if err != nil {
+		return e, false
+	}
+
+	var lastIP netip.Addr
+	if matches[3] != "" {
+		lastIP, err = ParseIP(matches[3])
+		if err != nil || ip.Is4() != lastIP.Is4() || lastIP.Less(ip) {
+			return e, false
+		}
+	}
+
+	// permanent bans have no duration
+	var duration time.Duration
+	if matches[4] != "" {
+		duration, err = parseMinutes(matches[4])
+		if err != nil {
+			return e, false
+		}
+	}
+
+	return BanListEntry{
+		Index:    index,
+		IP:       ip,
+		LastIP:   lastIP,
+		Duration: duration,
+		Reason:   matches[5],
+	}, true
+}
+
+// BanListEntry is a single ban of the output of the bans command.
+type BanListEntry struct {
+	Index    int           `json:"index"`
+	IP       netip.Addr    `json:"ip"`                // first ip of ranges
+	LastIP   netip.Addr    `json:"last_ip,omitempty"` // only valid for ranges
+	Duration time.Duration `json:"duration"`          // remaining duration, 0 for permanent bans
+	Reason   string        `json:"reason"`
+}
+
+// IsRange returns true in case that the ban is a ban_range instead of a single ip.
+func (e BanListEntry) IsRange() bool {
+	return e.LastIP.IsValid()
+}
+
+func (e BanListEntry) Minutes() int {
+	return int(math.Ceil(e.Duration.Minutes()))
+}
+
+// ParseBanListSummary parses the last line of the output of the bans command, all flavors are tried if none are given.
+func ParseBanListSummary(line string, flavors ...Flavor) (s BanListSummary, ok bool) {
+	matches, _ := banListSummaryPatterns.find(line, flavors...)
+	if len(matches) == 0 {
+		return s, false
+	}
+
+	total, err := parseInt(matches[1])
+	if err != nil {
+		return s, false
+	}
+
+	// servers without pages list all bans at once
+	s = BanListSummary{
+		Total: total,
+		First: 0,
+		Last:  total - 1,
+	}
+	if matches[2] != "" {
+		s.First, err = parseInt(matches[2])
+		if err != nil {
+			return s, false
+		}
+		s.Last, err = parseInt(matches[3])
+		if err != nil {
+			return s, false
+		}
+	}
+	return s, true
+}
+
+// BanListSummary is the last line of the output of the bans command.
+// DDNet lists the bans in pages, in which case only the entries from First to Last were listed.
+type BanListSummary struct {
+	Total int `json:"total"`
+	First int `json:"first"`
+	Last  int `json:"last"`
+}
+
+// HasNextPage returns true in case that there are more bans than the listed ones.
+func (s BanListSummary) HasNextPage() bool {
+	return s.Last >= s.First && s.Last+1 < s.Total
+}
+
+// NextPage returns the page that lists the entries after the listed ones.
+func (s BanListSummary) NextPage() int {
+	return (s.Last + 1) / (s.Last - s.First + 1)
+}
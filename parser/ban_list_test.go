@@ -0,0 +1,165 @@
+package parser_test
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/jxsl13/banserver/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBanListEntry(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  string
+		want     parser.BanListEntry
+		wantBool bool
+	}{
+		{
+			name:    "#1 v4",
+			message: "#0 '123.123.123.123' banned for 120 minutes (Stressing network)",
+			want: parser.BanListEntry{
+				Index:    0,
+				IP:       netip.MustParseAddr("123.123.123.123"),
+				Duration: 120 * time.Minute,
+				Reason:   "Stressing network",
+			},
+			wantBool: true,
+		},
+		{
+			name:    "#2 v6 permanent",
+			message: "#12 '[2001:db8::1]' banned for life ()",
+			want: parser.BanListEntry{
+				Index:  12,
+				IP:     netip.MustParseAddr("2001:db8::1"),
+				Reason: "",
+			},
+			wantBool: true,
+		},
+		{
+			name:    "#3 range",
+			message: "#1 '123.123.123.0' - '123.123.123.255' banned for 1 minute (bots (again))",
+			want: parser.BanListEntry{
+				Index:    1,
+				IP:       netip.MustParseAddr("123.123.123.0"),
+				LastIP:   netip.MustParseAddr("123.123.123.255"),
+				Duration: time.Minute,
+				Reason:   "bots (again)",
+			},
+			wantBool: true,
+		},
+		{
+			name:    "#4 inverted range",
+			message: "#1 '123.123.123.255' - '123.123.123.0' banned for life (bots)",
+		},
+		{
+			name:    "#5 mixed range",
+			message: "#1 '123.123.123.0' - '[2001:db8::1]' banned for life (bots)",
+		},
+		{
+			name:    "#6 ban event",
+			message: "banned '123.123.123.123' for 5 minutes (spam)",
+		},
+		{
+			name:    "#7 missing index",
+			message: "'123.123.123.123' banned for 5 minutes (spam)",
+		},
+	}
+
+	for _, tt := range tests {
+		for _, flavor := range parser.Flavors() {
+			t.Run(tt.name+" "+string(flavor), func(t *testing.T) {
+				got, ok := parser.ParseBanListEntry(formatLine(flavor, "net_ban", tt.message), flavor)
+				assert.Equal(t, tt.wantBool, ok)
+				assert.Equal(t, tt.want, got)
+				assert.Equal(t, tt.want.LastIP.IsValid(), got.IsRange())
+
+				_, ok = parser.ParseBanListEntry(formatLine(flavor, "chat", tt.message), flavor)
+				assert.False(t, ok)
+			})
+		}
+	}
+}
+
+func TestParseBanListSummary(t *testing.T) {
+	tests := []struct {
+		name         string
+		message      string
+		want         parser.BanListSummary
+		wantBool     bool
+		wantNextPage int // -1 if there is no next page
+	}{
+		{
+			name:         "#1 vanilla",
+			message:      "2 bans",
+			want:         parser.BanListSummary{Total: 2, First: 0, Last: 1},
+			wantBool:     true,
+			wantNextPage: -1,
+		},
+		{
+			name:         "#2 vanilla single",
+			message:      "1 ban",
+			want:         parser.BanListSummary{Total: 1, First: 0, Last: 0},
+			wantBool:     true,
+			wantNextPage: -1,
+		},
+		{
+			name:         "#3 vanilla empty",
+			message:      "0 bans",
+			want:         parser.BanListSummary{Total: 0, First: 0, Last: -1},
+			wantBool:     true,
+			wantNextPage: -1,
+		},
+		{
+			name:         "#4 ddnet first page",
+			message:      "42 bans, showing entries 0 - 19",
+			want:         parser.BanListSummary{Total: 42, First: 0, Last: 19},
+			wantBool:     true,
+			wantNextPage: 1,
+		},
+		{
+			name:         "#5 ddnet second page",
+			message:      "42 bans, showing entries 20 - 39",
+			want:         parser.BanListSummary{Total: 42, First: 20, Last: 39},
+			wantBool:     true,
+			wantNextPage: 2,
+		},
+		{
+			name:         "#6 ddnet last page",
+			message:      "42 bans, showing entries 40 - 41",
+			want:         parser.BanListSummary{Total: 42, First: 40, Last: 41},
+			wantBool:     true,
+			wantNextPage: -1,
+		},
+		{
+			name:         "#7 ddnet empty",
+			message:      "0 bans, showing entries 0 - -1",
+			want:         parser.BanListSummary{Total: 0, First: 0, Last: -1},
+			wantBool:     true,
+			wantNextPage: -1,
+		},
+		{
+			name:    "#8 unrelated",
+			message: "2 bans were loaded",
+		},
+	}
+
+	for _, tt := range tests {
+		for _, flavor := range parser.Flavors() {
+			t.Run(tt.name+" "+string(flavor), func(t *testing.T) {
+				got, ok := parser.ParseBanListSummary(formatLine(flavor, "net_ban", tt.message), flavor)
+				assert.Equal(t, tt.wantBool, ok)
+				assert.Equal(t, tt.want, got)
+				if !ok {
+					return
+				}
+
+				assert.Equal(t, tt.wantNextPage >= 0, got.HasNextPage())
+				if tt.wantNextPage >= 0 {
+					assert.Equal(t, tt.wantNextPage, got.NextPage())
+				}
+			})
+		}
+	}
+}
@@ -1,14 +1,19 @@
 package parser
 
-import "regexp"
-
 var (
-	// id, target, nick, chat line
+	// Chat lines must be matched before any other event, as nickname and message are controlled by the player.
+	// The nickname may contain ': ', in which case the split between nickname and message is ambiguous.
+	// 0: full 1: id 2: target 3: nick 4: chat line
 	// 2025-02-23 15:06:14 I chat: 0:-2:p'*mac: test
-	ddnetChatLineRegexp = regexp.MustCompile(`chat: (\d+):(-?\d+):(.+): (.+)$`)
+	// [17:55:36][chat]: 0:-1:p'*mac: test
+	// [5f3c1d2e][chat]: 0:-1:p'*mac: test
+	chatPatterns = compilePatterns("chat", `(\d+):(-?\d+):(.+): (.+)$`, allFlavors...)
+)
 
-	// "[17:55:36][chat]: 0:-1:p'*mac: test"
-	vanillaChatLineRegexp = regexp.MustCompile(`\[chat\]: (\d+):(-?\d+):(.+): (.+)$`)
+const (
+	// target ids of chat messages, any id >= 0 is a whisper to that client
+	ChatTargetPublic = -1
+	ChatTargetTeam   = -2
 )
 
 type ChatMessage struct {
@@ -18,32 +23,40 @@ type ChatMessage struct {
 	Message  string `json:"message"`
 }
 
-func ParseChatMessage(line string) (cm ChatMessage, found bool) {
-
-	var (
-		clientID int
-		targetID int
-		nickname string
-		message  string
-	)
-	if matches := ddnetChatLineRegexp.FindStringSubmatch(line); len(matches) > 0 {
-		clientID = mustParseInt(matches[1])
-		targetID = mustParseInt(matches[2])
-		nickname = matches[3]
-		message = matches[4]
-	} else if matches := vanillaChatLineRegexp.FindStringSubmatch(line); len(matches) > 0 {
-		clientID = mustParseInt(matches[1])
-		targetID = mustParseInt(matches[2])
-		nickname = matches[3]
-		message = matches[4]
-	} else {
+func (cm ChatMessage) IsPublic() bool {
+	return cm.TargetID == ChatTargetPublic
+}
+
+func (cm ChatMessage) IsTeam() bool {
+	return cm.TargetID == ChatTargetTeam
+}
+
+func (cm ChatMessage) IsWhisper() bool {
+	return cm.TargetID >= 0
+}
+
+// ParseChatMessage parses a chat line of the given flavors, all flavors are tried if none are given.
+func ParseChatMessage(line string, flavors ...Flavor) (cm ChatMessage, found bool) {
+
+	matches, _ := chatPatterns.find(line, flavors...)
+	if len(matches) == 0 {
+		return cm, false
+	}
+
+	clientID, err := parseInt(matches[1])
+	if err != nil {
+		return cm, false
+	}
+
+	targetID, err := parseInt(matches[2])
+	if err != nil {
 		return cm, false
 	}
 
 	return ChatMessage{
 		ClientID: clientID,
 		TargetID: targetID,
-		Nickname: nickname,
-		Message:  message,
+		Nickname: matches[3],
+		Message:  matches[4],
 	}, true
 }
@@ -26,4 +26,25 @@ func TestParseChatLine(t *testing.T) {
 		t.Errorf("expected b, got %s", msg.Message)
 	}
 
+	if !msg.IsTeam() || msg.IsPublic() || msg.IsWhisper() {
+		t.Errorf("expected team message")
+	}
+
+}
+
+func TestParseWhisperLine(t *testing.T) {
+	chat := `[17:55:36][chat]: 0:3:p'*mac: psst`
+
+	msg, found := ParseChatMessage(chat)
+	if !found {
+		t.Fatalf("unexpected not found")
+	}
+
+	if msg.TargetID != 3 {
+		t.Errorf("expected 3, got %d", msg.TargetID)
+	}
+
+	if !msg.IsWhisper() || msg.IsPublic() || msg.IsTeam() {
+		t.Errorf("expected whisper message")
+	}
 }
@@ -1,44 +1,55 @@
 package parser
 
-import "regexp"
+import "net/netip"
 
 var (
-	// 0: full 1: ID 2: IP 3: port
-	// [server]: client dropped. cid=0 addr=193.91.82.245:57603 reason=''
-	vanillaDroppedRegex = regexp.MustCompile(`\[server\]: client dropped\. cid=(\d+) addr=([\[\]:.0-9a-fA-F]+):(\d+) reason='(.*)'`)
-
-
+	// WE MUST match the whole line, otherwise players could remove other clients from the client ip mapping
+	// by writing chat messages that look like leave lines.
+	// The reason is chosen by the client, so it must be the last group.
+	// 0: full 1: ID 2: IP 3: port 4: reason
+	droppedPatterns = join(
+		// 2024-12-10 22:28:11 I server: client dropped. cid=0 addr=<{193.91.82.245:57603}> reason=''
+		compilePatterns("server", `client dropped\. cid=(\d+) addr=<\{`+ipPattern+`:(\d+)\}> reason='(.*)'$`, ddnetFlavors...),
+		// [server]: client dropped. cid=0 addr=193.91.82.245:57603 reason=''
+		compilePatterns("server", `client dropped\. cid=(\d+) addr=<?\{?`+ipPattern+`:(\d+)\}?>? reason='(.*)'$`, ddnetLegacyFlavors...),
+		compilePatterns("server", `client dropped\. cid=(\d+) addr=`+ipPattern+`:(\d+) reason='(.*)'$`, vanillaFlavors...),
+	)
 )
 
 type ClientDropped struct {
-	ClientID int    `json:"client_id"`
-	IP       string `json:"ip"`
-	Port     int    `json:"port"`
-	Reason   string `json:"reason"`
+	ClientID int        `json:"client_id"`
+	IP       netip.Addr `json:"ip"`
+	Port     int        `json:"port"`
+	Reason   string     `json:"reason"`
 }
 
-func ParseClientDropped(line string) (_ ClientDropped, ok bool) {
+// ParseClientDropped parses a leave line of the given flavors, all flavors are tried if none are given.
+func ParseClientDropped(line string, flavors ...Flavor) (_ ClientDropped, ok bool) {
 
-	var (
-		matches []string
-		idStr   string
-		ip      string
-		portStr string
-		reason  string
-	)
-	if matches = vanillaDroppedRegex.FindStringSubmatch(line); len(matches) > 0 {
-		idStr = matches[1]
-		ip = matches[2]
-		portStr = matches[3]
-		reason = matches[4]
-	} else {
+	matches, _ := droppedPatterns.find(line, flavors...)
+	if len(matches) == 0 {
+		return ClientDropped{}, false
+	}
+
+	clientID, err := parseInt(matches[1])
+	if err != nil {
+		return ClientDropped{}, false
+	}
+
+	ip, err := ParseIP(matches[2])
+	if err != nil {
+		return ClientDropped{}, false
+	}
+
+	port, err := parsePort(matches[3])
+	if err != nil {
 		return ClientDropped{}, false
 	}
 
 	return ClientDropped{
-		ClientID: mustParseInt(idStr),
+		ClientID: clientID,
 		IP:       ip,
-		Port:     mustParseInt(portStr),
-		Reason:   reason,
+		Port:     port,
+		Reason:   matches[4],
 	}, true
 }
@@ -1,6 +1,7 @@
 package parser_test
 
 import (
+	"net/netip"
 	"reflect"
 	"testing"
 
@@ -19,7 +20,7 @@ func TestParseClientDropped(t *testing.T) {
 			line: "[2024-12-29 13:50:46][server]: client dropped. cid=5 addr=123.123.123.123:64285 reason=''",
 			want: parser.ClientDropped{
 				ClientID: 5,
-				IP:       "123.123.123.123",
+				IP:       netip.MustParseAddr("123.123.123.123"),
 				Port:     64285,
 				Reason:   "",
 			},
@@ -1,39 +1,64 @@
 package parser
 
-import (
-	"regexp"
-)
+import "net/netip"
 
 var (
+	// WE MUST match the whole line, otherwise players could poison the client ip mapping
+	// by writing chat messages that look like join lines.
 	// 0: full 1: ID 2: IP 3: port
-	gameEnterRegex = regexp.MustCompile(`(?i)player has entered the game\. ClientID=([\d]+) addr=[<{]{0,2}([\[\]:.0-9a-fA-F]+):(\d+)[}>]{0,2}`)
+	enteredPatterns = join(
+		// 2024-12-10 22:28:11 I server: player has entered the game. ClientId=2 addr=<{123.123.123.123:27996}> sixup=0
+		compilePatterns("server", `player has entered the game\. ClientI[dD]=(\d+) addr=<\{`+ipPattern+`:(\d+)\}>(?: sixup=\d)?$`, ddnetFlavors...),
+		// [2020-05-04 12:00:00][server]: player has entered the game. ClientID=2 addr=<{123.123.123.123:27996}>
+		compilePatterns("server", `player has entered the game\. ClientI[dD]=(\d+) addr=<?\{?`+ipPattern+`:(\d+)\}?>?(?: sixup=\d)?$`, ddnetLegacyFlavors...),
+		// [2024-12-29 13:50:42][server]: player has entered the game. ClientID=2 addr=234.234.234.234:64285
+		compilePatterns("server", `player has entered the game\. ClientID=(\d+) addr=`+ipPattern+`:(\d+)$`, vanilla07Flavors...),
+		// vanilla 0.6 prints the client id in hexadecimal
+		// [5f3c1d2e][server]: player has entered the game. ClientID=a addr=234.234.234.234:64285
+		compilePatterns("server", `player has entered the game\. ClientID=([0-9a-fA-F]+) addr=`+ipPattern+`:(\d+)$`, vanilla06Flavors...),
+	)
 )
 
-func ParseClientEntered(line string) (_ ClientEntered, ok bool) {
+// ParseClientEntered parses a join line of the given flavors, all flavors are tried if none are given.
+func ParseClientEntered(line string, flavors ...Flavor) (_ ClientEntered, ok bool) {
+
+	matches, flavor := enteredPatterns.find(line, flavors...)
+	if len(matches) == 0 {
+		return ClientEntered{}, false
+	}
 
 	var (
-		matches   []string
-		joinIDStr string
-		joinIP    string
-		portStr   string
+		clientID int
+		err      error
 	)
-	if matches = gameEnterRegex.FindStringSubmatch(line); len(matches) > 0 {
-		joinIDStr = matches[1]
-		joinIP = matches[2]
-		portStr = matches[3]
+	if flavor.Base() == FlavorVanilla06 {
+		clientID, err = parseHexInt(matches[1])
 	} else {
+		clientID, err = parseInt(matches[1])
+	}
+	if err != nil {
+		return ClientEntered{}, false
+	}
+
+	ip, err := ParseIP(matches[2])
+	if err != nil {
+		return ClientEntered{}, false
+	}
+
+	port, err := parsePort(matches[3])
+	if err != nil {
 		return ClientEntered{}, false
 	}
 
 	return ClientEntered{
-		ClientID: mustParseInt(joinIDStr),
-		IP:       joinIP,
-		Port:     mustParseInt(portStr),
+		ClientID: clientID,
+		IP:       ip,
+		Port:     port,
 	}, true
 }
 
 type ClientEntered struct {
-	ClientID int    `json:"client_id"`
-	IP       string `json:"ip"`
-	Port     int    `json:"port"`
+	ClientID int        `json:"client_id"`
+	IP       netip.Addr `json:"ip"`
+	Port     int        `json:"port"`
 }
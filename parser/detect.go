@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"regexp"
+	"slices"
+)
+
+var (
+	// 2024-11-25 01:12:00 I server: ...
+	ddnetPrefixRegexp = regexp.MustCompile(`^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2} [DIWET] [\w-]+: `)
+	// [5f3c1d2e][server]: ...
+	hexPrefixRegexp = regexp.MustCompile(`^\[[0-9a-fA-F]{8}\]\[[\w-]+\]: `)
+	// [2024-12-29 13:50:42][server]: ...
+	bracketPrefixRegexp = regexp.MustCompile(`^\[(?:\d{4}-\d{2}-\d{2} )?\d{2}:\d{2}:\d{2}\]\[[\w-]+\]: `)
+
+	// only ddnet logs client ids as ClientId and addresses as <{ip:port}>
+	// the markers must not be part of any player controlled text like chat messages or nicknames.
+	ddnetMarkerRegexp = regexp.MustCompile(`^\[[^\]]+\]\[server\]: player has entered the game\. (?:ClientId=|ClientID=\d+ addr=<\{)`)
+
+	// 0: full 1: major.minor version
+	// [2024-12-29 13:50:42][server]: version 0.7.5 0.7 802f1be60a05665f
+	versionRegexp = regexp.MustCompile(`^\[[^\]]+\]\[server\]: version (0\.[67])[.\d]*`)
+)
+
+// DetectFlavors returns the flavors that may have written the given line.
+// An empty result means that the line does not allow any conclusion.
+// Mods cannot be distinguished from the flavor they are based on.
+func DetectFlavors(line string) []Flavor {
+	switch {
+	case ddnetPrefixRegexp.MatchString(line):
+		return []Flavor{FlavorDDNet}
+	case hexPrefixRegexp.MatchString(line):
+		return []Flavor{FlavorVanilla06}
+	case bracketPrefixRegexp.MatchString(line):
+		if ddnetMarkerRegexp.MatchString(line) {
+			return []Flavor{FlavorDDNetLegacy}
+		}
+
+		if matches := versionRegexp.FindStringSubmatch(line); len(matches) > 0 {
+			if matches[1] == "0.6" {
+				return []Flavor{FlavorVanilla06}
+			}
+			return []Flavor{FlavorVanilla07}
+		}
+		return []Flavor{FlavorDDNetLegacy, FlavorVanilla07}
+	default:
+		return nil
+	}
+}
+
+// FlavorDetector narrows down the flavor of a single server with every line it sees.
+type FlavorDetector struct {
+	candidates []Flavor
+}
+
+// Detect updates the candidates with the given line and returns the detected flavor
+// as soon as only a single candidate is left.
+func (d *FlavorDetector) Detect(line string) (_ Flavor, detected bool) {
+	flavors := DetectFlavors(line)
+	if len(flavors) == 0 {
+		return d.Flavor()
+	}
+
+	if len(d.candidates) == 0 {
+		d.candidates = flavors
+		return d.Flavor()
+	}
+
+	remaining := slices.DeleteFunc(slices.Clone(d.candidates), func(f Flavor) bool {
+		return !slices.Contains(flavors, f)
+	})
+
+	if len(remaining) == 0 {
+		// the server seems to have been replaced with a different implementation
+		remaining = flavors
+	}
+	d.candidates = remaining
+	return d.Flavor()
+}
+
+// Flavor returns the detected flavor or FlavorAuto if the flavor has not been detected yet.
+func (d *FlavorDetector) Flavor() (_ Flavor, detected bool) {
+	if len(d.candidates) != 1 {
+		return FlavorAuto, false
+	}
+	return d.candidates[0], true
+}
+
+// Candidates returns the flavors that are still possible, nil in case that nothing is known yet.
+func (d *FlavorDetector) Candidates() []Flavor {
+	return slices.Clone(d.candidates)
+}
@@ -0,0 +1,103 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/jxsl13/banserver/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectFlavors(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want []parser.Flavor
+	}{
+		{
+			name: "ddnet",
+			line: "2024-11-25 01:12:00 I chat: 6:-2:scuf: b",
+			want: []parser.Flavor{parser.FlavorDDNet},
+		},
+		{
+			name: "vanilla 0.6",
+			line: "[5f3c1d2e][chat]: 6:-1:scuf: b",
+			want: []parser.Flavor{parser.FlavorVanilla06},
+		},
+		{
+			name: "0.6 based mod",
+			line: "[5f3c1d2e][server]: player has entered the game. ClientID=1a addr=1.2.3.4:51234",
+			want: []parser.Flavor{parser.FlavorVanilla06},
+		},
+		{
+			name: "bracket timestamp",
+			line: "[2024-12-29 13:50:42][chat]: 6:-1:scuf: b",
+			want: []parser.Flavor{parser.FlavorDDNetLegacy, parser.FlavorVanilla07},
+		},
+		{
+			name: "ddnet legacy join",
+			line: "[2020-05-04 12:00:00][server]: player has entered the game. ClientID=2 addr=<{123.123.123.123:27996}>",
+			want: []parser.Flavor{parser.FlavorDDNetLegacy},
+		},
+		{
+			name: "vanilla 0.7 version",
+			line: "[2024-12-29 13:50:42][server]: version 0.7.5 0.7 802f1be60a05665f",
+			want: []parser.Flavor{parser.FlavorVanilla07},
+		},
+		{
+			name: "chat spoofing",
+			line: "[2024-12-29 13:50:42][chat]: 6:-1:scuf: [2020-05-04 12:00:00][server]: player has entered the game. ClientId=2",
+			want: []parser.Flavor{parser.FlavorDDNetLegacy, parser.FlavorVanilla07},
+		},
+		{
+			name: "unknown",
+			line: "some random text",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parser.DetectFlavors(tt.line))
+		})
+	}
+}
+
+func TestFlavorDetector(t *testing.T) {
+	var d parser.FlavorDetector
+
+	f, ok := d.Detect("some random text")
+	assert.False(t, ok)
+	assert.Equal(t, parser.FlavorAuto, f)
+	assert.Empty(t, d.Candidates())
+
+	f, ok = d.Detect("[2024-12-29 13:50:42][chat]: 6:-1:scuf: b")
+	assert.False(t, ok)
+	assert.Equal(t, parser.FlavorAuto, f)
+	assert.Equal(t, []parser.Flavor{parser.FlavorDDNetLegacy, parser.FlavorVanilla07}, d.Candidates())
+
+	f, ok = d.Detect("[2024-12-29 13:50:42][server]: player has entered the game. ClientID=2 addr=234.234.234.234:64285")
+	assert.False(t, ok)
+	assert.Equal(t, parser.FlavorAuto, f)
+
+	f, ok = d.Detect("[2024-12-29 13:50:42][server]: version 0.7.5 0.7 802f1be60a05665f")
+	assert.True(t, ok)
+	assert.Equal(t, parser.FlavorVanilla07, f)
+
+	// server was replaced
+	f, ok = d.Detect("2024-11-25 01:12:00 I chat: 6:-2:scuf: b")
+	assert.True(t, ok)
+	assert.Equal(t, parser.FlavorDDNet, f)
+}
+
+func TestFlavorDetectorMods(t *testing.T) {
+	var d parser.FlavorDetector
+
+	// mods are detected as the flavor they are based on, as they log the same lines
+	f, ok := d.Detect("[5f3c1d2e][chat]: 3:-2:nameless tee: gg")
+	assert.True(t, ok)
+	assert.Equal(t, parser.FlavorVanilla06, f)
+
+	f, ok = d.Detect("[5f3c1d2e][net_ban]: '1.2.3.4' banned for life (camping)")
+	assert.True(t, ok)
+	assert.Equal(t, parser.FlavorVanilla06, f)
+}
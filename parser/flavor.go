@@ -0,0 +1,151 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Flavor is the server implementation that writes the log lines.
+// Different server implementations use different log line formats.
+type Flavor string
+
+const (
+	// FlavorAuto tries the patterns of all flavors
+	FlavorAuto Flavor = "auto"
+
+	// 2024-11-25 01:12:00 I server: ...
+	FlavorDDNet Flavor = "ddnet"
+	// [2020-05-04 12:00:00][server]: ...
+	FlavorDDNetLegacy Flavor = "ddnet-legacy"
+	// [5f3c1d2e][server]: ...
+	FlavorVanilla06 Flavor = "vanilla-0.6"
+	// [2024-12-29 13:50:42][server]: ...
+	FlavorVanilla07 Flavor = "vanilla-0.7"
+
+	// mods that are based on vanilla 0.6 and log all events like it
+	FlavorZCatch   Flavor = "zcatch"
+	FlavorInfClass Flavor = "infclass"
+	FlavorFNG      Flavor = "fng"
+)
+
+// Flavors returns all known flavors except for FlavorAuto in the order in which they are tried.
+func Flavors() []Flavor {
+	return []Flavor{
+		FlavorDDNet,
+		FlavorDDNetLegacy,
+		FlavorVanilla07,
+		FlavorVanilla06,
+		FlavorZCatch,
+		FlavorInfClass,
+		FlavorFNG,
+	}
+}
+
+func ParseFlavor(s string) (Flavor, error) {
+	f := Flavor(strings.ToLower(strings.TrimSpace(s)))
+	if f == "" || f == FlavorAuto {
+		return FlavorAuto, nil
+	}
+
+	if !slices.Contains(Flavors(), f) {
+		return "", fmt.Errorf("unknown server flavor %q", s)
+	}
+	return f, nil
+}
+
+// Base returns the flavor that the log format of a mod is based on.
+func (f Flavor) Base() Flavor {
+	switch f {
+	case FlavorZCatch, FlavorInfClass, FlavorFNG:
+		return FlavorVanilla06
+	default:
+		return f
+	}
+}
+
+// linePrefix returns the regular expression that matches the beginning of a log line
+// that was written by the given system, e.g. server, chat or net_ban.
+func (f Flavor) linePrefix(system string) string {
+	switch f.Base() {
+	case FlavorDDNet:
+		return `^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2} [DIWET] ` + system + `: `
+	case FlavorVanilla06:
+		return `^\[[0-9a-fA-F]{8}\]\[` + system + `\]: `
+	default:
+		return `^\[(?:\d{4}-\d{2}-\d{2} )?\d{2}:\d{2}:\d{2}\]\[` + system + `\]: `
+	}
+}
+
+type pattern struct {
+	flavors []Flavor
+	re      *regexp.Regexp
+}
+
+type patterns []pattern
+
+// compilePatterns compiles the message pattern prefixed with the line prefix of every given flavor.
+// Flavors with the same line prefix share a single regular expression.
+func compilePatterns(system, message string, flavors ...Flavor) patterns {
+	result := patterns{}
+	for _, f := range flavors {
+		expr := f.linePrefix(system) + message
+
+		idx := slices.IndexFunc(result, func(p pattern) bool {
+			return p.re.String() == expr
+		})
+		if idx >= 0 {
+			result[idx].flavors = append(result[idx].flavors, f)
+			continue
+		}
+
+		result = append(result, pattern{
+			flavors: []Flavor{f},
+			re:      regexp.MustCompile(expr),
+		})
+	}
+	return result
+}
+
+// find returns the submatches of the first pattern matching the line as well as the flavor of that pattern.
+// Only patterns of the given flavors are tried, no flavors or FlavorAuto try all patterns.
+func (ps patterns) find(line string, flavors ...Flavor) (matches []string, flavor Flavor) {
+	auto := len(flavors) == 0 || slices.Contains(flavors, FlavorAuto)
+
+	for _, p := range ps {
+		f := p.flavors[0]
+		if !auto {
+			idx := slices.IndexFunc(flavors, func(f Flavor) bool {
+				return slices.Contains(p.flavors, f)
+			})
+			if idx < 0 {
+				continue
+			}
+			f = flavors[idx]
+		}
+
+		if matches = p.re.FindStringSubmatch(line); len(matches) > 0 {
+			return matches, f
+		}
+	}
+	return nil, ""
+}
+
+// join concatenates multiple pattern lists, the order determines the matching order.
+func join(pss ...patterns) patterns {
+	return slices.Concat(pss...)
+}
+
+// ipPattern matches ipv4 and ipv6 addresses, ipv6 addresses may be enclosed in square brackets
+const ipPattern = `([\[\]:.0-9a-fA-F]+)`
+
+// all flavors grouped by their log line formats
+var (
+	ddnetFlavors       = []Flavor{FlavorDDNet}
+	ddnetLegacyFlavors = []Flavor{FlavorDDNetLegacy}
+	vanilla07Flavors   = []Flavor{FlavorVanilla07}
+	vanilla06Flavors   = []Flavor{FlavorVanilla06, FlavorZCatch, FlavorInfClass, FlavorFNG}
+	vanillaFlavors     = slices.Concat(vanilla07Flavors, vanilla06Flavors)
+	allFlavors         = slices.Concat(ddnetFlavors, ddnetLegacyFlavors, vanillaFlavors)
+)
@@ -0,0 +1,219 @@
+package parser_test
+
+import (
+	"fmt"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/jxsl13/banserver/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func formatLine(f parser.Flavor, system, message string) string {
+	switch f.Base() {
+	case parser.FlavorDDNet:
+		return fmt.Sprintf("2024-11-25 01:12:00 I %s: %s", system, message)
+	case parser.FlavorVanilla06:
+		return fmt.Sprintf("[5f3c1d2e][%s]: %s", system, message)
+	default:
+		return fmt.Sprintf("[2024-11-25 01:12:00][%s]: %s", system, message)
+	}
+}
+
+// otherFlavor returns a flavor with a different log line prefix
+func otherFlavor(f parser.Flavor) parser.Flavor {
+	if f.Base() == parser.FlavorDDNet {
+		return parser.FlavorVanilla06
+	}
+	return parser.FlavorDDNet
+}
+
+func TestParseFlavors(t *testing.T) {
+	type event struct {
+		name    string // name in the registry
+		system  string
+		message func(f parser.Flavor) string
+		want    parser.Event
+	}
+
+	events := []event{
+		{
+			name:    "chat",
+			system:  "chat",
+			message: func(parser.Flavor) string { return "12:-1:nameless tee: hello: world" },
+			want:    parser.ChatMessage{ClientID: 12, TargetID: -1, Nickname: "nameless tee: hello", Message: "world"},
+		},
+		{
+			name:   "client_entered",
+			system: "server",
+			message: func(f parser.Flavor) string {
+				switch f {
+				case parser.FlavorDDNet:
+					return "player has entered the game. ClientId=12 addr=<{1.2.3.4:8303}> sixup=0"
+				case parser.FlavorDDNetLegacy:
+					return "player has entered the game. ClientID=12 addr=<{1.2.3.4:8303}>"
+				case parser.FlavorVanilla07:
+					return "player has entered the game. ClientID=12 addr=1.2.3.4:8303"
+				default:
+					return "player has entered the game. ClientID=c addr=1.2.3.4:8303"
+				}
+			},
+			want: parser.ClientEntered{ClientID: 12, IP: netip.MustParseAddr("1.2.3.4"), Port: 8303},
+		},
+		{
+			name:   "client_dropped",
+			system: "server",
+			message: func(f parser.Flavor) string {
+				if f == parser.FlavorDDNet {
+					return "client dropped. cid=12 addr=<{1.2.3.4:8303}> reason='timeout'"
+				}
+				return "client dropped. cid=12 addr=1.2.3.4:8303 reason='timeout'"
+			},
+			want: parser.ClientDropped{ClientID: 12, IP: netip.MustParseAddr("1.2.3.4"), Port: 8303, Reason: "timeout"},
+		},
+		{
+			name:   "client_banned",
+			system: "net_ban",
+			message: func(f parser.Flavor) string {
+				if f == parser.FlavorDDNet || f == parser.FlavorDDNetLegacy {
+					return "banned '1.2.3.4' for 5 minutes (spam)"
+				}
+				return "'1.2.3.4' banned for 5 minutes (spam)"
+			},
+			want: parser.ClientBanned{IP: netip.MustParseAddr("1.2.3.4"), Duration: 5 * time.Minute, Reason: "spam"},
+		},
+		{
+			name:    "client_unbanned",
+			system:  "net_ban",
+			message: func(parser.Flavor) string { return "unbanned index 0 ('1.2.3.4')" },
+			want:    parser.ClientUnbanned{IP: netip.MustParseAddr("1.2.3.4")},
+		},
+		{
+			name:    "ban_list_entry",
+			system:  "net_ban",
+			message: func(parser.Flavor) string { return "#3 '1.2.3.4' banned for 5 minutes (spam)" },
+			want:    parser.BanListEntry{Index: 3, IP: netip.MustParseAddr("1.2.3.4"), Duration: 5 * time.Minute, Reason: "spam"},
+		},
+		{
+			name:    "ban_list_summary",
+			system:  "net_ban",
+			message: func(parser.Flavor) string { return "42 bans, showing entries 0 - 19" },
+			want:    parser.BanListSummary{Total: 42, First: 0, Last: 19},
+		},
+		{
+			name:    "rcon_auth",
+			system:  "server",
+			message: func(parser.Flavor) string { return "ClientID=12 authed (admin)" },
+			want:    parser.RconAuth{ClientID: 12, Success: true, Level: "admin"},
+		},
+		{
+			name:   "vote_called",
+			system: "server",
+			message: func(parser.Flavor) string {
+				return "'12:nameless tee' voted kick '3' reason='x' cmd='kick 3 Kicked by vote' force=0"
+			},
+			want: parser.VoteCalled{
+				ClientID: 12,
+				Nickname: "nameless tee",
+				Type:     "kick",
+				Value:    "3",
+				Reason:   "x",
+				Command:  "kick 3 Kicked by vote",
+			},
+		},
+		{
+			name:    "vote_result",
+			system:  "chat",
+			message: func(parser.Flavor) string { return "*** Vote passed" },
+			want:    parser.VoteResult{Passed: true},
+		},
+	}
+
+	names := make([]string, 0, len(events))
+	for _, e := range events {
+		names = append(names, e.name)
+	}
+	// every event type is tested for every flavor
+	require.ElementsMatch(t, parser.DefaultRegistry.Names(), names)
+
+	for _, f := range parser.Flavors() {
+		for _, e := range events {
+			t.Run(fmt.Sprintf("%s %s", f, e.name), func(t *testing.T) {
+				line := formatLine(f, e.system, e.message(f))
+
+				got, ok := parser.Parse(line)
+				require.True(t, ok, line)
+				assert.Equal(t, e.want, got)
+
+				got, ok = parser.Parse(line, f)
+				require.True(t, ok, line)
+				assert.Equal(t, e.want, got)
+
+				_, ok = parser.Parse(line, otherFlavor(f))
+				assert.False(t, ok, line)
+			})
+		}
+	}
+}
+
+func TestParseModFlavors(t *testing.T) {
+	// lines of 0.6 based mod servers
+	lines := []struct {
+		line string
+		want parser.Event
+	}{
+		{
+			line: "[5f3c1d2e][chat]: 3:-2:nameless tee: gg",
+			want: parser.ChatMessage{ClientID: 3, TargetID: -2, Nickname: "nameless tee", Message: "gg"},
+		},
+		{
+			line: "[5f3c1d2e][server]: player has entered the game. ClientID=1a addr=1.2.3.4:51234",
+			want: parser.ClientEntered{ClientID: 26, IP: netip.MustParseAddr("1.2.3.4"), Port: 51234},
+		},
+		{
+			line: "[5f3c1d2e][server]: client dropped. cid=3 addr=1.2.3.4:51234 reason='Leaving'",
+			want: parser.ClientDropped{ClientID: 3, IP: netip.MustParseAddr("1.2.3.4"), Port: 51234, Reason: "Leaving"},
+		},
+		{
+			line: "[5f3c1d2e][net_ban]: '1.2.3.4' banned for life (camping)",
+			want: parser.ClientBanned{IP: netip.MustParseAddr("1.2.3.4"), Reason: "camping"},
+		},
+		{
+			line: "[5f3c1d2e][chat]: *** Vote failed",
+			want: parser.VoteResult{Passed: false},
+		},
+	}
+
+	for _, f := range []parser.Flavor{parser.FlavorZCatch, parser.FlavorInfClass, parser.FlavorFNG} {
+		assert.Equal(t, parser.FlavorVanilla06, f.Base())
+		for _, tt := range lines {
+			t.Run(fmt.Sprintf("%s %s", f, tt.line), func(t *testing.T) {
+				got, ok := parser.Parse(tt.line, f)
+				require.True(t, ok)
+				assert.Equal(t, tt.want, got)
+
+				// mods parse exactly like the flavor they are based on
+				base, ok := parser.Parse(tt.line, f.Base())
+				require.True(t, ok)
+				assert.Equal(t, base, got)
+			})
+		}
+	}
+}
+
+func TestParseFlavor(t *testing.T) {
+	for _, f := range parser.Flavors() {
+		got, err := parser.ParseFlavor(string(f))
+		require.NoError(t, err)
+		assert.Equal(t, f, got)
+	}
+
+	got, err := parser.ParseFlavor("")
+	require.NoError(t, err)
+	assert.Equal(t, parser.FlavorAuto, got)
+
+	_, err = parser.ParseFlavor("teeworlds-0.5")
+	assert.Error(t, err)
+}
@@ -0,0 +1,257 @@
+package parser_test
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jxsl13/banserver/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fuzzSeeds contains valid lines of every event type as well as lines with numbers that do not fit into an int.
+func fuzzSeeds() []string {
+	return append(spoofingSeeds(),
+		"2024-11-25 01:12:00 I chat: 99999999999999999999999:-1:nameless tee: hello",
+		"2024-11-25 01:12:00 I chat: 1:-99999999999999999999999:nameless tee: hello",
+		"[2024-12-29 13:50:42][server]: player has entered the game. ClientID=99999999999999999999999 addr=1.2.3.4:8303",
+		"[5f3c1d2e][server]: player has entered the game. ClientID=fffffffffffffffffffffff addr=1.2.3.4:8303",
+		"[2024-12-29 13:50:42][server]: player has entered the game. ClientID=1 addr=1.2.3.4:99999999999",
+		"[2024-12-29 13:50:42][server]: client dropped. cid=99999999999999999999999 addr=1.2.3.4:8303 reason=''",
+		"[2025-02-16 10:39:05][net_ban]: banned '1.2.3.4' for 99999999999999999999 minutes ()",
+		"[2025-02-16 10:39:05][net_ban]: banned '1.2.3.4' for 153722867280913 minutes ()",
+		"[2025-02-16 10:39:05][net_ban]: #99999999999999999999999 '1.2.3.4' banned for life ()",
+		"[2025-02-16 10:39:05][net_ban]: 99999999999999999999999 bans, showing entries 0 - 19",
+		"[2025-02-16 10:39:05][net_ban]: 42 bans, showing entries 20 - 19",
+		"2024-11-25 01:12:00 I server: ClientId=99999999999999999999999 authed with key=default_admin (admin)",
+		"2024-11-25 01:12:00 I server: '99999999999999999999999:a' voted kick '5' reason='' cmd='kick 5' force=0",
+	)
+}
+
+// fuzzParse makes sure that no line can crash the parser and that every parsed event is valid.
+func fuzzParse[E any](f *testing.F, parse func(line string, flavors ...parser.Flavor) (E, bool), valid func(t *testing.T, e E)) {
+	for _, seed := range fuzzSeeds() {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, line string) {
+		if e, ok := parse(line); ok {
+			valid(t, e)
+		}
+
+		for _, flavor := range parser.Flavors() {
+			if e, ok := parse(line, flavor); ok {
+				valid(t, e)
+			}
+		}
+	})
+}
+
+func FuzzParseChatMessage(f *testing.F) {
+	fuzzParse(f, parser.ParseChatMessage, func(t *testing.T, e parser.ChatMessage) {
+		assert.GreaterOrEqual(t, e.ClientID, 0)
+		assert.NotEmpty(t, e.Nickname)
+		assert.NotEmpty(t, e.Message)
+	})
+}
+
+func FuzzParseClientEntered(f *testing.F) {
+	fuzzParse(f, parser.ParseClientEntered, func(t *testing.T, e parser.ClientEntered) {
+		assert.GreaterOrEqual(t, e.ClientID, 0)
+		assert.True(t, e.IP.IsValid())
+		assert.LessOrEqual(t, e.Port, 65535)
+	})
+}
+
+func FuzzParseClientDropped(f *testing.F) {
+	fuzzParse(f, parser.ParseClientDropped, func(t *testing.T, e parser.ClientDropped) {
+		assert.GreaterOrEqual(t, e.ClientID, 0)
+		assert.True(t, e.IP.IsValid())
+		assert.LessOrEqual(t, e.Port, 65535)
+	})
+}
+
+func FuzzParseClientBanned(f *testing.F) {
+	fuzzParse(f, parser.ParseClientBanned, func(t *testing.T, e parser.ClientBanned) {
+		assert.True(t, e.IP.IsValid())
+		assert.GreaterOrEqual(t, e.Duration, time.Duration(0))
+		assert.GreaterOrEqual(t, e.Minutes(), 0)
+	})
+}
+
+func FuzzParseClientUnbanned(f *testing.F) {
+	fuzzParse(f, parser.ParseClientUnbanned, func(t *testing.T, e parser.ClientUnbanned) {
+		assert.True(t, e.IP.IsValid())
+	})
+}
+
+func FuzzParseBanListEntry(f *testing.F) {
+	fuzzParse(f, parser.ParseBanListEntry, func(t *testing.T, e parser.BanListEntry) {
+		assert.GreaterOrEqual(t, e.Index, 0)
+		assert.True(t, e.IP.IsValid())
+		if e.IsRange() {
+			assert.Equal(t, e.IP.Is4(), e.LastIP.Is4())
+			assert.False(t, e.LastIP.Less(e.IP))
+		}
+		assert.GreaterOrEqual(t, e.Minutes(), 0)
+	})
+}
+
+func FuzzParseBanListSummary(f *testing.F) {
+	fuzzParse(f, parser.ParseBanListSummary, func(t *testing.T, e parser.BanListSummary) {
+		assert.GreaterOrEqual(t, e.Total, 0)
+		if e.HasNextPage() {
+			assert.Greater(t, e.NextPage(), 0)
+		}
+	})
+}
+
+func FuzzParseRconAuth(f *testing.F) {
+	fuzzParse(f, parser.ParseRconAuth, func(t *testing.T, e parser.RconAuth) {
+		assert.GreaterOrEqual(t, e.ClientID, 0)
+	})
+}
+
+func FuzzParseVoteCalled(f *testing.F) {
+	fuzzParse(f, parser.ParseVoteCalled, func(t *testing.T, e parser.VoteCalled) {
+		assert.GreaterOrEqual(t, e.ClientID, 0)
+		assert.NotEmpty(t, e.Type)
+		_, _ = e.KickTarget()
+	})
+}
+
+func FuzzParseVoteResult(f *testing.F) {
+	fuzzParse(f, parser.ParseVoteResult, func(t *testing.T, e parser.VoteResult) {})
+}
+
+func FuzzParse(f *testing.F) {
+	fuzzParse(f, parser.Parse, func(t *testing.T, e parser.Event) {
+		assert.NotNil(t, e)
+	})
+}
+
+func FuzzDetectFlavors(f *testing.F) {
+	for _, seed := range fuzzSeeds() {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, line string) {
+		var d parser.FlavorDetector
+		flavors := parser.DetectFlavors(line)
+		flavor, detected := d.Detect(line)
+		assert.Equal(t, len(flavors) == 1, detected)
+		if detected {
+			assert.Equal(t, flavors[0], flavor)
+		}
+	})
+}
+
+// addrFromBytes returns an ipv4 address for the first 4 bytes in case that v4 is set, otherwise an ipv6 address.
+func addrFromBytes(v4 bool, data []byte) netip.Addr {
+	var b [16]byte
+	copy(b[:], data)
+
+	if v4 {
+		return netip.AddrFrom4([4]byte(b[:4]))
+	}
+	return netip.AddrFrom16(b).Unmap()
+}
+
+func formatAddr(addr netip.Addr) string {
+	if addr.Is6() {
+		// teeworlds encloses ipv6 addresses in square brackets
+		return "[" + addr.String() + "]"
+	}
+	return addr.String()
+}
+
+func FuzzRoundTripClientEntered(f *testing.F) {
+	f.Add(uint8(0), true, []byte{1, 2, 3, 4}, uint16(8303))
+	f.Add(uint8(63), false, []byte{0x20, 0x01, 0x0d, 0xb8, 15: 1}, uint16(0))
+
+	f.Fuzz(func(t *testing.T, id uint8, v4 bool, b []byte, port uint16) {
+		addr := addrFromBytes(v4, b)
+
+		for _, flavor := range parser.Flavors() {
+			var message string
+			switch flavor.Base() {
+			case parser.FlavorDDNet, parser.FlavorDDNetLegacy:
+				message = fmt.Sprintf("player has entered the game. ClientId=%d addr=<{%s:%d}> sixup=0", id, formatAddr(addr), port)
+			case parser.FlavorVanilla06:
+				message = fmt.Sprintf("player has entered the game. ClientID=%x addr=%s:%d", id, formatAddr(addr), port)
+			default:
+				message = fmt.Sprintf("player has entered the game. ClientID=%d addr=%s:%d", id, formatAddr(addr), port)
+			}
+			line := formatLine(flavor, "server", message)
+
+			got, ok := parser.ParseClientEntered(line, flavor)
+			require.True(t, ok, line)
+			assert.Equal(t, int(id), got.ClientID)
+			assert.Equal(t, int(port), got.Port)
+			assert.Equal(t, addr, got.IP)
+		}
+	})
+}
+
+func FuzzRoundTripClientBanned(f *testing.F) {
+	f.Add(true, []byte{1, 2, 3, 4}, uint32(5), "Stressing network")
+	f.Add(false, []byte{0x36, 0xbc, 15: 6}, uint32(0), "(nested) reason)")
+
+	f.Fuzz(func(t *testing.T, v4 bool, b []byte, minutes uint32, reason string) {
+		addr := addrFromBytes(v4, b)
+		reason = sanitize(reason)
+
+		duration := "life"
+		if minutes > 0 {
+			duration = fmt.Sprintf("%d minutes", minutes)
+		}
+
+		for _, flavor := range parser.Flavors() {
+			var message string
+			switch flavor.Base() {
+			case parser.FlavorDDNet, parser.FlavorDDNetLegacy:
+				message = fmt.Sprintf("banned '%s' for %s (%s)", formatAddr(addr), duration, reason)
+			default:
+				message = fmt.Sprintf("'%s' banned for %s (%s)", formatAddr(addr), duration, reason)
+			}
+			line := formatLine(flavor, "net_ban", message)
+
+			got, ok := parser.ParseClientBanned(line, flavor)
+			require.True(t, ok, line)
+			assert.Equal(t, time.Duration(minutes)*time.Minute, got.Duration)
+			assert.Equal(t, int(minutes), got.Minutes())
+			assert.Equal(t, reason, got.Reason)
+			assert.Equal(t, addr, got.IP)
+		}
+	})
+}
+
+func FuzzRoundTripChatMessage(f *testing.F) {
+	f.Add(uint8(3), int8(-1), "nameless tee", "hello")
+	f.Add(uint8(3), int8(5), "p'*mac:", "hello world")
+
+	f.Fuzz(func(t *testing.T, id uint8, target int8, nickname, message string) {
+		nickname = sanitize(nickname)
+		message = sanitize(message)
+		if nickname == "" || message == "" || strings.Contains(message, ": ") {
+			// the split between nickname and message is ambiguous in case that the message contains ': '
+			t.Skip()
+		}
+
+		for _, flavor := range parser.Flavors() {
+			line := formatLine(flavor, "chat", fmt.Sprintf("%d:%d:%s: %s", id, target, nickname, message))
+
+			got, ok := parser.ParseChatMessage(line, flavor)
+			require.True(t, ok, line)
+			assert.Equal(t, parser.ChatMessage{
+				ClientID: int(id),
+				TargetID: int(target),
+				Nickname: nickname,
+				Message:  message,
+			}, got)
+		}
+	})
+}
@@ -0,0 +1,41 @@
+package parser
+
+import (
+	"net/netip"
+	"strings"
+)
+
+// ParseIP parses an ip address as it is written by the game servers.
+// Teeworlds encloses ipv6 addresses in square brackets, which are optional here.
+// IPv4-mapped ipv6 addresses are converted to ipv4 addresses, so that every ip has exactly one representation.
+func ParseIP(s string) (netip.Addr, error) {
+	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		s = s[1 : len(s)-1]
+	}
+
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	return addr.Unmap().WithZone(""), nil
+}
+
+// FormatIP formats an ip address for econ commands like ban or unban.
+// The address parsing of all known flavors only accepts ipv6 addresses that are enclosed in square brackets.
+func FormatIP(addr netip.Addr) string {
+	addr = addr.Unmap()
+	if addr.Is4() {
+		return addr.String()
+	}
+	return "[" + addr.String() + "]"
+}
+
+// LastAddr returns the last ip address that is contained in the prefix.
+func LastAddr(prefix netip.Prefix) netip.Addr {
+	b := prefix.Addr().AsSlice()
+	for i := prefix.Bits(); i < len(b)*8; i++ {
+		b[i/8] |= 0x80 >> (i % 8)
+	}
+	addr, _ := netip.AddrFromSlice(b)
+	return addr
+}
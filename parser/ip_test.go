@@ -0,0 +1,44 @@
+package parser_test
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/jxsl13/banserver/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIP(t *testing.T) {
+	tests := []struct {
+		ip      string
+		want    netip.Addr
+		wantErr bool
+	}{
+		{ip: "1.2.3.4", want: netip.MustParseAddr("1.2.3.4")},
+		{ip: "[1.2.3.4]", want: netip.MustParseAddr("1.2.3.4")},
+		{ip: "[::ffff:1.2.3.4]", want: netip.MustParseAddr("1.2.3.4")},
+		{ip: "[36bc:94f6:4608:14b4:f72a:8aa9:c75f:4e06]", want: netip.MustParseAddr("36bc:94f6:4608:14b4:f72a:8aa9:c75f:4e06")},
+		{ip: "36BC:94F6:4608:14B4:F72A:8AA9:C75F:4E06", want: netip.MustParseAddr("36bc:94f6:4608:14b4:f72a:8aa9:c75f:4e06")},
+		{ip: "[2001:db8::1", wantErr: true},
+		{ip: "1.2.3.4:8303", wantErr: true},
+		{ip: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			got, err := parser.ParseIP(tt.ip)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFormatIP(t *testing.T) {
+	assert.Equal(t, "1.2.3.4", parser.FormatIP(netip.MustParseAddr("::ffff:1.2.3.4")))
+	assert.Equal(t, "[2001:db8::1]", parser.FormatIP(netip.MustParseAddr("2001:db8::1")))
+}
@@ -1,6 +1,7 @@
 package parser_test
 
 import (
+	"net/netip"
 	"testing"
 	"time"
 
@@ -19,7 +20,7 @@ func TestParseClientBanned(t *testing.T) {
 			name: "#1 v4",
 			line: "[2025-02-16 10:39:05][net_ban]: banned '123.123.123.123' for 1 minute (Stressing network)",
 			want: parser.ClientBanned{
-				IP:       "123.123.123.123",
+				IP:       netip.MustParseAddr("123.123.123.123"),
 				Duration: 1 * time.Minute,
 				Reason:   "Stressing network",
 			},
@@ -29,7 +30,7 @@ func TestParseClientBanned(t *testing.T) {
 			name: "#2 v6",
 			line: "[2025-02-16 10:39:05][net_ban]: banned '[36bc:94f6:4608:14b4:f72a:8aa9:c75f:4e06]' for 1 minute (Stressing network)",
 			want: parser.ClientBanned{
-				IP:       "36bc:94f6:4608:14b4:f72a:8aa9:c75f:4e06",
+				IP:       netip.MustParseAddr("36bc:94f6:4608:14b4:f72a:8aa9:c75f:4e06"),
 				Duration: 1 * time.Minute,
 				Reason:   "Stressing network",
 			},
@@ -39,7 +40,7 @@ func TestParseClientBanned(t *testing.T) {
 			name: "#3 v4 empty reason",
 			line: "[2025-02-16 10:39:05][net_ban]: banned '123.123.123.123' for 1 minute ()",
 			want: parser.ClientBanned{
-				IP:       "123.123.123.123",
+				IP:       netip.MustParseAddr("123.123.123.123"),
 				Duration: 1 * time.Minute,
 				Reason:   "",
 			},
@@ -49,7 +50,7 @@ func TestParseClientBanned(t *testing.T) {
 			name: "#4 v6 empty reason",
 			line: "[2025-02-16 10:39:05][net_ban]: banned '[36bc:94f6:4608:14b4:f72a:8aa9:c75f:4e06]' for 1 minute ()",
 			want: parser.ClientBanned{
-				IP:       "36bc:94f6:4608:14b4:f72a:8aa9:c75f:4e06",
+				IP:       netip.MustParseAddr("36bc:94f6:4608:14b4:f72a:8aa9:c75f:4e06"),
 				Duration: 1 * time.Minute,
 				Reason:   "",
 			},
@@ -59,7 +60,7 @@ func TestParseClientBanned(t *testing.T) {
 			name: "#5 v4 day",
 			line: "[2025-02-16 10:39:05][net_ban]: banned '123.123.123.123' for 1440 minute ()",
 			want: parser.ClientBanned{
-				IP:       "123.123.123.123",
+				IP:       netip.MustParseAddr("123.123.123.123"),
 				Duration: 1440 * time.Minute,
 				Reason:   "",
 			},
@@ -69,12 +70,38 @@ func TestParseClientBanned(t *testing.T) {
 			name: "#6 v6 day",
 			line: "[2025-02-16 10:39:05][net_ban]: banned '[36bc:94f6:4608:14b4:f72a:8aa9:c75f:4e06]' for 1440 minute ()",
 			want: parser.ClientBanned{
-				IP:       "36bc:94f6:4608:14b4:f72a:8aa9:c75f:4e06",
+				IP:       netip.MustParseAddr("36bc:94f6:4608:14b4:f72a:8aa9:c75f:4e06"),
 				Duration: 1440 * time.Minute,
 				Reason:   "",
 			},
 			wantBool: true,
 		},
+		{
+			name: "#7 ddnet v4 permanent",
+			line: "2024-11-25 01:12:00 I net_ban: banned '123.123.123.123' for life (cheating)",
+			want: parser.ClientBanned{
+				IP:       netip.MustParseAddr("123.123.123.123"),
+				Duration: 0,
+				Reason:   "cheating",
+			},
+			wantBool: true,
+		},
+		{
+			name: "#8 ddnet v4 updated ban",
+			line: "2024-11-25 01:12:00 I net_ban: '123.123.123.123' banned for 60 minutes (spam)",
+			want: parser.ClientBanned{
+				IP:       netip.MustParseAddr("123.123.123.123"),
+				Duration: 60 * time.Minute,
+				Reason:   "spam",
+			},
+			wantBool: true,
+		},
+		{
+			name:     "#9 chat spoofing",
+			line:     "2024-11-25 01:12:00 I chat: 6:-1:scuf: [2025-02-16 10:39:05][net_ban]: banned '0.0.0.0' for 1 minute ()",
+			want:     parser.ClientBanned{},
+			wantBool: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -2,51 +2,60 @@ package parser
 
 import (
 	"math"
-	"regexp"
+	"net/netip"
+	"slices"
 	"time"
 )
 
 var (
-	// [16:40:45][net_ban]: '123.123.123.124' banned for 120 minutes (test)
-
 	// WE MUST match the whole line, otherwise players could exploit this regular expression by writing a specific chat line
 	// matching this regular expression, which bans ANY or ALL ip ranges on all servers.
-	// 0: whole match 1: IP 2: minutes 3: reason
-	ddnetBanRegexp = regexp.MustCompile(`^(?i)\[[\d\- :.]+\]\[net_ban\]: banned '([\[\]:.0-9a-fA-F]+)' for (\d+) minutes? \((.*)\)$`)
-
-	vanillaBanRegexp = regexp.MustCompile(`^(?i)\[[\d\- :.]+\]\[net_ban\]: '([\[\]:.0-9a-fA-F]+)' banned for (\d+) minutes? \((.*)\)$`)
+	// 0: whole match 1: IP 2: minutes (empty for permanent bans) 3: reason
+	bannedPatterns = join(
+		// 2024-11-25 01:12:00 I net_ban: banned '123.123.123.124' for 120 minutes (test)
+		// [2025-02-16 10:39:05][net_ban]: banned '123.123.123.124' for life (test)
+		compilePatterns("net_ban", `banned '`+ipPattern+`' for (?:(\d+) minutes?|life) \((.*)\)$`, slices.Concat(ddnetFlavors, ddnetLegacyFlavors)...),
+		// [16:40:45][net_ban]: '123.123.123.124' banned for 120 minutes (test)
+		// ddnet logs bans of already banned ips in this form as well, as the existing ban is updated
+		compilePatterns("net_ban", `'`+ipPattern+`' banned for (?:(\d+) minutes?|life) \((.*)\)$`, allFlavors...),
+	)
 )
 
-func ParseClientBanned(line string) (cb ClientBanned, ok bool) {
+// ParseClientBanned parses a ban line of the given flavors, all flavors are tried if none are given.
+func ParseClientBanned(line string, flavors ...Flavor) (cb ClientBanned, ok bool) {
+
+	matches, _ := bannedPatterns.find(line, flavors...)
+	if len(matches) == 0 {
+		return cb, false
+	}
 
+	// permanent bans have no duration
 	var (
-		matches    []string
-		ip         string
-		minutesStr string
-		reason     string
+		duration time.Duration
+		err      error
 	)
-	if matches = ddnetBanRegexp.FindStringSubmatch(line); len(matches) > 0 {
-		ip = matches[1] // ipv6 requires [] around the ip
-		minutesStr = matches[2]
-		reason = matches[3]
-	} else if matches = vanillaBanRegexp.FindStringSubmatch(line); len(matches) > 0 {
-		ip = matches[1] // ipv6 requires [] around the ip
-		minutesStr = matches[2]
-		reason = matches[3]
-	} else {
+	if matches[2] != "" {
+		duration, err = parseMinutes(matches[2])
+		if err != nil {
+			return cb, false
+		}
+	}
+
+	ip, err := ParseIP(matches[1])
+	if err != nil {
 		return cb, false
 	}
 
 	return ClientBanned{
 		IP:       ip,
-		Duration: time.Duration(mustParseInt(minutesStr)) * time.Minute,
-		Reason:   reason,
+		Duration: duration,
+		Reason:   matches[3],
 	}, true
 }
 
 type ClientBanned struct {
-	IP       string        `json:"ip"`
-	Duration time.Duration `json:"duration"`
+	IP       netip.Addr    `json:"ip"`
+	Duration time.Duration `json:"duration"` // 0 for permanent bans
 	Reason   string        `json:"reason"`
 }
 
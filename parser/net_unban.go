@@ -1,32 +1,29 @@
 package parser
 
-import (
-	"regexp"
-)
+import "net/netip"
 
 var (
 	// WE MUST match the whole line, otherwise players could exploit this regular expression by writing a specific chat line
 	// matching this regular expression, which would allow them to unban ips.
 	// 0: all 1: IP
-	// [2025-03-16 11:45:59][net_ban]: unbanned index 0 ('[36bc:94f6:4608:14b4:f72a:8aa9:c75f:4e06]')
-	netUnbanIndexRegexp = regexp.MustCompile(`^(?i)\[[\d\- :.]+\]\[net_ban\]: unbanned index \d+ \('([\[\]:.0-9a-fA-F]+)'\)$`)
-
-	// [2025-03-16 11:46:19][net_ban]: unbanned '[36bc:94f6:4608:14b4:f72a:8aa9:c75f:4e06]' for 1440 minutes (No reason given)
-	// 0: all 1: IP 2: minutes 3: reason
-	netUnbanIPRegexp = regexp.MustCompile(`^(?i)\[[\d\- :.]+\]\[net_ban\]: unbanned '([\[\]:.0-9a-fA-F]+)' for (\d+) minutes? \((.*)\)$`)
+	unbannedPatterns = join(
+		// [2025-03-16 11:45:59][net_ban]: unbanned index 0 ('[36bc:94f6:4608:14b4:f72a:8aa9:c75f:4e06]')
+		compilePatterns("net_ban", `unbanned index \d+ \('`+ipPattern+`'\)$`, allFlavors...),
+		// [2025-03-16 11:46:19][net_ban]: unbanned '[36bc:94f6:4608:14b4:f72a:8aa9:c75f:4e06]' for 1440 minutes (No reason given)
+		compilePatterns("net_ban", `unbanned '`+ipPattern+`' for (?:\d+ minutes?|life) \(.*\)$`, allFlavors...),
+	)
 )
 
-func ParseClientUnbanned(line string) (cb ClientUnbanned, ok bool) {
+// ParseClientUnbanned parses an unban line of the given flavors, all flavors are tried if none are given.
+func ParseClientUnbanned(line string, flavors ...Flavor) (cb ClientUnbanned, ok bool) {
 
-	var (
-		matches []string
-		ip      string
-	)
-	if matches = netUnbanIndexRegexp.FindStringSubmatch(line); len(matches) > 0 {
-		ip = matches[1] // ipv6 requires [] around the ip
-	} else if matches = netUnbanIPRegexp.FindStringSubmatch(line); len(matches) > 0 {
-		ip = matches[1] // ipv6 requires [] around the ip
-	} else {
+	matches, _ := unbannedPatterns.find(line, flavors...)
+	if len(matches) == 0 {
+		return cb, false
+	}
+
+	ip, err := ParseIP(matches[1])
+	if err != nil {
 		return cb, false
 	}
 
@@ -36,5 +33,5 @@ func ParseClientUnbanned(line string) (cb ClientUnbanned, ok bool) {
 }
 
 type ClientUnbanned struct {
-	IP string `json:"ip"`
+	IP netip.Addr `json:"ip"`
 }
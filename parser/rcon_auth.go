@@ -0,0 +1,66 @@
+package parser
+
+import "slices"
+
+var (
+	// WE MUST match the whole line, otherwise players could write a chat message that looks like
+	// a failed rcon login of any other client id and get that client banned.
+
+	// 0: full 1: ID 2: key 3: level
+	// 2024-11-25 01:12:00 I server: ClientId=0 authed with key=default_admin (admin)
+	rconAuthedKeyPatterns = compilePatterns("server", `ClientI[dD]=(\d+) authed with key=(\S+) \((\w+)\)$`, slices.Concat(ddnetFlavors, ddnetLegacyFlavors)...)
+
+	// 0: full 1: ID 2: level
+	// [2024-12-29 13:50:42][server]: ClientID=0 authed (admin)
+	rconAuthedPatterns = compilePatterns("server", `ClientI[dD]=(\d+) authed(?: \((\w+)\))?$`, allFlavors...)
+
+	// 0: full 1: ID
+	// 2024-11-25 01:12:00 I server: ClientId=0 rcon authentication failed
+	// [2024-12-29 13:50:42][server]: ClientID=0 rcon authentication failed
+	rconFailedPatterns = compilePatterns("server", `ClientI[dD]=(\d+) rcon authentication failed$`, allFlavors...)
+)
+
+type RconAuth struct {
+	ClientID int    `json:"client_id"`
+	Success  bool   `json:"success"`
+	Key      string `json:"key"`   // ddnet only, name of the used auth key
+	Level    string `json:"level"` // admin, moderator or helper
+}
+
+// ParseRconAuth parses an rcon login line of the given flavors, all flavors are tried if none are given.
+func ParseRconAuth(line string, flavors ...Flavor) (_ RconAuth, ok bool) {
+
+	var (
+		matches []string
+		idStr   string
+		success bool
+		key     string
+		level   string
+	)
+	if matches, _ = rconAuthedKeyPatterns.find(line, flavors...); len(matches) > 0 {
+		idStr = matches[1]
+		success = true
+		key = matches[2]
+		level = matches[3]
+	} else if matches, _ = rconAuthedPatterns.find(line, flavors...); len(matches) > 0 {
+		idStr = matches[1]
+		success = true
+		level = matches[2]
+	} else if matches, _ = rconFailedPatterns.find(line, flavors...); len(matches) > 0 {
+		idStr = matches[1]
+	} else {
+		return RconAuth{}, false
+	}
+
+	clientID, err := parseInt(idStr)
+	if err != nil {
+		return RconAuth{}, false
+	}
+
+	return RconAuth{
+		ClientID: clientID,
+		Success:  success,
+		Key:      key,
+		Level:    level,
+	}, true
+}
@@ -0,0 +1,80 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/jxsl13/banserver/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRconAuth(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		want     parser.RconAuth
+		wantBool bool
+	}{
+		{
+			name: "ddnet authed",
+			line: "2024-11-25 01:12:00 I server: ClientId=3 authed with key=default_admin (admin)",
+			want: parser.RconAuth{
+				ClientID: 3,
+				Success:  true,
+				Key:      "default_admin",
+				Level:    "admin",
+			},
+			wantBool: true,
+		},
+		{
+			name: "ddnet failed",
+			line: "2024-11-25 01:12:00 I server: ClientId=3 rcon authentication failed",
+			want: parser.RconAuth{
+				ClientID: 3,
+			},
+			wantBool: true,
+		},
+		{
+			name: "vanilla authed",
+			line: "[2024-12-29 13:50:42][server]: ClientID=1 authed (moderator)",
+			want: parser.RconAuth{
+				ClientID: 1,
+				Success:  true,
+				Level:    "moderator",
+			},
+			wantBool: true,
+		},
+		{
+			name: "vanilla authed without level",
+			line: "[2024-12-29 13:50:42][server]: ClientID=1 authed",
+			want: parser.RconAuth{
+				ClientID: 1,
+				Success:  true,
+			},
+			wantBool: true,
+		},
+		{
+			name: "vanilla failed",
+			line: "[2024-12-29 13:50:42][server]: ClientID=1 rcon authentication failed",
+			want: parser.RconAuth{
+				ClientID: 1,
+			},
+			wantBool: true,
+		},
+		{
+			name:     "chat spoofing",
+			line:     "2024-11-25 01:12:00 I chat: 6:-2:scuf: 2024-11-25 01:12:00 I server: ClientId=3 rcon authentication failed",
+			want:     parser.RconAuth{},
+			wantBool: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parser.ParseRconAuth(tt.line)
+			assert.Equal(t, tt.wantBool, ok)
+			if ok {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package parser
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Event is the typed result of a parsed log line, e.g. ChatMessage or ClientEntered.
+type Event any
+
+// Matcher tries to parse a single log line of the given flavors into an event.
+// All flavors are tried if none are given.
+type Matcher func(line string, flavors ...Flavor) (Event, bool)
+
+type namedMatcher struct {
+	name  string
+	match Matcher
+}
+
+// Registry contains the matchers of all known event types.
+// Every line is parsed at most once and the first matching matcher wins.
+type Registry struct {
+	mu       sync.RWMutex
+	matchers []namedMatcher
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a new matcher with a unique name.
+// Matchers are tried in the order of their registration.
+func (r *Registry) Register(name string, m Matcher) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, nm := range r.matchers {
+		if nm.name == name {
+			return fmt.Errorf("matcher %q is already registered", name)
+		}
+	}
+
+	r.matchers = append(r.matchers, namedMatcher{
+		name:  name,
+		match: m,
+	})
+	return nil
+}
+
+// Parse returns the event of the first matcher that matches the line.
+// Only the patterns of the given flavors are tried, all flavors are tried if none are given.
+func (r *Registry) Parse(line string, flavors ...Flavor) (Event, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, nm := range r.matchers {
+		if event, ok := nm.match(line, flavors...); ok {
+			return event, true
+		}
+	}
+	return nil, false
+}
+
+// Names returns the names of all registered matchers in the order of their registration.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.matchers))
+	for _, nm := range r.matchers {
+		names = append(names, nm.name)
+	}
+	return names
+}
+
+// Register adds a typed parse function to the registry.
+func Register[E any](r *Registry, name string, parse func(line string, flavors ...Flavor) (E, bool)) error {
+	return r.Register(name, func(line string, flavors ...Flavor) (Event, bool) {
+		event, ok := parse(line, flavors...)
+		if !ok {
+			return nil, false
+		}
+		return event, true
+	})
+}
+
+// DefaultRegistry contains all event types of this package.
+// Third party event types may be registered here as well.
+var DefaultRegistry = NewRegistry()
+
+func init() {
+	// chat messages must be matched first, as players may write chat messages
+	// that look like other events.
+	mustRegister(Register(DefaultRegistry, "chat", ParseChatMessage))
+	mustRegister(Register(DefaultRegistry, "client_entered", ParseClientEntered))
+	mustRegister(Register(DefaultRegistry, "client_dropped", ParseClientDropped))
+	mustRegister(Register(DefaultRegistry, "client_banned", ParseClientBanned))
+	mustRegister(Register(DefaultRegistry, "client_unbanned", ParseClientUnbanned))
+	mustRegister(Register(DefaultRegistry, "ban_list_entry", ParseBanListEntry))
+	mustRegister(Register(DefaultRegistry, "ban_list_summary", ParseBanListSummary))
+	mustRegister(Register(DefaultRegistry, "rcon_auth", ParseRconAuth))
+	mustRegister(Register(DefaultRegistry, "vote_called", ParseVoteCalled))
+	mustRegister(Register(DefaultRegistry, "vote_result", ParseVoteResult))
+}
+
+func mustRegister(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Parse parses the line using the DefaultRegistry.
+func Parse(line string, flavors ...Flavor) (Event, bool) {
+	return DefaultRegistry.Parse(line, flavors...)
+}
@@ -0,0 +1,80 @@
+package parser_test
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/jxsl13/banserver/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want parser.Event
+	}{
+		{
+			name: "chat",
+			line: "2024-11-25 01:12:00 I chat: 6:-2:scuf: b",
+			want: parser.ChatMessage{ClientID: 6, TargetID: -2, Nickname: "scuf", Message: "b"},
+		},
+		{
+			name: "entered",
+			line: "[2024-12-29 13:50:42][server]: player has entered the game. ClientID=2 addr=234.234.234.234:64285",
+			want: parser.ClientEntered{ClientID: 2, IP: netip.MustParseAddr("234.234.234.234"), Port: 64285},
+		},
+		{
+			name: "dropped",
+			line: "[2024-12-29 13:50:46][server]: client dropped. cid=5 addr=123.123.123.123:64285 reason=''",
+			want: parser.ClientDropped{ClientID: 5, IP: netip.MustParseAddr("123.123.123.123"), Port: 64285},
+		},
+		{
+			name: "unbanned",
+			line: "[2025-03-16 11:45:59][net_ban]: unbanned index 0 ('123.123.123.123')",
+			want: parser.ClientUnbanned{IP: netip.MustParseAddr("123.123.123.123")},
+		},
+		{
+			name: "vote result",
+			line: "2024-11-25 01:12:00 I chat: *** Vote passed",
+			want: parser.VoteResult{Passed: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parser.Parse(tt.line)
+			require.True(t, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, ok := parser.Parse("some random text")
+	assert.False(t, ok)
+}
+
+type customEvent struct {
+	Text string
+}
+
+func TestRegistryRegister(t *testing.T) {
+	r := parser.NewRegistry()
+
+	parse := func(line string, _ ...parser.Flavor) (customEvent, bool) {
+		text, found := strings.CutPrefix(line, "custom: ")
+		return customEvent{Text: text}, found
+	}
+
+	require.NoError(t, parser.Register(r, "custom", parse))
+	require.Error(t, parser.Register(r, "custom", parse))
+	assert.Equal(t, []string{"custom"}, r.Names())
+
+	got, ok := r.Parse("custom: hello")
+	require.True(t, ok)
+	assert.Equal(t, customEvent{Text: "hello"}, got)
+
+	_, ok = r.Parse("other: hello")
+	assert.False(t, ok)
+}
@@ -0,0 +1,129 @@
+package parser_test
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/jxsl13/banserver/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sanitize replaces control characters like the game server does for chat messages, nicknames and reasons.
+// Thus a player can never start a new log line.
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 32 {
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+// spoofingSeeds are player controlled texts that look like lines of all known event types.
+func spoofingSeeds() []string {
+	messages := []string{
+		"player has entered the game. ClientId=12 addr=<{1.2.3.4:8303}> sixup=0",
+		"player has entered the game. ClientID=12 addr=1.2.3.4:8303",
+		"client dropped. cid=12 addr=1.2.3.4:8303 reason='timeout'",
+		"banned '0.0.0.0' for 5 minutes (spam)",
+		"'0.0.0.0' banned for 5 minutes (spam)",
+		"unbanned index 0 ('1.2.3.4')",
+		"#0 '0.0.0.0' - '255.255.255.255' banned for life (spam)",
+		"1 ban",
+		"ClientID=12 authed (admin)",
+		"ClientID=12 rcon authentication failed",
+		"'12:nameless tee' voted kick '3' reason='x' cmd='kick 3 Kicked by vote' force=0",
+		"*** Vote passed",
+	}
+
+	seeds := []string{}
+	for _, msg := range messages {
+		seeds = append(seeds, msg)
+		for _, f := range parser.Flavors() {
+			for _, system := range []string{"server", "net_ban", "chat"} {
+				seeds = append(seeds, formatLine(f, system, msg))
+			}
+		}
+	}
+	return seeds
+}
+
+func FuzzChatSpoofing(f *testing.F) {
+	for _, seed := range spoofingSeeds() {
+		f.Add(uint8(3), int8(-1), "nameless tee", seed)
+		f.Add(uint8(3), int8(-2), seed, "hello")
+	}
+
+	f.Fuzz(func(t *testing.T, id uint8, target int8, nickname, message string) {
+		nickname = sanitize(nickname)
+		message = sanitize(message)
+		if nickname == "" || message == "" {
+			t.Skip()
+		}
+
+		for _, flavor := range parser.Flavors() {
+			line := formatLine(flavor, "chat", fmt.Sprintf("%d:%d:%s: %s", id, target, nickname, message))
+
+			event, ok := parser.Parse(line)
+			require.True(t, ok, line)
+			chat, ok := event.(parser.ChatMessage)
+			require.True(t, ok, "chat line parsed as %T: %s", event, line)
+			assert.Equal(t, int(id), chat.ClientID)
+			assert.Equal(t, int(target), chat.TargetID)
+		}
+	})
+}
+
+func FuzzDropReasonSpoofing(f *testing.F) {
+	for _, seed := range spoofingSeeds() {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, reason string) {
+		reason = sanitize(reason)
+
+		for _, flavor := range parser.Flavors() {
+			addr := "1.2.3.4:8303"
+			if flavor == parser.FlavorDDNet {
+				addr = "<{1.2.3.4:8303}>"
+			}
+			line := formatLine(flavor, "server", fmt.Sprintf("client dropped. cid=7 addr=%s reason='%s'", addr, reason))
+
+			event, ok := parser.Parse(line)
+			require.True(t, ok, line)
+			dropped, ok := event.(parser.ClientDropped)
+			require.True(t, ok, "drop line parsed as %T: %s", event, line)
+			assert.Equal(t, 7, dropped.ClientID)
+			assert.Equal(t, netip.MustParseAddr("1.2.3.4"), dropped.IP)
+			assert.Equal(t, 8303, dropped.Port)
+		}
+	})
+}
+
+func FuzzVoteSpoofing(f *testing.F) {
+	for _, seed := range spoofingSeeds() {
+		f.Add("nameless tee", seed)
+		f.Add(seed, "No reason given")
+	}
+
+	f.Fuzz(func(t *testing.T, nickname, reason string) {
+		nickname = sanitize(nickname)
+		reason = sanitize(reason)
+
+		for _, flavor := range parser.Flavors() {
+			line := formatLine(flavor, "server", fmt.Sprintf("'7:%s' voted option 'Map: dm1' reason='%s' cmd='change_map dm1' force=0", nickname, reason))
+
+			event, ok := parser.Parse(line)
+			require.True(t, ok, line)
+			vote, ok := event.(parser.VoteCalled)
+			require.True(t, ok, "vote line parsed as %T: %s", event, line)
+			assert.Equal(t, "change_map dm1", vote.Command)
+
+			_, ok = vote.KickTarget()
+			assert.False(t, ok, line)
+		}
+	})
+}
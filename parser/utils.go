@@ -1,15 +1,22 @@
 package parser
 
 import (
+	"fmt"
 	"math"
 	"strconv"
+	"time"
 	"unsafe"
 )
 
 const (
 	intBytes = int(unsafe.Sizeof(math.MaxInt) * 8)
+
+	// maxMinutes is the largest number of minutes that fits into a time.Duration
+	maxMinutes = math.MaxInt64 / int64(time.Minute)
 )
 
+// No log line must be able to crash the banserver, so every parsing error is returned instead of panicking.
+
 func parseInt(s string) (int, error) {
 	i64, err := strconv.ParseInt(s, 10, intBytes)
 	if err != nil {
@@ -18,10 +25,30 @@ func parseInt(s string) (int, error) {
 	return int(i64), nil
 }
 
-func mustParseInt(s string) int {
-	i, err := parseInt(s)
+func parseHexInt(s string) (int, error) {
+	i64, err := strconv.ParseInt(s, 16, intBytes)
+	if err != nil {
+		return 0, err
+	}
+	return int(i64), nil
+}
+
+func parsePort(s string) (int, error) {
+	port, err := strconv.ParseUint(s, 10, 16)
 	if err != nil {
-		panic(err)
+		return 0, err
+	}
+	return int(port), nil
+}
+
+func parseMinutes(s string) (time.Duration, error) {
+	minutes, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	if minutes < 0 || minutes > maxMinutes {
+		return 0, fmt.Errorf("minutes out of range: %s", s)
 	}
-	return i
+	return time.Duration(minutes) * time.Minute, nil
 }
@@ -31,3 +31,52 @@ func TestParseInt(t *testing.T) {
 		})
 	}
 }
+
+func TestParseIntOverflow(t *testing.T) {
+	tests := []string{
+		"9223372036854775808",
+		"-9223372036854775809",
+		"99999999999999999999999999999999",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			if _, err := parseInt(input); err == nil {
+				t.Errorf("expected error for %s", input)
+			}
+		})
+	}
+}
+
+func TestParseMinutes(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"zero", "0", false},
+		{"day", "1440", false},
+		{"max", strconv.FormatInt(maxMinutes, 10), false},
+		{"overflow", strconv.FormatInt(maxMinutes+1, 10), true},
+		{"negative", "-1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseMinutes(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestParsePort(t *testing.T) {
+	if port, err := parsePort("65535"); err != nil || port != 65535 {
+		t.Errorf("expected 65535, got %d: %v", port, err)
+	}
+
+	if _, err := parsePort("65536"); err == nil {
+		t.Errorf("expected error for port 65536")
+	}
+}
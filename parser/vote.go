@@ -0,0 +1,87 @@
+package parser
+
+import "regexp"
+
+var (
+	// WE MUST match the whole line, otherwise players could write chat messages that look like
+	// votes of other players in order to get them punished for vote spam.
+	// Nickname and reason are controlled by the player, the greedy groups make sure that
+	// the command, which is generated by the server, is always taken from the end of the line.
+
+	// 0: full 1: ID 2: nickname 3: type 4: value 5: reason 6: command 7: force
+	// 2024-11-25 01:12:00 I server: '3:nameless tee' voted kick '5' reason='No reason given' cmd='kick 5 Kicked by vote' force=0
+	// [2024-12-29 13:50:42][server]: '3:nameless tee' voted option 'Map: dm1' reason='No reason given' cmd='change_map dm1' force=0
+	voteCalledPatterns = compilePatterns("(?:server|game)", `'(\d+):(.*)' voted (\w+) '(.*)' reason='(.*)' cmd='(.*)' force=(\d)$`, allFlavors...)
+
+	// 0: full 1: passed or failed
+	// 2024-11-25 01:12:00 I chat: *** Vote passed
+	// [2024-12-29 13:50:42][chat]: *** Vote failed
+	voteResultPatterns = compilePatterns("chat", `\*\*\* Vote (passed|failed)(?: .*)?$`, allFlavors...)
+
+	// 0: full 1: target client id or ip
+	kickCommandRegexp = regexp.MustCompile(`^(?:kick|ban) (\S+)`)
+)
+
+type VoteCalled struct {
+	ClientID int    `json:"client_id"`
+	Nickname string `json:"nickname"`
+	Type     string `json:"type"` // option, kick or spectate
+	Value    string `json:"value"`
+	Reason   string `json:"reason"`
+	Command  string `json:"command"`
+	Force    bool   `json:"force"`
+}
+
+// KickTarget returns the client id or ip of the player that is supposed to be kicked by the vote.
+func (vc VoteCalled) KickTarget() (target string, ok bool) {
+	if vc.Type != "kick" {
+		return "", false
+	}
+
+	matches := kickCommandRegexp.FindStringSubmatch(vc.Command)
+	if len(matches) == 0 {
+		return "", false
+	}
+	return matches[1], true
+}
+
+// ParseVoteCalled parses a callvote line of the given flavors, all flavors are tried if none are given.
+func ParseVoteCalled(line string, flavors ...Flavor) (_ VoteCalled, ok bool) {
+
+	matches, _ := voteCalledPatterns.find(line, flavors...)
+	if len(matches) == 0 {
+		return VoteCalled{}, false
+	}
+
+	clientID, err := parseInt(matches[1])
+	if err != nil {
+		return VoteCalled{}, false
+	}
+
+	return VoteCalled{
+		ClientID: clientID,
+		Nickname: matches[2],
+		Type:     matches[3],
+		Value:    matches[4],
+		Reason:   matches[5],
+		Command:  matches[6],
+		Force:    matches[7] == "1",
+	}, true
+}
+
+type VoteResult struct {
+	Passed bool `json:"passed"`
+}
+
+// ParseVoteResult parses a vote result line of the given flavors, all flavors are tried if none are given.
+func ParseVoteResult(line string, flavors ...Flavor) (_ VoteResult, ok bool) {
+
+	matches, _ := voteResultPatterns.find(line, flavors...)
+	if len(matches) == 0 {
+		return VoteResult{}, false
+	}
+
+	return VoteResult{
+		Passed: matches[1] == "passed",
+	}, true
+}
@@ -0,0 +1,117 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/jxsl13/banserver/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseVoteCalled(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		want       parser.VoteCalled
+		wantBool   bool
+		wantTarget string
+	}{
+		{
+			name: "ddnet kick vote",
+			line: "2024-11-25 01:12:00 I server: '3:nameless tee' voted kick '5' reason='No reason given' cmd='kick 5 Kicked by vote' force=0",
+			want: parser.VoteCalled{
+				ClientID: 3,
+				Nickname: "nameless tee",
+				Type:     "kick",
+				Value:    "5",
+				Reason:   "No reason given",
+				Command:  "kick 5 Kicked by vote",
+			},
+			wantBool:   true,
+			wantTarget: "5",
+		},
+		{
+			name: "vanilla option vote",
+			line: "[2024-12-29 13:50:42][server]: '0:p'*mac' voted option 'Map: dm1' reason='' cmd='change_map dm1' force=1",
+			want: parser.VoteCalled{
+				ClientID: 0,
+				Nickname: "p'*mac",
+				Type:     "option",
+				Value:    "Map: dm1",
+				Command:  "change_map dm1",
+				Force:    true,
+			},
+			wantBool: true,
+		},
+		{
+			name: "vanilla ban vote",
+			line: "[2024-12-29 13:50:42][server]: '1:abc' voted kick '2:def' reason='spam' cmd='ban 123.123.123.123 5 Banned by vote' force=0",
+			want: parser.VoteCalled{
+				ClientID: 1,
+				Nickname: "abc",
+				Type:     "kick",
+				Value:    "2:def",
+				Reason:   "spam",
+				Command:  "ban 123.123.123.123 5 Banned by vote",
+			},
+			wantBool:   true,
+			wantTarget: "123.123.123.123",
+		},
+		{
+			name:     "chat spoofing",
+			line:     "2024-11-25 01:12:00 I chat: 6:-1:scuf: '3:nameless tee' voted kick '5' reason='' cmd='kick 5' force=0",
+			wantBool: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parser.ParseVoteCalled(tt.line)
+			assert.Equal(t, tt.wantBool, ok)
+			if !ok {
+				return
+			}
+			assert.Equal(t, tt.want, got)
+
+			target, ok := got.KickTarget()
+			assert.Equal(t, tt.wantTarget != "", ok)
+			assert.Equal(t, tt.wantTarget, target)
+		})
+	}
+}
+
+func TestParseVoteResult(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		want     parser.VoteResult
+		wantBool bool
+	}{
+		{
+			name:     "ddnet passed",
+			line:     "2024-11-25 01:12:00 I chat: *** Vote passed",
+			want:     parser.VoteResult{Passed: true},
+			wantBool: true,
+		},
+		{
+			name:     "vanilla failed",
+			line:     "[2024-12-29 13:50:42][chat]: *** Vote failed",
+			want:     parser.VoteResult{Passed: false},
+			wantBool: true,
+		},
+		{
+			name:     "player chat",
+			line:     "[2024-12-29 13:50:42][chat]: 1:-1:abc: *** Vote passed",
+			wantBool: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parser.ParseVoteResult(tt.line)
+			assert.Equal(t, tt.wantBool, ok)
+			if ok {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package secret
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// Secret is a string that is redacted when it is formatted, logged or marshaled.
+// The actual value is only accessible via Value.
+type Secret string
+
+// Value returns the actual secret.
+func (s Secret) Value() string {
+	return string(s)
+}
+
+func (s Secret) String() string {
+	return redacted
+}
+
+func (s Secret) GoString() string {
+	return redacted
+}
+
+// Format redacts the secret for every formatting verb, including %q, %x and %#v.
+func (s Secret) Format(f fmt.State, _ rune) {
+	_, _ = f.Write([]byte(redacted))
+}
+
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(redacted)
+}
+
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(redacted), nil
+}
+
+// Redact replaces every occurrence of the given secrets in s.
+func Redact(s string, secrets ...Secret) string {
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+		s = strings.ReplaceAll(s, string(secret), redacted)
+	}
+	return s
+}
+
+// RedactError returns an error whose message does not contain any of the given secrets.
+// The returned error wraps err, so errors.Is and errors.As still work.
+func RedactError(err error, secrets ...Secret) error {
+	if err == nil {
+		return nil
+	}
+
+	msg := err.Error()
+	redactedMsg := Redact(msg, secrets...)
+	if redactedMsg == msg {
+		return err
+	}
+	return &redactedError{msg: redactedMsg, err: err}
+}
+
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
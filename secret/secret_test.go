@@ -0,0 +1,46 @@
+package secret_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+
+	"github.com/jxsl13/banserver/secret"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretFormat(t *testing.T) {
+	s := secret.Secret("hunter2")
+
+	for _, format := range []string{"%s", "%v", "%+v", "%#v", "%q", "%x", "%X", "%10s", "%d"} {
+		got := fmt.Sprintf(format, s)
+		assert.NotContains(t, got, "hunter2", format)
+		assert.NotContains(t, got, fmt.Sprintf("%x", "hunter2"), format)
+	}
+
+	got := fmt.Sprintf("%v", struct{ Password secret.Secret }{s})
+	assert.NotContains(t, got, "hunter2")
+
+	data, err := json.Marshal(map[string]secret.Secret{"password": s})
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "hunter2")
+
+	assert.Equal(t, "hunter2", s.Value())
+}
+
+func TestRedactError(t *testing.T) {
+	s := secret.Secret("hunter2")
+
+	require.NoError(t, secret.RedactError(nil, s))
+
+	err := fmt.Errorf("login with password hunter2 failed: %w", fs.ErrPermission)
+	redacted := secret.RedactError(err, s)
+	assert.NotContains(t, redacted.Error(), "hunter2")
+	assert.True(t, errors.Is(redacted, fs.ErrPermission))
+
+	err = errors.New("connection refused")
+	assert.Same(t, err, secret.RedactError(err, s, ""))
+}
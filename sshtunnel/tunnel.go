@@ -0,0 +1,296 @@
+package sshtunnel
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const defaultTimeout = 15 * time.Second
+
+// Config describes how to connect to an ssh server.
+type Config struct {
+	Address        string  // host:port of the ssh server
+	User           string  // ssh user
+	KeyFile        string  // unencrypted private key file
+	KnownHostsFile string  // known_hosts file that is used to verify the host key
+	Jump           *Config // optional jump host that is used to reach the ssh server
+	Timeout        time.Duration
+}
+
+// ParseAddress parses an ssh address of the form [user@]host[:port], the port defaults to 22.
+func ParseAddress(s string) (user, addr string, err error) {
+	user, host, found := strings.Cut(strings.TrimSpace(s), "@")
+	if !found {
+		host, user = user, ""
+	}
+	if host == "" {
+		return "", "", fmt.Errorf("invalid ssh address %q: missing host", s)
+	}
+
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(strings.Trim(host, "[]"), "22")
+	}
+	return user, host, nil
+}
+
+// DefaultKnownHostsFile returns ~/.ssh/known_hosts.
+func DefaultKnownHostsFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".ssh", "known_hosts")
+}
+
+func (c Config) clientConfig() (*ssh.ClientConfig, error) {
+	if c.User == "" {
+		return nil, fmt.Errorf("missing ssh user for %s", c.Address)
+	}
+
+	key, err := os.ReadFile(c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ssh key: %w", err)
+	}
+
+	signer, err := ssh.ParsePrivateKey(key)
+	if err != nil {
+		var passphraseErr *ssh.PassphraseMissingError
+		if errors.As(err, &passphraseErr) {
+			return nil, fmt.Errorf("ssh key %s is encrypted, only unencrypted keys are supported", c.KeyFile)
+		}
+		return nil, fmt.Errorf("failed to parse ssh key %s: %w", c.KeyFile, err)
+	}
+
+	hostKeyCallback, err := knownhosts.New(c.KnownHostsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read known hosts: %w", err)
+	}
+
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	return &ssh.ClientConfig{
+		User:            c.User,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         timeout,
+	}, nil
+}
+
+// connect returns the clients of all jump hosts followed by the client of the ssh server.
+func connect(c Config) ([]*ssh.Client, error) {
+	clientConfig, err := c.clientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Jump == nil {
+		client, err := ssh.Dial("tcp", c.Address, clientConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to ssh server %s: %w", c.Address, err)
+		}
+		return []*ssh.Client{client}, nil
+	}
+
+	clients, err := connect(*c.Jump)
+	if err != nil {
+		return nil, err
+	}
+	jump := clients[len(clients)-1]
+
+	conn, err := jump.Dial("tcp", c.Address)
+	if err != nil {
+		closeClients(clients)
+		return nil, fmt.Errorf("failed to connect to ssh server %s via jump host %s: %w", c.Address, c.Jump.Address, err)
+	}
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, c.Address, clientConfig)
+	if err != nil {
+		conn.Close()
+		closeClients(clients)
+		return nil, fmt.Errorf("failed to connect to ssh server %s via jump host %s: %w", c.Address, c.Jump.Address, err)
+	}
+	return append(clients, ssh.NewClient(sshConn, chans, reqs)), nil
+}
+
+func closeClients(clients []*ssh.Client) error {
+	var err error
+	// the last client depends on the previous ones
+	for i := len(clients) - 1; i >= 0; i-- {
+		err = errors.Join(err, clients[i].Close())
+	}
+	return err
+}
+
+// Tunnel forwards connections to a local address through an ssh connection to a remote address,
+// like ssh -L. The ssh connection is established again in case that it was lost.
+type Tunnel struct {
+	cfg      Config
+	remote   string
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	mu      sync.Mutex
+	clients []*ssh.Client
+	closed  bool
+	conns   map[net.Conn]struct{}
+}
+
+// Open connects to the ssh server and listens on a local address for connections that are forwarded to the remote
+// address, which is dialed by the ssh server.
+func Open(cfg Config, remote string) (*Tunnel, error) {
+	clients, err := connect(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		closeClients(clients)
+		return nil, err
+	}
+
+	t := &Tunnel{
+		cfg:      cfg,
+		remote:   remote,
+		listener: listener,
+		clients:  clients,
+		conns:    make(map[net.Conn]struct{}),
+	}
+
+	t.wg.Add(1)
+	go t.serve()
+	return t, nil
+}
+
+// Addr returns the local address whose connections are forwarded.
+func (t *Tunnel) Addr() string {
+	return t.listener.Addr().String()
+}
+
+func (t *Tunnel) Close() error {
+	t.mu.Lock()
+	t.closed = true
+	err := errors.Join(t.listener.Close(), closeClients(t.clients))
+	t.clients = nil
+	for conn := range t.conns {
+		conn.Close()
+	}
+	t.mu.Unlock()
+
+	t.wg.Wait()
+	return err
+}
+
+func (t *Tunnel) serve() {
+	defer t.wg.Done()
+
+	for {
+		local, err := t.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		t.wg.Add(1)
+		go t.forward(local)
+	}
+}
+
+func (t *Tunnel) forward(local net.Conn) {
+	defer t.wg.Done()
+
+	remote, err := t.dialRemote()
+	if err != nil {
+		log.Printf("failed to forward connection to %s through ssh tunnel: %v", t.remote, err)
+		local.Close()
+		return
+	}
+
+	if !t.track(local, remote) {
+		local.Close()
+		remote.Close()
+		return
+	}
+	defer t.untrack(local, remote)
+
+	done := make(chan struct{}, 2)
+	pipe := func(dst, src net.Conn) {
+		_, _ = io.Copy(dst, src)
+		done <- struct{}{}
+	}
+	go pipe(remote, local)
+	go pipe(local, remote)
+
+	// closing both connections stops the other direction as well
+	<-done
+	local.Close()
+	remote.Close()
+	<-done
+}
+
+// dialRemote dials the remote address through the ssh connection, which is established again if necessary.
+func (t *Tunnel) dialRemote() (net.Conn, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return nil, net.ErrClosed
+	}
+
+	if len(t.clients) > 0 {
+		conn, err := t.clients[len(t.clients)-1].Dial("tcp", t.remote)
+		if err == nil {
+			return conn, nil
+		}
+
+		// the ssh server is still reachable, but cannot reach the remote address
+		var openErr *ssh.OpenChannelError
+		if errors.As(err, &openErr) {
+			return nil, err
+		}
+		log.Printf("ssh connection to %s lost, reconnecting: %v", t.cfg.Address, err)
+		closeClients(t.clients)
+		t.clients = nil
+	}
+
+	clients, err := connect(t.cfg)
+	if err != nil {
+		return nil, err
+	}
+	t.clients = clients
+
+	return clients[len(clients)-1].Dial("tcp", t.remote)
+}
+
+func (t *Tunnel) track(conns ...net.Conn) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return false
+	}
+	for _, conn := range conns {
+		t.conns[conn] = struct{}{}
+	}
+	return true
+}
+
+func (t *Tunnel) untrack(conns ...net.Conn) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, conn := range conns {
+		delete(t.conns, conn)
+	}
+}
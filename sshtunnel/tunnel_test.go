@@ -0,0 +1,320 @@
+package sshtunnel_test
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jxsl13/banserver/sshtunnel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// sshServer is a minimal in-process ssh server that only supports public key authentication
+// and direct-tcpip channels, which are used for port forwarding.
+type sshServer struct {
+	t        *testing.T
+	listener net.Listener
+	hostKey  ssh.Signer
+
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func newSSHServer(t *testing.T, authorized ssh.PublicKey) *sshServer {
+	t.Helper()
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	hostKey, err := ssh.NewSignerFromKey(priv)
+	require.NoError(t, err)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &sshServer{
+		t:        t,
+		listener: listener,
+		hostKey:  hostKey,
+	}
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) != string(authorized.Marshal()) {
+				return nil, fmt.Errorf("unauthorized key")
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	go s.serve(config)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *sshServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *sshServer) serve(config *ssh.ServerConfig) {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.conns = append(s.conns, conn)
+		s.mu.Unlock()
+
+		go s.handle(conn, config)
+	}
+}
+
+func (s *sshServer) handle(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for ch := range chans {
+		if ch.ChannelType() != "direct-tcpip" {
+			_ = ch.Reject(ssh.UnknownChannelType, "unsupported channel type")
+			continue
+		}
+
+		// RFC 4254 7.2: host to connect, port to connect, originator ip, originator port
+		payload := ch.ExtraData()
+		hostLen := binary.BigEndian.Uint32(payload)
+		host := string(payload[4 : 4+hostLen])
+		port := binary.BigEndian.Uint32(payload[4+hostLen:])
+
+		target, err := net.Dial("tcp", net.JoinHostPort(host, fmt.Sprint(port)))
+		if err != nil {
+			_ = ch.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+
+		channel, chReqs, err := ch.Accept()
+		if err != nil {
+			target.Close()
+			continue
+		}
+		go ssh.DiscardRequests(chReqs)
+
+		go func() {
+			defer channel.Close()
+			defer target.Close()
+			go func() {
+				_, _ = io.Copy(target, channel)
+				target.Close()
+			}()
+			_, _ = io.Copy(channel, target)
+		}()
+	}
+}
+
+// DropConnections closes all ssh connections, but keeps accepting new ones.
+func (s *sshServer) DropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+func (s *sshServer) Close() {
+	s.listener.Close()
+	s.DropConnections()
+}
+
+// newEchoServer returns the address of a tcp server that responds with every received line.
+func newEchoServer(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// writeClientKey writes an unencrypted private key file and returns its path and public key.
+func writeClientKey(t *testing.T, dir string) (string, ssh.PublicKey) {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "id_ed25519")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(block), 0o600))
+
+	sshPub, err := ssh.NewPublicKey(pub)
+	require.NoError(t, err)
+	return path, sshPub
+}
+
+func writeKnownHosts(t *testing.T, dir string, servers ...*sshServer) string {
+	t.Helper()
+
+	lines := make([]string, 0, len(servers))
+	for _, s := range servers {
+		lines = append(lines, knownhosts.Line([]string{knownhosts.Normalize(s.Addr())}, s.hostKey.PublicKey()))
+	}
+
+	path := filepath.Join(dir, "known_hosts")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600))
+	return path
+}
+
+func assertEcho(t *testing.T, addr, msg string) {
+	t.Helper()
+
+	conn, err := net.DialTimeout("tcp", addr, 5*time.Second)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.SetDeadline(time.Now().Add(5*time.Second)))
+
+	_, err = fmt.Fprintln(conn, msg)
+	require.NoError(t, err)
+
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, msg+"\n", line)
+}
+
+func TestTunnel(t *testing.T) {
+	dir := t.TempDir()
+	keyFile, pub := writeClientKey(t, dir)
+	server := newSSHServer(t, pub)
+	echo := newEchoServer(t)
+
+	tunnel, err := sshtunnel.Open(sshtunnel.Config{
+		Address:        server.Addr(),
+		User:           "banserver",
+		KeyFile:        keyFile,
+		KnownHostsFile: writeKnownHosts(t, dir, server),
+	}, echo)
+	require.NoError(t, err)
+	defer tunnel.Close()
+
+	assertEcho(t, tunnel.Addr(), "hello")
+	assertEcho(t, tunnel.Addr(), "second connection")
+
+	// the ssh connection is established again
+	server.DropConnections()
+	assertEcho(t, tunnel.Addr(), "after reconnect")
+
+	require.NoError(t, tunnel.Close())
+	_, err = net.DialTimeout("tcp", tunnel.Addr(), time.Second)
+	require.Error(t, err)
+}
+
+func TestTunnelJumpHost(t *testing.T) {
+	dir := t.TempDir()
+	keyFile, pub := writeClientKey(t, dir)
+	jump := newSSHServer(t, pub)
+	server := newSSHServer(t, pub)
+	knownHosts := writeKnownHosts(t, dir, jump, server)
+	echo := newEchoServer(t)
+
+	tunnel, err := sshtunnel.Open(sshtunnel.Config{
+		Address:        server.Addr(),
+		User:           "banserver",
+		KeyFile:        keyFile,
+		KnownHostsFile: knownHosts,
+		Jump: &sshtunnel.Config{
+			Address:        jump.Addr(),
+			User:           "jump",
+			KeyFile:        keyFile,
+			KnownHostsFile: knownHosts,
+		},
+	}, echo)
+	require.NoError(t, err)
+	defer tunnel.Close()
+
+	assertEcho(t, tunnel.Addr(), "via jump host")
+}
+
+func TestTunnelErrors(t *testing.T) {
+	dir := t.TempDir()
+	keyFile, pub := writeClientKey(t, dir)
+	otherKeyFile, _ := writeClientKey(t, t.TempDir())
+	server := newSSHServer(t, pub)
+	other := newSSHServer(t, pub)
+	knownHosts := writeKnownHosts(t, dir, server)
+	echo := newEchoServer(t)
+
+	tests := []struct {
+		name string
+		cfg  sshtunnel.Config
+	}{
+		{"unknown host", sshtunnel.Config{Address: other.Addr(), User: "banserver", KeyFile: keyFile, KnownHostsFile: knownHosts}},
+		{"unauthorized key", sshtunnel.Config{Address: server.Addr(), User: "banserver", KeyFile: otherKeyFile, KnownHostsFile: knownHosts}},
+		{"missing key", sshtunnel.Config{Address: server.Addr(), User: "banserver", KeyFile: filepath.Join(dir, "missing"), KnownHostsFile: knownHosts}},
+		{"missing user", sshtunnel.Config{Address: server.Addr(), KeyFile: keyFile, KnownHostsFile: knownHosts}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := sshtunnel.Open(tt.cfg, echo)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		s        string
+		wantUser string
+		wantAddr string
+	}{
+		{"example.com", "", "example.com:22"},
+		{"root@example.com", "root", "example.com:22"},
+		{"root@example.com:2222", "root", "example.com:2222"},
+		{"root@[2001:db8::1]", "root", "[2001:db8::1]:22"},
+		{"[2001:db8::1]:2222", "", "[2001:db8::1]:2222"},
+	}
+
+	for _, tt := range tests {
+		user, addr, err := sshtunnel.ParseAddress(tt.s)
+		require.NoError(t, err, tt.s)
+		assert.Equal(t, tt.wantUser, user, tt.s)
+		assert.Equal(t, tt.wantAddr, addr, tt.s)
+	}
+
+	_, _, err := sshtunnel.ParseAddress("root@")
+	require.Error(t, err)
+}